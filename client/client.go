@@ -0,0 +1,76 @@
+// Package client calls the pg_timetable REST API. Types and the client are generated by oapi-codegen from
+// internal/api/openapi.json, run go generate after changing the document.
+//
+//	c, err := client.New("http://localhost:8008", os.Getenv("PGTT_TOKEN"))
+//	chainID, status := 42, "failed"
+//	resp, err := c.ListRunsWithResponse(ctx, &client.ListRunsParams{ChainId: &chainID, Status: &status})
+//	if resp.JSON200 == nil { // the status other than 200, see resp.StatusCode() and resp.Body
+//	}
+package client
+
+//go:generate go run github.com/oapi-codegen/oapi-codegen/v2/cmd/oapi-codegen@v2.8.0 -config oapi-codegen.yaml ../internal/api/openapi.json
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// New returns the client of the REST API at the base URL, e.g. http://localhost:8008. The bearer token is sent
+// if not empty, i.e. if authentication is configured. Use WithHTTPClient for the client certificate
+func New(baseURL, token string, opts ...ClientOption) (*ClientWithResponses, error) {
+	if token != "" {
+		opts = append([]ClientOption{WithRequestEditorFn(func(_ context.Context, req *http.Request) error {
+			req.Header.Set("Authorization", "Bearer "+token)
+			return nil
+		})}, opts...)
+	}
+	return NewClientWithResponses(baseURL, opts...)
+}
+
+// Error is returned by StreamEvents for responses with the status other than 2xx
+type Error struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("pg_timetable REST API: %d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), e.Message)
+}
+
+// StreamEvents calls fn for every scheduler event until the context is canceled, the server closes the stream
+// or fn returns the error, GET /api/v1/events. Events sent while the client reconnects are lost
+func StreamEvents(ctx context.Context, c ClientInterface, params *StreamEventsParams, fn func(Event) error) error {
+	resp, err := c.StreamEvents(ctx, params)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return &Error{StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(msg))}
+	}
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+		if !ok {
+			continue // event type, id, keep-alive comments and separators, the type is in the data as well
+		}
+		var e Event
+		if err = json.Unmarshal([]byte(data), &e); err != nil {
+			return err
+		}
+		if err = fn(e); err != nil {
+			return err
+		}
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return scanner.Err()
+}
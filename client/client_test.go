@@ -0,0 +1,109 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v1/runs", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		assert.Equal(t, url.Values{"chain_id": {"42"}, "from": {"2026-01-02T00:00:00Z"}, "status": {"failed"}}, r.URL.Query())
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"items": [{"run_id": 7, "chain_id": 42, "status": "FAILED"}], "next_cursor": "next"}`)
+	})
+	mux.HandleFunc("POST /startchain", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "42", r.URL.Query().Get("id"))
+		body, _ := io.ReadAll(r.Body)
+		if string(body) != "null" {
+			assert.JSONEq(t, `{"report": {"day": "2026-01-01"}}`, string(body))
+			assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		}
+	})
+	mux.HandleFunc("GET /api/v1/chains/{id}", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "not found", http.StatusNotFound)
+	})
+	mux.HandleFunc("DELETE /api/v1/tasks/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	ctx := context.Background()
+	c, err := New(srv.URL+"/", "secret")
+	require.NoError(t, err)
+
+	chainID, status, from := 42, "failed", time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)
+	runs, err := c.ListRunsWithResponse(ctx, &ListRunsParams{ChainId: &chainID, Status: &status, From: &from})
+	require.NoError(t, err)
+	require.NotNil(t, runs.JSON200)
+	assert.Equal(t, int64(7), runs.JSON200.Items[0].RunId)
+	assert.Equal(t, "next", *runs.JSON200.NextCursor)
+
+	started, err := c.StartChainWithResponse(ctx, &StartChainParams{Id: 42}, Overrides{"report": map[string]string{"day": "2026-01-01"}})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, started.StatusCode())
+	started, err = c.StartChainWithResponse(ctx, &StartChainParams{Id: 42}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, started.StatusCode())
+	deleted, err := c.DeleteTaskWithResponse(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNoContent, deleted.StatusCode())
+
+	chain, err := c.GetChainWithResponse(ctx, 1)
+	require.NoError(t, err)
+	assert.Nil(t, chain.JSON200)
+	assert.Equal(t, http.StatusNotFound, chain.StatusCode())
+	assert.Equal(t, "not found\n", string(chain.Body))
+}
+
+func TestStreamEvents(t *testing.T) {
+	chainID, eventType := 42, "chain_failed"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/forbidden/") {
+			http.Error(w, "forbidden for the chain", http.StatusForbidden)
+			return
+		}
+		assert.Equal(t, "type=chain_failed", r.URL.RawQuery)
+		w.Header().Set("Content-Type", "text/event-stream")
+		for i, status := range []string{"FAILED", "CANCELLED"} {
+			data, _ := json.Marshal(Event{Id: int64(i + 1), Type: ChainFailed, ChainId: &chainID, Status: &status})
+			_, _ = io.WriteString(w, ": keep-alive\n\nid: 1\nevent: chain_failed\ndata: "+string(data)+"\n\n")
+		}
+	}))
+	defer srv.Close()
+
+	c, err := New(srv.URL, "")
+	require.NoError(t, err)
+	var events []Event
+	err = StreamEvents(context.Background(), c, &StreamEventsParams{Type: &eventType}, func(e Event) error {
+		events = append(events, e)
+		return nil
+	})
+	assert.NoError(t, err, "stream closed by the server")
+	require.Len(t, events, 2)
+	assert.Equal(t, "CANCELLED", *events[1].Status)
+
+	stop := errors.New("stop")
+	err = StreamEvents(context.Background(), c, &StreamEventsParams{Type: &eventType}, func(Event) error { return stop })
+	assert.ErrorIs(t, err, stop)
+
+	forbidden, err := New(srv.URL+"/forbidden", "")
+	require.NoError(t, err)
+	err = StreamEvents(context.Background(), forbidden, nil, func(Event) error { return nil })
+	var apiErr *Error
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, http.StatusForbidden, apiErr.StatusCode)
+}
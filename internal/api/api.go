@@ -1,52 +1,240 @@
 package api
 
 import (
+	"cmp"
 	"context"
-	"fmt"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net"
 	"net/http"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/cybertec-postgresql/pg_timetable/internal/config"
 	"github.com/cybertec-postgresql/pg_timetable/internal/log"
+	"github.com/cybertec-postgresql/pg_timetable/internal/pgengine"
+	"github.com/cybertec-postgresql/pg_timetable/internal/scheduler"
 )
 
 // RestHandler is a common interface describing the current status of a connection
 type RestHandler interface {
 	IsReady() bool
-	StartChain(context.Context, int) error
+	StartChain(context.Context, int, pgengine.ParamOverrides) error
 	StopChain(context.Context, int) error
+	Status() scheduler.Status
 }
 
+const (
+	defaultMaxBodySize = 1 << 20
+	shutdownTimeout    = 5 * time.Second
+)
+
 type RestAPIServer struct {
-	APIHandler RestHandler
-	l          log.LoggerIface
+	APIHandler   RestHandler
+	ChainStore   ChainStore
+	HistoryStore HistoryStore
+	Events       EventSource
+	GRPCHandler  http.Handler // gRPC server sharing the REST API port, e.g. *grpc.Server
+	l            log.LoggerIface
+	auth         *AuthConfig // nil if authentication is not configured
+	tlsConfig    *tls.Config // nil if HTTPS is not enabled, http.Server modifies its own copy
+	started      bool
+	stopping     chan struct{} // closed on shutdown to finish event streams
+	routeErr     error         // routes missing in the OpenAPI document, the server is not started if any
 	http.Server
 }
 
 func Init(opts config.RestAPIOpts, logger log.LoggerIface) *RestAPIServer {
 	mux := http.NewServeMux()
 	s := &RestAPIServer{
+		nil,
+		nil,
+		nil,
+		nil,
 		nil,
 		logger,
+		nil,
+		nil,
+		false,
+		make(chan struct{}),
+		nil,
 		http.Server{
-			Addr:           fmt.Sprintf(":%d", opts.Port),
+			Addr:           net.JoinHostPort(opts.Address, strconv.Itoa(opts.Port)),
 			ReadTimeout:    10 * time.Second,
 			WriteTimeout:   10 * time.Second,
 			MaxHeaderBytes: 1 << 20,
-			Handler:        mux,
+			Handler:        withCORS(opts.CORSOrigins, withBodyLimit(cmp.Or(opts.MaxBodySize, defaultMaxBodySize), mux)),
 		},
 	}
 	mux.HandleFunc("/liveness", s.livenessHandler)
 	mux.HandleFunc("/readiness", s.readinessHandler)
-	mux.HandleFunc("/startchain", s.chainHandler)
-	mux.HandleFunc("/stopchain", s.chainHandler)
-	if opts.Port != 0 {
-		logger.WithField("port", opts.Port).Info("Starting REST API server...")
-		go func() { logger.Error(s.ListenAndServe()) }()
+	mux.HandleFunc("GET /api/v1/openapi.json", s.openAPIHandler)
+	s.handle(mux, "GET /api/v1/status", RoleReadOnly, listScope, s.statusHandler)
+	s.handle(mux, "GET /api/v1/events", RoleReadOnly, chainFromOptionalQuery("chain_id"), s.eventsHandler)
+	s.handle(mux, "POST /startchain", RoleOperator, chainFromQuery("id"), s.chainHandler)
+	s.handle(mux, "POST /stopchain", RoleOperator, chainFromQuery("id"), s.chainHandler)
+	s.handleChainStore(mux)
+	s.handleHistory(mux)
+	s.handleUI(mux)
+	s.RegisterOnShutdown(func() { close(s.stopping) })
+	if opts.GRPC && opts.GRPCPort == 0 {
+		s.Handler = s.withGRPC(s.Handler)
+		s.Protocols = new(http.Protocols)
+		s.Protocols.SetHTTP1(true)
+		s.Protocols.SetHTTP2(true)
+		s.Protocols.SetUnencryptedHTTP2(true) // gRPC clients use HTTP/2 with prior knowledge without TLS
+	}
+	if opts.Port == 0 {
+		return s
+	}
+	if s.routeErr != nil {
+		logger.WithError(s.routeErr).Error("REST API routes do not match the OpenAPI document, REST API server is not started")
+		return s
 	}
+	s.auth = LoadAuth(opts, logger, "REST API")
+	if opts.TLSCert != "" {
+		tlsConfig, err := NewServerTLSConfig(opts, logger)
+		if err != nil {
+			logger.WithError(err).Error("Cannot load REST API certificate, REST API server is not started")
+			return s
+		}
+		s.tlsConfig, s.TLSConfig = tlsConfig, tlsConfig.Clone()
+	}
+	logger.WithField("address", s.Addr).WithField("tls", s.TLSConfig != nil).Info("Starting REST API server...")
+	s.started = true
+	go func() {
+		var err error
+		if s.TLSConfig != nil {
+			err = s.ListenAndServeTLS("", "") // the certificate is provided by TLSConfig.GetCertificate
+		} else {
+			err = s.ListenAndServe()
+		}
+		if !errors.Is(err, http.ErrServerClosed) {
+			logger.Error(err)
+		}
+	}()
 	return s
 }
 
+// LoadAuth returns users of the auth file, nil if authentication is not configured. If the file cannot be used,
+// the empty configuration is returned, so all requests are rejected
+func LoadAuth(opts config.RestAPIOpts, logger log.LoggerIface, name string) *AuthConfig {
+	if opts.AuthFile == "" {
+		logger.Warningf("%s authentication is not configured, all requests are allowed", name)
+		return nil
+	}
+	auth, err := LoadAuthConfig(opts.AuthFile)
+	if err != nil { // fail closed: nobody is allowed if the auth file cannot be used
+		logger.WithError(err).Errorf("Cannot load %s auth file, all requests will be rejected", name)
+		return &AuthConfig{}
+	}
+	return auth
+}
+
+// NewServerTLSConfig returns the TLS configuration with the certificate reloaded on change and optional
+// verification of client certificates
+func NewServerTLSConfig(opts config.RestAPIOpts, logger log.LoggerIface) (*tls.Config, error) {
+	reloader, err := newCertReloader(opts.TLSCert, opts.TLSKey, logger)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig := &tls.Config{GetCertificate: reloader.GetCertificate, MinVersion: tls.VersionTLS12}
+	if opts.ClientCA != "" {
+		pool, err := loadCertPool(opts.ClientCA)
+		if err != nil {
+			logger.WithError(err).Error("Cannot load client CA, client certificates will be rejected")
+			pool = x509.NewCertPool()
+		}
+		tlsConfig.ClientCAs, tlsConfig.ClientAuth = pool, tls.VerifyClientCertIfGiven
+	}
+	return tlsConfig, nil
+}
+
+// Started returns true if the REST API server is listening, e.g. the port is set and the certificate is loaded
+func (Server *RestAPIServer) Started() bool {
+	return Server.started
+}
+
+// Auth returns REST API users, nil if authentication is not configured
+func (Server *RestAPIServer) Auth() *AuthConfig {
+	return Server.auth
+}
+
+// ServerTLSConfig returns the copy of the REST API TLS configuration, nil if HTTPS is not enabled
+func (Server *RestAPIServer) ServerTLSConfig() *tls.Config {
+	if Server.tlsConfig == nil {
+		return nil
+	}
+	return Server.tlsConfig.Clone()
+}
+
+// withGRPC routes gRPC requests to GRPCHandler, once it is set, bypassing REST API middleware
+func (Server *RestAPIServer) withGRPC(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if Server.GRPCHandler == nil || r.ProtoMajor != 2 || !strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc") {
+			next.ServeHTTP(w, r)
+			return
+		}
+		rc := http.NewResponseController(w)
+		_ = rc.SetReadDeadline(time.Time{}) // streaming calls outlive the server timeouts
+		_ = rc.SetWriteDeadline(time.Time{})
+		Server.GRPCHandler.ServeHTTP(w, r)
+	})
+}
+
+// Stop gracefully shuts down the REST API server waiting for active requests to complete
+func (Server *RestAPIServer) Stop() {
+	if !Server.started {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	Server.l.Info("Shutting down REST API server...")
+	if err := Server.Shutdown(ctx); err != nil {
+		Server.l.WithError(err).Error("REST API server shutdown failed")
+	}
+}
+
+// withBodyLimit rejects request bodies larger than limit bytes
+func withBodyLimit(limit int64, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ContentLength > limit {
+			http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, limit)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// withCORS allows browsers to call the API from the origins listed, "*" allows any origin.
+// Preflight requests are answered without authentication, since browsers never send credentials with them
+func withCORS(origins []string, next http.Handler) http.Handler {
+	if len(origins) == 0 {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin == "" || !slices.ContainsFunc(origins, func(o string) bool { return o == "*" || o == origin }) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+		w.Header().Add("Vary", "Origin")
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE")
+			w.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type")
+			w.Header().Set("Access-Control-Max-Age", "600")
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
 func (Server *RestAPIServer) livenessHandler(w http.ResponseWriter, _ *http.Request) {
 	Server.l.Debug("Received /liveness REST API request")
 	w.WriteHeader(http.StatusOK) // i'm serving hence I'm alive
@@ -60,3 +248,19 @@ func (Server *RestAPIServer) readinessHandler(w http.ResponseWriter, _ *http.Req
 	}
 	w.WriteHeader(http.StatusOK)
 }
+
+func (Server *RestAPIServer) statusHandler(w http.ResponseWriter, r *http.Request) {
+	Server.l.Debug("Received /api/v1/status REST API request")
+	if Server.APIHandler == nil {
+		http.Error(w, "scheduler is not running", http.StatusServiceUnavailable)
+		return
+	}
+	status := Server.APIHandler.Status()
+	status.ActiveChains = slices.DeleteFunc(status.ActiveChains, func(c scheduler.ActiveChainStatus) bool {
+		return !canAccess(r, c.ChainID)
+	})
+	status.IntervalChains = slices.DeleteFunc(status.IntervalChains, func(c scheduler.IntervalChainStatus) bool {
+		return !canAccess(r, c.ChainID)
+	})
+	writeJSON(w, http.StatusOK, status)
+}
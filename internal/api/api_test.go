@@ -4,22 +4,28 @@ import (
 	"context"
 	"errors"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
+	"strings"
 	"testing"
 
 	"github.com/cybertec-postgresql/pg_timetable/internal/config"
 	"github.com/cybertec-postgresql/pg_timetable/internal/log"
+	"github.com/cybertec-postgresql/pg_timetable/internal/pgengine"
+	"github.com/cybertec-postgresql/pg_timetable/internal/scheduler"
 	"github.com/stretchr/testify/assert"
 )
 
 type apihandler struct {
+	overrides pgengine.ParamOverrides
 }
 
 func (r *apihandler) IsReady() bool {
 	return true
 }
 
-func (r *apihandler) StartChain(_ context.Context, chainID int) error {
+func (r *apihandler) StartChain(_ context.Context, chainID int, overrides pgengine.ParamOverrides) error {
+	r.overrides = overrides
 	if chainID == 0 {
 		return errors.New("invalid chain id")
 	}
@@ -30,6 +36,11 @@ func (r *apihandler) StopChain(context.Context, int) error {
 	return nil
 }
 
+func (r *apihandler) Status() scheduler.Status {
+	return scheduler.Status{Ready: true, ActiveChains: []scheduler.ActiveChainStatus{{ChainID: 42, Task: "1|foo"}},
+		IntervalChains: []scheduler.IntervalChainStatus{}}
+}
+
 var restsrv = Init(config.RestAPIOpts{Port: 8080}, log.Init(config.LoggingOpts{LogLevel: "panic"}))
 
 const turl = "http://localhost:8080/"
@@ -42,18 +53,105 @@ func TestStatus(t *testing.T) {
 	assert.HTTPSuccess(t, restsrv.readinessHandler, "GET", turl+"readiness", nil)
 }
 
+func TestStatusEndpoint(t *testing.T) {
+	restsrv.APIHandler = nil
+	assert.Equal(t, http.StatusServiceUnavailable, serve("GET", "/api/v1/status", "").Code)
+
+	restsrv.APIHandler = &apihandler{}
+	w := serve("GET", "/api/v1/status", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"ready":true`)
+	assert.Contains(t, w.Body.String(), `"current_task":"1|foo"`)
+}
+
 func TestChainManager(t *testing.T) {
 	restsrv.APIHandler = &apihandler{}
-	assert.HTTPStatusCode(t, restsrv.chainHandler, "GET", turl+"startchain", nil, http.StatusBadRequest)
-	assert.HTTPBodyContains(t, restsrv.chainHandler, "GET", turl+"startchain", nil, "invalid syntax")
+	assert.HTTPStatusCode(t, restsrv.chainHandler, "POST", turl+"startchain", nil, http.StatusBadRequest)
+	assert.HTTPBodyContains(t, restsrv.chainHandler, "POST", turl+"startchain", nil, "invalid syntax")
 
-	assert.HTTPSuccess(t, restsrv.chainHandler, "GET", turl+"startchain",
+	assert.HTTPSuccess(t, restsrv.chainHandler, "POST", turl+"startchain",
 		url.Values{"id": []string{"1"}})
-	assert.HTTPSuccess(t, restsrv.chainHandler, "GET", turl+"stopchain",
+	assert.HTTPSuccess(t, restsrv.chainHandler, "POST", turl+"stopchain",
 		url.Values{"id": []string{"1"}})
 
-	assert.HTTPError(t, restsrv.chainHandler, "GET", turl+"startchain",
+	assert.HTTPError(t, restsrv.chainHandler, "POST", turl+"startchain",
 		url.Values{"id": []string{"0"}})
-	assert.HTTPBodyContains(t, restsrv.chainHandler, "GET", turl+"startchain",
+	assert.HTTPBodyContains(t, restsrv.chainHandler, "POST", turl+"startchain",
 		url.Values{"id": []string{"0"}}, "invalid chain id")
 }
+
+func TestStartChainOverrides(t *testing.T) {
+	h := &apihandler{}
+	restsrv.APIHandler = h
+	assert.Equal(t, http.StatusOK, serve("POST", "/startchain?id=1", `{"report": {"day": "2026-01-01"}}`).Code)
+	assert.JSONEq(t, `{"day": "2026-01-01"}`, string(h.overrides["report"]))
+
+	assert.Equal(t, http.StatusOK, serve("POST", "/startchain?id=1", "").Code)
+	assert.Equal(t, http.StatusOK, serve("POST", "/startchain?id=1", "null").Code, "sent by the generated client without overrides")
+	assert.Nil(t, h.overrides)
+
+	w := serve("POST", "/startchain?id=1", `["not", "an", "object"]`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "invalid request body: value must be an object")
+}
+
+func TestServerHardening(t *testing.T) {
+	restsrv.APIHandler = &apihandler{}
+	assert.Equal(t, http.StatusMethodNotAllowed, serve("GET", "/startchain?id=1", "").Code)
+	assert.Equal(t, http.StatusOK, serve("POST", "/startchain?id=1", "").Code)
+
+	srv := Init(config.RestAPIOpts{Address: "127.0.0.1", Port: 0, MaxBodySize: 10, CORSOrigins: []string{"https://ui.example.com"}},
+		log.Init(config.LoggingOpts{LogLevel: "panic"}))
+	assert.Equal(t, "127.0.0.1:0", srv.Addr)
+	srv.ChainStore = &chainstore{}
+
+	w := httptest.NewRecorder()
+	srv.Handler.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/chains", strings.NewReader(`{"name": "too long body"}`)))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+
+	r := httptest.NewRequest("OPTIONS", "/startchain", nil)
+	r.Header.Set("Origin", "https://ui.example.com")
+	r.Header.Set("Access-Control-Request-Method", "POST")
+	w = httptest.NewRecorder()
+	srv.Handler.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "https://ui.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Contains(t, w.Header().Get("Access-Control-Allow-Headers"), "Authorization")
+
+	r = httptest.NewRequest("GET", "/liveness", nil)
+	r.Header.Set("Origin", "https://evil.example.com")
+	w = httptest.NewRecorder()
+	srv.Handler.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+
+	srv.Stop() // not started, nothing to do
+}
+
+func TestGRPCRouting(t *testing.T) {
+	srv := Init(config.RestAPIOpts{GRPC: true}, log.Init(config.LoggingOpts{LogLevel: "panic"}))
+	assert.True(t, srv.Protocols.UnencryptedHTTP2())
+	grpcRequest := func(protoMajor int) *http.Request {
+		r := httptest.NewRequest("POST", "/pgtimetable.v1.Timetable/StopChain", nil)
+		r.ProtoMajor = protoMajor
+		r.Header.Set("Content-Type", "application/grpc")
+		return r
+	}
+
+	w := httptest.NewRecorder()
+	srv.Handler.ServeHTTP(w, grpcRequest(2))
+	assert.Equal(t, http.StatusNotFound, w.Code, "gRPC handler is not set yet")
+
+	srv.GRPCHandler = http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusTeapot) })
+	w = httptest.NewRecorder()
+	srv.Handler.ServeHTTP(w, grpcRequest(2))
+	assert.Equal(t, http.StatusTeapot, w.Code)
+
+	w = httptest.NewRecorder()
+	srv.Handler.ServeHTTP(w, grpcRequest(1))
+	assert.Equal(t, http.StatusNotFound, w.Code, "gRPC requires HTTP/2")
+
+	w = httptest.NewRecorder()
+	srv.Handler.ServeHTTP(w, httptest.NewRequest("GET", "/liveness", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
@@ -0,0 +1,264 @@
+package api
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Role specifies the set of REST API operations allowed to the user
+type Role int
+
+const (
+	// RoleReadOnly allows to get chains, history and status
+	RoleReadOnly Role = iota + 1
+	// RoleOperator additionally allows to start, stop, pause and resume chains
+	RoleOperator
+	// RoleAdmin additionally allows to create, update and delete chains, tasks and parameters
+	RoleAdmin
+)
+
+var roleNames = map[Role]string{RoleReadOnly: "read-only", RoleOperator: "operator", RoleAdmin: "admin"}
+
+func (r Role) String() string {
+	return roleNames[r]
+}
+
+// UnmarshalYAML parses the role name
+func (r *Role) UnmarshalYAML(value *yaml.Node) error {
+	for role, name := range roleNames {
+		if value.Value == name {
+			*r = role
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown role %q, must be read-only, operator or admin", value.Value)
+}
+
+// User is the REST API client identified by the bearer token or the client certificate
+type User struct {
+	Name        string `yaml:"name"`
+	TokenSHA256 string `yaml:"token_sha256"` // hex encoded SHA-256 hash of the bearer token
+	CertCN      string `yaml:"cert_cn"`      // common name of the verified client certificate
+	Role        Role   `yaml:"role"`
+	Chains      []int  `yaml:"chains"` // IDs of chains the user is restricted to, all chains if empty
+	tokenHash   []byte
+}
+
+// AuthConfig lists users allowed to access the REST API. Nobody is allowed if there are no users
+type AuthConfig struct {
+	Users []User `yaml:"users"`
+}
+
+// LoadAuthConfig reads and validates the file with REST API users
+func LoadAuthConfig(filename string) (*AuthConfig, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var c AuthConfig
+	if err := yaml.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("failed to parse REST API auth file: %w", err)
+	}
+	for i := range c.Users {
+		if err := c.Users[i].validate(); err != nil {
+			return nil, fmt.Errorf("REST API user %d: %w", i+1, err)
+		}
+	}
+	return &c, nil
+}
+
+func (u *User) validate() (err error) {
+	switch {
+	case u.Name == "":
+		return fmt.Errorf("name is required")
+	case u.Role == 0:
+		return fmt.Errorf("role is required")
+	case u.TokenSHA256 == "" && u.CertCN == "":
+		return fmt.Errorf("token_sha256 or cert_cn is required")
+	}
+	if u.TokenSHA256 != "" {
+		if u.tokenHash, err = hex.DecodeString(u.TokenSHA256); err != nil || len(u.tokenHash) != sha256.Size {
+			return fmt.Errorf("token_sha256 must be hex encoded SHA-256 hash")
+		}
+	}
+	return nil
+}
+
+// authenticate returns the user of the request identified by the bearer token or the verified client certificate
+func (c *AuthConfig) authenticate(r *http.Request) *User {
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		return c.AuthenticateToken(token)
+	}
+	return c.AuthenticateCert(r.TLS)
+}
+
+// AuthenticateToken returns the user with the bearer token, nil if there is none
+func (c *AuthConfig) AuthenticateToken(token string) *User {
+	hash := sha256.Sum256([]byte(strings.TrimSpace(token)))
+	for i, u := range c.Users {
+		if u.tokenHash != nil && subtle.ConstantTimeCompare(hash[:], u.tokenHash) == 1 {
+			return &c.Users[i]
+		}
+	}
+	return nil
+}
+
+// AuthenticateCert returns the user with the common name of the verified client certificate, nil if there is none
+func (c *AuthConfig) AuthenticateCert(state *tls.ConnectionState) *User {
+	if state == nil || len(state.VerifiedChains) == 0 {
+		return nil
+	}
+	cn := state.VerifiedChains[0][0].Subject.CommonName
+	for i, u := range c.Users {
+		if u.CertCN != "" && u.CertCN == cn {
+			return &c.Users[i]
+		}
+	}
+	return nil
+}
+
+// CanAccess returns true if the user is not restricted to some chains or the chain is one of them
+func (u *User) CanAccess(chainID int) bool {
+	return len(u.Chains) == 0 || slices.Contains(u.Chains, chainID)
+}
+
+// chainScope returns the ID of the chain the request refers to, false if the request is not about one chain
+type chainScope func(r *http.Request) (int, bool)
+
+// anyChain is the scope of requests listing chains, handlers filter the response with canAccess
+const anyChain = -1
+
+// listScope allows the list request to users restricted to chains, see anyChain
+func listScope(*http.Request) (int, bool) {
+	return anyChain, true
+}
+
+// chainFromPath returns the chain ID from the {id} path wildcard
+func chainFromPath(r *http.Request) (int, bool) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	return id, err == nil
+}
+
+// chainFromQuery returns the chain ID from the query parameter
+func chainFromQuery(param string) chainScope {
+	return func(r *http.Request) (int, bool) {
+		id, err := strconv.Atoi(r.URL.Query().Get(param))
+		return id, err == nil
+	}
+}
+
+// statusRecorder remembers the status code of the response for the audit
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (rec *statusRecorder) WriteHeader(code int) {
+	rec.status = code
+	rec.ResponseWriter.WriteHeader(code)
+}
+
+// Unwrap allows http.ResponseController to flush the underlying writer, e.g. for event streams
+func (rec *statusRecorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
+
+// handle registers the handler allowed to users with the role or higher. Users restricted to chains are allowed
+// only if the request refers to one of their chains. Requests are validated against the OpenAPI document
+// before the handler is called, undocumented routes are not registered and prevent the server start.
+// Calls changing the state, i.e. above read-only, are audited
+func (Server *RestAPIServer) handle(mux *http.ServeMux, pattern string, role Role, scope chainScope, handler http.HandlerFunc) {
+	route, err := routeOf(pattern)
+	if err != nil {
+		Server.routeErr = errors.Join(Server.routeErr, err)
+		return
+	}
+	mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		user := "anonymous"
+		if u, ok := Server.authorize(rec, r, role, scope); ok {
+			if u != nil {
+				user = u.Name
+				r = r.WithContext(context.WithValue(r.Context(), userKey{}, u))
+			}
+			if Server.validateRequest(rec, r, route) {
+				handler(rec, r)
+			}
+		}
+		if role > RoleReadOnly {
+			Server.l.WithField("user", user).
+				WithField("method", r.Method).
+				WithField("path", r.URL.RequestURI()).
+				WithField("remote", r.RemoteAddr).
+				WithField("status", rec.status).
+				Info("REST API call audited")
+		}
+	})
+}
+
+// authorize checks the user of the request is allowed to call the handler. Responds with 401 or 403 otherwise
+func (Server *RestAPIServer) authorize(w http.ResponseWriter, r *http.Request, role Role, scope chainScope) (*User, bool) {
+	if Server.auth == nil { // authentication is not configured
+		return nil, true
+	}
+	u := Server.auth.authenticate(r)
+	if u == nil {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="pg_timetable"`)
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return nil, false
+	}
+	if u.Role < role {
+		http.Error(w, fmt.Sprintf("forbidden for the %s role", u.Role), http.StatusForbidden)
+		return u, false
+	}
+	if len(u.Chains) > 0 {
+		if chainID, ok := scopeOf(scope, r); !ok || chainID != anyChain && !u.CanAccess(chainID) {
+			http.Error(w, "forbidden for the chain", http.StatusForbidden)
+			return u, false
+		}
+	}
+	return u, true
+}
+
+type userKey struct{}
+
+// chainFromOptionalQuery returns the chain ID from the query parameter, or anyChain without the parameter
+func chainFromOptionalQuery(param string) chainScope {
+	return func(r *http.Request) (int, bool) {
+		if !r.URL.Query().Has(param) {
+			return anyChain, true
+		}
+		return chainFromQuery(param)(r)
+	}
+}
+
+// userOf returns the authenticated user of the request, nil if authentication is not configured
+func userOf(r *http.Request) *User {
+	u, _ := r.Context().Value(userKey{}).(*User)
+	return u
+}
+
+// canAccess returns true if the user of the request is not restricted to chains or the chain is one of them
+func canAccess(r *http.Request, chainID int) bool {
+	u := userOf(r)
+	return u == nil || u.CanAccess(chainID)
+}
+
+func scopeOf(scope chainScope, r *http.Request) (int, bool) {
+	if scope == nil {
+		return 0, false
+	}
+	return scope(r)
+}
@@ -0,0 +1,176 @@
+package api
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cybertec-postgresql/pg_timetable/internal/config"
+	"github.com/cybertec-postgresql/pg_timetable/internal/log"
+	"github.com/cybertec-postgresql/pg_timetable/internal/scheduler"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func tokenHash(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
+
+func writeAuthFile(t *testing.T, content string) string {
+	filename := filepath.Join(t.TempDir(), "auth.yaml")
+	require.NoError(t, os.WriteFile(filename, []byte(content), 0600))
+	return filename
+}
+
+func TestLoadAuthConfig(t *testing.T) {
+	c, err := LoadAuthConfig(writeAuthFile(t, `
+users:
+  - name: viewer
+    token_sha256: `+tokenHash("viewer-secret")+`
+    role: read-only
+  - name: ci
+    cert_cn: ci.example.com
+    role: operator
+    chains: [1, 2]
+`))
+	require.NoError(t, err)
+	require.Len(t, c.Users, 2)
+	assert.Equal(t, RoleReadOnly, c.Users[0].Role)
+	assert.Equal(t, []int{1, 2}, c.Users[1].Chains)
+
+	_, err = LoadAuthConfig("nonexistent.yaml")
+	assert.Error(t, err)
+	for _, content := range []string{
+		"users: foo",
+		"users: [{name: foo, token_sha256: abc, role: admin}]",
+		"users: [{name: foo, cert_cn: foo, role: root}]",
+		"users: [{name: foo, role: admin}]",
+		"users: [{cert_cn: foo, role: admin}]",
+		"users: [{name: foo, cert_cn: foo}]",
+	} {
+		_, err = LoadAuthConfig(writeAuthFile(t, content))
+		assert.Error(t, err, content)
+	}
+}
+
+func TestAuthorization(t *testing.T) {
+	srv := Init(config.RestAPIOpts{}, log.Init(config.LoggingOpts{LogLevel: "panic"}))
+	srv.APIHandler = &apihandler{}
+	srv.auth = &AuthConfig{Users: []User{
+		{Name: "viewer", TokenSHA256: tokenHash("viewer-secret"), Role: RoleReadOnly},
+		{Name: "operator", TokenSHA256: tokenHash("operator-secret"), Role: RoleOperator, Chains: []int{1}},
+		{Name: "ci", CertCN: "ci.example.com", Role: RoleAdmin},
+	}}
+	for i := range srv.auth.Users {
+		require.NoError(t, srv.auth.Users[i].validate())
+	}
+
+	send := func(method, target, token, cn, body string) int {
+		r := httptest.NewRequest(method, target, strings.NewReader(body))
+		if token != "" {
+			r.Header.Set("Authorization", "Bearer "+token)
+		}
+		if cn != "" {
+			r.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{{Subject: pkix.Name{CommonName: cn}}}}}
+		}
+		w := httptest.NewRecorder()
+		srv.Handler.ServeHTTP(w, r)
+		return w.Code
+	}
+	call := func(method, target, token, cn string) int {
+		return send(method, target, token, cn, "")
+	}
+
+	assert.Equal(t, http.StatusOK, call("GET", "/liveness", "", ""), "health checks are always allowed")
+	assert.Equal(t, http.StatusUnauthorized, call("GET", "/api/v1/status", "", ""))
+	assert.Equal(t, http.StatusUnauthorized, call("GET", "/api/v1/status", "wrong", ""))
+	assert.Equal(t, http.StatusUnauthorized, call("GET", "/api/v1/status", "", "unknown.example.com"))
+
+	assert.Equal(t, http.StatusOK, call("GET", "/api/v1/status", "viewer-secret", ""))
+	assert.Equal(t, http.StatusForbidden, call("POST", "/startchain?id=1", "viewer-secret", ""))
+
+	assert.Equal(t, http.StatusOK, call("POST", "/startchain?id=1", "operator-secret", ""))
+	assert.Equal(t, http.StatusForbidden, call("POST", "/startchain?id=2", "operator-secret", ""), "chain is out of scope")
+	assert.Equal(t, http.StatusForbidden, call("GET", "/api/v1/runs", "operator-secret", ""), "endpoint is not about one chain")
+
+	assert.Equal(t, http.StatusOK, call("POST", "/startchain?id=2", "", "ci.example.com"))
+	assert.Equal(t, http.StatusOK, call("POST", "/stopchain?id=2", "", "ci.example.com"))
+
+	overrides := `{"report": {"day": "2026-01-01"}}`
+	assert.Equal(t, http.StatusForbidden, send("POST", "/startchain?id=1", "operator-secret", "", overrides), "overrides require admin")
+	assert.Equal(t, http.StatusOK, send("POST", "/startchain?id=1", "operator-secret", "", "{}"))
+	assert.Equal(t, http.StatusOK, send("POST", "/startchain?id=1", "", "ci.example.com", overrides))
+}
+
+func TestChainScope(t *testing.T) {
+	srv := Init(config.RestAPIOpts{}, log.Init(config.LoggingOpts{LogLevel: "panic"}))
+	srv.APIHandler = &apihandler{}
+	srv.ChainStore = newChainStore()   // chain 1 with task 1
+	srv.HistoryStore = &historystore{} // chain 42 with run 1
+	srv.Events = &eventsource{events: []scheduler.Event{
+		{ID: 1, Type: scheduler.EventChainStarted, ChainID: 1},
+		{ID: 2, Type: scheduler.EventChainStarted, ChainID: 42},
+		{ID: 3, Type: scheduler.EventLog, Message: "not about a chain"},
+	}}
+	srv.auth = &AuthConfig{Users: []User{
+		{Name: "viewer", TokenSHA256: tokenHash("viewer-secret"), Role: RoleReadOnly, Chains: []int{1}},
+		{Name: "admin", TokenSHA256: tokenHash("admin-secret"), Role: RoleAdmin, Chains: []int{42}},
+	}}
+	for i := range srv.auth.Users {
+		require.NoError(t, srv.auth.Users[i].validate())
+	}
+	get := func(target, token string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("GET", target, nil)
+		r.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		srv.Handler.ServeHTTP(w, r)
+		return w
+	}
+
+	w := get("/api/v1/chains", "viewer-secret")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `[{"id": 1, "name": "foo", "schedule": "* * * * *", "live": false}]`, w.Body.String())
+	w = get("/api/v1/chains", "admin-secret")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `[]`, w.Body.String(), "chains out of scope are filtered")
+
+	w = get("/api/v1/overview", "viewer-secret")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `[]`, w.Body.String())
+	w = get("/api/v1/overview", "admin-secret")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"chain_id":42`)
+
+	w = get("/api/v1/status", "viewer-secret")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"active_chains":[]`)
+	w = get("/api/v1/status", "admin-secret")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"chain_id":42`)
+
+	assert.Equal(t, http.StatusOK, get("/api/v1/tasks/1", "viewer-secret").Code)
+	assert.Equal(t, http.StatusOK, get("/api/v1/tasks/1/parameters", "viewer-secret").Code)
+	assert.Equal(t, http.StatusForbidden, get("/api/v1/tasks/1", "admin-secret").Code, "task of the chain out of scope")
+	assert.Equal(t, http.StatusForbidden, get("/api/v1/tasks/2", "viewer-secret").Code, "unknown task")
+
+	assert.Equal(t, http.StatusOK, get("/api/v1/runs/1/executions", "admin-secret").Code)
+	assert.Equal(t, http.StatusForbidden, get("/api/v1/runs/1/executions", "viewer-secret").Code, "run of the chain out of scope")
+	assert.Equal(t, http.StatusForbidden, get("/api/v1/runs/2/executions", "admin-secret").Code, "unknown run")
+
+	w = get("/api/v1/events", "viewer-secret")
+	assert.Equal(t, http.StatusOK, w.Code, "the dashboard subscribes without chain_id")
+	assert.Contains(t, w.Body.String(), "id: 1\n")
+	assert.NotContains(t, w.Body.String(), "id: 2\n", "events of chains out of scope are filtered")
+	assert.NotContains(t, w.Body.String(), "id: 3\n")
+	assert.Equal(t, http.StatusOK, get("/api/v1/events?chain_id=1", "viewer-secret").Code)
+	assert.Equal(t, http.StatusForbidden, get("/api/v1/events?chain_id=42", "viewer-secret").Code)
+}
@@ -1,8 +1,14 @@
 package api
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"strconv"
+
+	"github.com/cybertec-postgresql/pg_timetable/internal/pgengine"
 )
 
 func (Server *RestAPIServer) chainHandler(w http.ResponseWriter, r *http.Request) {
@@ -14,7 +20,16 @@ func (Server *RestAPIServer) chainHandler(w http.ResponseWriter, r *http.Request
 	}
 	switch r.URL.Path {
 	case "/startchain":
-		err = Server.APIHandler.StartChain(r.Context(), chainID)
+		var overrides pgengine.ParamOverrides
+		if overrides, err = decodeOverrides(r); err != nil {
+			http.Error(w, "invalid overrides: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if u := userOf(r); u != nil && len(overrides) > 0 && u.Role < RoleAdmin {
+			http.Error(w, fmt.Sprintf("parameter overrides are forbidden for the %s role", u.Role), http.StatusForbidden)
+			return
+		}
+		err = Server.APIHandler.StartChain(r.Context(), chainID, overrides)
 	case "/stopchain":
 		err = Server.APIHandler.StopChain(r.Context(), chainID)
 	}
@@ -24,3 +39,14 @@ func (Server *RestAPIServer) chainHandler(w http.ResponseWriter, r *http.Request
 	}
 	w.WriteHeader(http.StatusOK)
 }
+
+// decodeOverrides reads the optional JSON object with task parameter overrides from the request body
+func decodeOverrides(r *http.Request) (overrides pgengine.ParamOverrides, err error) {
+	if r.Body == nil {
+		return nil, nil
+	}
+	if err = json.NewDecoder(r.Body).Decode(&overrides); errors.Is(err, io.EOF) {
+		return nil, nil // empty body
+	}
+	return
+}
@@ -0,0 +1,471 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/cybertec-postgresql/pg_timetable/internal/pgengine"
+	pgx "github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// ChainStore manages definitions of chains, tasks and parameters stored in the timetable schema.
+// Methods return pgx.ErrNoRows if the chain or task doesn't exist
+type ChainStore interface {
+	SelectYamlChains(ctx context.Context) ([]pgengine.YamlChain, error)
+	SelectYamlChain(ctx context.Context, chainID int) (pgengine.YamlChain, error)
+	AddYamlChain(ctx context.Context, chain *pgengine.YamlChain) (int64, error)
+	UpdateYamlChain(ctx context.Context, chainID int, chain *pgengine.YamlChain) error
+	DeleteYamlChain(ctx context.Context, chainID int) error
+	SetChainLive(ctx context.Context, chainID int, live bool) error
+	SelectYamlTask(ctx context.Context, taskID int) (pgengine.YamlTask, error)
+	SelectTaskChainID(ctx context.Context, taskID int) (int, error)
+	AddYamlTask(ctx context.Context, chainID int, task *pgengine.YamlTask) (int64, error)
+	UpdateYamlTask(ctx context.Context, taskID int, task *pgengine.YamlTask) error
+	DeleteYamlTask(ctx context.Context, taskID int) error
+	SelectTaskParameters(ctx context.Context, taskID int) ([]any, error)
+	SetTaskParameters(ctx context.Context, taskID int, params []any) error
+}
+
+// Chain is the JSON representation of the chain, field names follow the YAML chain definition
+type Chain struct {
+	ID           int    `json:"id"`
+	Name         string `json:"name"`
+	Schedule     string `json:"schedule"`
+	Live         bool   `json:"live"`
+	ClientName   string `json:"client_name,omitempty"`
+	MaxInstances int    `json:"max_instances,omitempty"`
+	Timeout      int    `json:"timeout,omitempty"`
+	SelfDestruct bool   `json:"self_destruct,omitempty"`
+	Exclusive    bool   `json:"exclusive,omitempty"`
+	OnError      string `json:"on_error,omitempty"`
+	Tasks        []Task `json:"tasks,omitempty"`
+}
+
+// Task is the JSON representation of the chain task, field names follow the YAML task definition
+type Task struct {
+	ID            int                 `json:"id"`
+	Name          string              `json:"name,omitempty"`
+	Kind          string              `json:"kind,omitempty"`
+	Command       string              `json:"command"`
+	RunAs         string              `json:"run_as,omitempty"`
+	ConnectString string              `json:"connect_string,omitempty"`
+	Connection    string              `json:"connection,omitempty"`
+	IgnoreError   bool                `json:"ignore_error,omitempty"`
+	Autonomous    bool                `json:"autonomous,omitempty"`
+	Timeout       int                 `json:"timeout,omitempty"`
+	SuccessCodes  []int               `json:"success_codes,omitempty"`
+	WarningCodes  []int               `json:"warning_codes,omitempty"`
+	CaptureRows   int                 `json:"capture_rows,omitempty"`
+	Assert        *pgengine.Assertion `json:"assert,omitempty"`
+	Live          *bool               `json:"live,omitempty"`
+	Parameters    []any               `json:"parameters,omitempty"`
+}
+
+func chainFromYaml(c pgengine.YamlChain) Chain {
+	chain := Chain{
+		ID:           c.ChainID,
+		Name:         c.ChainName,
+		Schedule:     c.Schedule,
+		Live:         c.Live,
+		ClientName:   c.ClientName,
+		MaxInstances: c.MaxInstances,
+		Timeout:      c.Timeout,
+		SelfDestruct: c.SelfDestruct,
+		Exclusive:    c.ExclusiveExecution,
+		OnError:      c.OnError,
+	}
+	for _, t := range c.Tasks {
+		chain.Tasks = append(chain.Tasks, taskFromYaml(t))
+	}
+	return chain
+}
+
+func (c Chain) toYaml() pgengine.YamlChain {
+	chain := pgengine.YamlChain{
+		Chain: pgengine.Chain{
+			ChainName:          c.Name,
+			MaxInstances:       c.MaxInstances,
+			Timeout:            c.Timeout,
+			SelfDestruct:       c.SelfDestruct,
+			ExclusiveExecution: c.Exclusive,
+			OnError:            c.OnError,
+		},
+		ClientName: c.ClientName,
+		Schedule:   c.Schedule,
+		Live:       c.Live,
+	}
+	for _, t := range c.Tasks {
+		chain.Tasks = append(chain.Tasks, t.toYaml())
+	}
+	return chain
+}
+
+func taskFromYaml(t pgengine.YamlTask) Task {
+	return Task{
+		ID:            t.TaskID,
+		Name:          t.TaskName,
+		Kind:          t.Kind,
+		Command:       t.Command,
+		RunAs:         t.RunAs,
+		ConnectString: t.ConnectString,
+		Connection:    t.ConnectionName,
+		IgnoreError:   t.IgnoreError,
+		Autonomous:    t.Autonomous,
+		Timeout:       t.Timeout,
+		SuccessCodes:  t.SuccessCodes,
+		WarningCodes:  t.WarningCodes,
+		CaptureRows:   t.CaptureRows,
+		Assert:        t.Assertion,
+		Live:          t.Live,
+		Parameters:    t.Parameters,
+	}
+}
+
+func (t Task) toYaml() pgengine.YamlTask {
+	return pgengine.YamlTask{
+		ChainTask: pgengine.ChainTask{
+			TaskID:         t.ID,
+			Kind:           t.Kind,
+			Command:        t.Command,
+			RunAs:          t.RunAs,
+			ConnectString:  t.ConnectString,
+			ConnectionName: t.Connection,
+			IgnoreError:    t.IgnoreError,
+			Autonomous:     t.Autonomous,
+			Timeout:        t.Timeout,
+			SuccessCodes:   t.SuccessCodes,
+			WarningCodes:   t.WarningCodes,
+			CaptureRows:    t.CaptureRows,
+			Assertion:      t.Assert,
+		},
+		TaskName:   t.Name,
+		Live:       t.Live,
+		Parameters: t.Parameters,
+	}
+}
+
+// handleChainStore registers the versioned API to manage chains, tasks and parameters
+func (Server *RestAPIServer) handleChainStore(mux *http.ServeMux) {
+	Server.handle(mux, "GET /api/v1/chains", RoleReadOnly, listScope, Server.listChainsHandler)
+	Server.handle(mux, "POST /api/v1/chains", RoleAdmin, nil, Server.createChainHandler)
+	Server.handle(mux, "GET /api/v1/chains/{id}", RoleReadOnly, chainFromPath, Server.getChainHandler)
+	Server.handle(mux, "PUT /api/v1/chains/{id}", RoleAdmin, chainFromPath, Server.updateChainHandler)
+	Server.handle(mux, "DELETE /api/v1/chains/{id}", RoleAdmin, chainFromPath, Server.deleteChainHandler)
+	Server.handle(mux, "POST /api/v1/chains/{id}/pause", RoleOperator, chainFromPath, Server.pauseChainHandler)
+	Server.handle(mux, "POST /api/v1/chains/{id}/resume", RoleOperator, chainFromPath, Server.resumeChainHandler)
+	Server.handle(mux, "GET /api/v1/chains/{id}/tasks", RoleReadOnly, chainFromPath, Server.listTasksHandler)
+	Server.handle(mux, "POST /api/v1/chains/{id}/tasks", RoleAdmin, chainFromPath, Server.createTaskHandler)
+	Server.handle(mux, "GET /api/v1/tasks/{id}", RoleReadOnly, Server.chainFromTask, Server.getTaskHandler)
+	Server.handle(mux, "PUT /api/v1/tasks/{id}", RoleAdmin, Server.chainFromTask, Server.updateTaskHandler)
+	Server.handle(mux, "DELETE /api/v1/tasks/{id}", RoleAdmin, Server.chainFromTask, Server.deleteTaskHandler)
+	Server.handle(mux, "GET /api/v1/tasks/{id}/parameters", RoleReadOnly, Server.chainFromTask, Server.getParametersHandler)
+	Server.handle(mux, "PUT /api/v1/tasks/{id}/parameters", RoleAdmin, Server.chainFromTask, Server.setParametersHandler)
+}
+
+// chainFromTask returns the ID of the chain the task from the {id} path wildcard belongs to
+func (Server *RestAPIServer) chainFromTask(r *http.Request) (int, bool) {
+	taskID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil || Server.ChainStore == nil {
+		return 0, false
+	}
+	chainID, err := Server.ChainStore.SelectTaskChainID(r.Context(), taskID)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		Server.l.WithError(err).Error("Cannot resolve the chain of the task")
+	}
+	return chainID, err == nil
+}
+
+func (Server *RestAPIServer) listChainsHandler(w http.ResponseWriter, r *http.Request) {
+	if !Server.storeAvailable(w, r) {
+		return
+	}
+	yamlChains, err := Server.ChainStore.SelectYamlChains(r.Context())
+	if err != nil {
+		Server.storeError(w, err)
+		return
+	}
+	chains := make([]Chain, 0, len(yamlChains))
+	for _, c := range yamlChains {
+		if canAccess(r, c.ChainID) {
+			chains = append(chains, chainFromYaml(c))
+		}
+	}
+	writeJSON(w, http.StatusOK, chains)
+}
+
+func (Server *RestAPIServer) getChainHandler(w http.ResponseWriter, r *http.Request) {
+	chainID, ok := Server.pathID(w, r)
+	if !ok {
+		return
+	}
+	Server.writeChain(w, r, http.StatusOK, chainID)
+}
+
+func (Server *RestAPIServer) createChainHandler(w http.ResponseWriter, r *http.Request) {
+	if !Server.storeAvailable(w, r) {
+		return
+	}
+	yamlChain, ok := decodeChain(w, r)
+	if !ok {
+		return
+	}
+	chainID, err := Server.ChainStore.AddYamlChain(r.Context(), &yamlChain)
+	if err != nil {
+		Server.storeError(w, err)
+		return
+	}
+	w.Header().Set("Location", fmt.Sprintf("/api/v1/chains/%d", chainID))
+	Server.writeChain(w, r, http.StatusCreated, int(chainID))
+}
+
+func (Server *RestAPIServer) updateChainHandler(w http.ResponseWriter, r *http.Request) {
+	chainID, ok := Server.pathID(w, r)
+	if !ok {
+		return
+	}
+	yamlChain, ok := decodeChain(w, r)
+	if !ok {
+		return
+	}
+	if err := Server.ChainStore.UpdateYamlChain(r.Context(), chainID, &yamlChain); err != nil {
+		Server.storeError(w, err)
+		return
+	}
+	Server.writeChain(w, r, http.StatusOK, chainID)
+}
+
+func (Server *RestAPIServer) deleteChainHandler(w http.ResponseWriter, r *http.Request) {
+	if chainID, ok := Server.pathID(w, r); ok {
+		Server.writeNoContent(w, Server.ChainStore.DeleteYamlChain(r.Context(), chainID))
+	}
+}
+
+func (Server *RestAPIServer) pauseChainHandler(w http.ResponseWriter, r *http.Request) {
+	if chainID, ok := Server.pathID(w, r); ok {
+		Server.writeNoContent(w, Server.ChainStore.SetChainLive(r.Context(), chainID, false))
+	}
+}
+
+func (Server *RestAPIServer) resumeChainHandler(w http.ResponseWriter, r *http.Request) {
+	if chainID, ok := Server.pathID(w, r); ok {
+		Server.writeNoContent(w, Server.ChainStore.SetChainLive(r.Context(), chainID, true))
+	}
+}
+
+func (Server *RestAPIServer) listTasksHandler(w http.ResponseWriter, r *http.Request) {
+	chainID, ok := Server.pathID(w, r)
+	if !ok {
+		return
+	}
+	yamlChain, err := Server.ChainStore.SelectYamlChain(r.Context(), chainID)
+	if err != nil {
+		Server.storeError(w, err)
+		return
+	}
+	tasks := make([]Task, 0, len(yamlChain.Tasks))
+	for _, t := range yamlChain.Tasks {
+		tasks = append(tasks, taskFromYaml(t))
+	}
+	writeJSON(w, http.StatusOK, tasks)
+}
+
+func (Server *RestAPIServer) getTaskHandler(w http.ResponseWriter, r *http.Request) {
+	taskID, ok := Server.pathID(w, r)
+	if !ok {
+		return
+	}
+	Server.writeTask(w, r, http.StatusOK, taskID)
+}
+
+func (Server *RestAPIServer) createTaskHandler(w http.ResponseWriter, r *http.Request) {
+	chainID, ok := Server.pathID(w, r)
+	if !ok {
+		return
+	}
+	yamlTask, ok := decodeTask(w, r)
+	if !ok {
+		return
+	}
+	taskID, err := Server.ChainStore.AddYamlTask(r.Context(), chainID, &yamlTask)
+	if err != nil {
+		Server.storeError(w, err)
+		return
+	}
+	w.Header().Set("Location", fmt.Sprintf("/api/v1/tasks/%d", taskID))
+	Server.writeTask(w, r, http.StatusCreated, int(taskID))
+}
+
+func (Server *RestAPIServer) updateTaskHandler(w http.ResponseWriter, r *http.Request) {
+	taskID, ok := Server.pathID(w, r)
+	if !ok {
+		return
+	}
+	yamlTask, ok := decodeTask(w, r)
+	if !ok {
+		return
+	}
+	if err := Server.ChainStore.UpdateYamlTask(r.Context(), taskID, &yamlTask); err != nil {
+		Server.storeError(w, err)
+		return
+	}
+	Server.writeTask(w, r, http.StatusOK, taskID)
+}
+
+func (Server *RestAPIServer) deleteTaskHandler(w http.ResponseWriter, r *http.Request) {
+	if taskID, ok := Server.pathID(w, r); ok {
+		Server.writeNoContent(w, Server.ChainStore.DeleteYamlTask(r.Context(), taskID))
+	}
+}
+
+func (Server *RestAPIServer) getParametersHandler(w http.ResponseWriter, r *http.Request) {
+	taskID, ok := Server.pathID(w, r)
+	if !ok {
+		return
+	}
+	params, err := Server.ChainStore.SelectTaskParameters(r.Context(), taskID)
+	if err != nil {
+		Server.storeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, params)
+}
+
+func (Server *RestAPIServer) setParametersHandler(w http.ResponseWriter, r *http.Request) {
+	taskID, ok := Server.pathID(w, r)
+	if !ok {
+		return
+	}
+	var params []any
+	if !decodeBody(w, r, &params) {
+		return
+	}
+	if err := Server.ChainStore.SetTaskParameters(r.Context(), taskID, params); err != nil {
+		Server.storeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, params)
+}
+
+// storeAvailable responds with 503 if the chain store is not set yet, e.g. the database is not connected
+func (Server *RestAPIServer) storeAvailable(w http.ResponseWriter, r *http.Request) bool {
+	Server.l.WithField("path", r.URL.Path).Debug("Received chain store REST API request")
+	if Server.ChainStore == nil {
+		http.Error(w, "chain store is not available", http.StatusServiceUnavailable)
+		return false
+	}
+	return true
+}
+
+// pathID returns the ID of the chain or task from the request path
+func (Server *RestAPIServer) pathID(w http.ResponseWriter, r *http.Request) (int, bool) {
+	if !Server.storeAvailable(w, r) {
+		return 0, false
+	}
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return 0, false
+	}
+	return id, true
+}
+
+// storeError maps the chain store error to the HTTP status
+func (Server *RestAPIServer) storeError(w http.ResponseWriter, err error) {
+	var pgErr *pgconn.PgError
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		http.Error(w, "not found", http.StatusNotFound)
+	case errors.Is(err, pgengine.ErrTaskNotInChain):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.As(err, &pgErr) && pgErr.Code == "23505": // unique_violation
+		http.Error(w, pgErr.Message, http.StatusConflict)
+	case errors.As(err, &pgErr) && (strings.HasPrefix(pgErr.Code, "22") || strings.HasPrefix(pgErr.Code, "23")):
+		http.Error(w, pgErr.Message, http.StatusBadRequest) // data exception or integrity constraint violation
+	default:
+		Server.l.WithError(err).Error("Chain store REST API request failed")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (Server *RestAPIServer) writeChain(w http.ResponseWriter, r *http.Request, status int, chainID int) {
+	yamlChain, err := Server.ChainStore.SelectYamlChain(r.Context(), chainID)
+	if err != nil {
+		Server.storeError(w, err)
+		return
+	}
+	writeJSON(w, status, chainFromYaml(yamlChain))
+}
+
+func (Server *RestAPIServer) writeTask(w http.ResponseWriter, r *http.Request, status int, taskID int) {
+	yamlTask, err := Server.ChainStore.SelectYamlTask(r.Context(), taskID)
+	if err != nil {
+		Server.storeError(w, err)
+		return
+	}
+	writeJSON(w, status, taskFromYaml(yamlTask))
+}
+
+func (Server *RestAPIServer) writeNoContent(w http.ResponseWriter, err error) {
+	if err != nil {
+		Server.storeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// decodeChain reads the chain from the request body, sets defaults and validates it as the YAML chain
+func decodeChain(w http.ResponseWriter, r *http.Request) (pgengine.YamlChain, bool) {
+	var chain Chain
+	if !decodeBody(w, r, &chain) {
+		return pgengine.YamlChain{}, false
+	}
+	yamlChain := chain.toYaml()
+	yamlChain.SetDefaults()
+	if err := yamlChain.ValidateChain(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return yamlChain, false
+	}
+	return yamlChain, true
+}
+
+// decodeTask reads the task from the request body, sets defaults and validates it as the YAML task
+func decodeTask(w http.ResponseWriter, r *http.Request) (pgengine.YamlTask, bool) {
+	var task Task
+	if !decodeBody(w, r, &task) {
+		return pgengine.YamlTask{}, false
+	}
+	yamlTask := task.toYaml()
+	yamlTask.SetDefaults()
+	if err := yamlTask.ValidateTask(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return yamlTask, false
+	}
+	return yamlTask, true
+}
+
+func decodeBody(w http.ResponseWriter, r *http.Request, dest any) bool {
+	dec := json.NewDecoder(r.Body) // the size is limited by withBodyLimit
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(dest); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+			return false
+		}
+		http.Error(w, "invalid request body: "+err.Error(), http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
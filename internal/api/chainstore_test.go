@@ -0,0 +1,229 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cybertec-postgresql/pg_timetable/internal/pgengine"
+	pgx "github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// chainstore keeps chains in memory, chain with ID 1 has the task with ID 1
+type chainstore struct {
+	chain  pgengine.YamlChain
+	params []any
+}
+
+func newChainStore() *chainstore {
+	live := true
+	return &chainstore{chain: pgengine.YamlChain{
+		Chain:    pgengine.Chain{ChainID: 1, ChainName: "foo"},
+		Schedule: "* * * * *",
+		Tasks: []pgengine.YamlTask{{
+			ChainTask: pgengine.ChainTask{TaskID: 1, Command: "SELECT $1", Kind: "SQL"},
+			Live:      &live,
+		}},
+	}, params: []any{"bar"}}
+}
+
+func (s *chainstore) SelectYamlChains(context.Context) ([]pgengine.YamlChain, error) {
+	c := s.chain
+	c.Tasks = nil
+	return []pgengine.YamlChain{c}, nil
+}
+
+func (s *chainstore) SelectYamlChain(_ context.Context, chainID int) (pgengine.YamlChain, error) {
+	if chainID != s.chain.ChainID {
+		return pgengine.YamlChain{}, pgx.ErrNoRows
+	}
+	return s.chain, nil
+}
+
+func (s *chainstore) AddYamlChain(_ context.Context, chain *pgengine.YamlChain) (int64, error) {
+	if chain.ChainName == s.chain.ChainName {
+		return 0, &pgconn.PgError{Code: "23505", Message: "duplicate key value violates unique constraint"}
+	}
+	s.chain = *chain
+	s.chain.ChainID = 2
+	return 2, nil
+}
+
+func (s *chainstore) UpdateYamlChain(_ context.Context, chainID int, chain *pgengine.YamlChain) error {
+	if chainID != s.chain.ChainID {
+		return pgx.ErrNoRows
+	}
+	s.chain = *chain
+	s.chain.ChainID = chainID
+	return nil
+}
+
+func (s *chainstore) DeleteYamlChain(_ context.Context, chainID int) error {
+	if chainID != s.chain.ChainID {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+func (s *chainstore) SetChainLive(_ context.Context, chainID int, live bool) error {
+	if chainID != s.chain.ChainID {
+		return pgx.ErrNoRows
+	}
+	s.chain.Live = live
+	return nil
+}
+
+func (s *chainstore) SelectYamlTask(_ context.Context, taskID int) (pgengine.YamlTask, error) {
+	for _, t := range s.chain.Tasks {
+		if t.TaskID == taskID {
+			return t, nil
+		}
+	}
+	return pgengine.YamlTask{}, pgx.ErrNoRows
+}
+
+func (s *chainstore) SelectTaskChainID(ctx context.Context, taskID int) (int, error) {
+	_, err := s.SelectYamlTask(ctx, taskID)
+	return s.chain.ChainID, err
+}
+
+func (s *chainstore) AddYamlTask(_ context.Context, chainID int, task *pgengine.YamlTask) (int64, error) {
+	if chainID != s.chain.ChainID {
+		return 0, pgx.ErrNoRows
+	}
+	task.TaskID = len(s.chain.Tasks) + 1
+	s.chain.Tasks = append(s.chain.Tasks, *task)
+	return int64(task.TaskID), nil
+}
+
+func (s *chainstore) UpdateYamlTask(_ context.Context, taskID int, task *pgengine.YamlTask) error {
+	return errors.New("connection lost")
+}
+
+func (s *chainstore) DeleteYamlTask(_ context.Context, taskID int) error {
+	_, err := s.SelectYamlTask(context.Background(), taskID)
+	return err
+}
+
+func (s *chainstore) SelectTaskParameters(_ context.Context, taskID int) ([]any, error) {
+	return s.params, nil
+}
+
+func (s *chainstore) SetTaskParameters(_ context.Context, taskID int, params []any) error {
+	s.params = params
+	return nil
+}
+
+func serve(method, target, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	restsrv.Handler.ServeHTTP(w, httptest.NewRequest(method, target, strings.NewReader(body)))
+	return w
+}
+
+func TestChainStoreUnavailable(t *testing.T) {
+	restsrv.ChainStore = nil
+	assert.Equal(t, http.StatusServiceUnavailable, serve("GET", "/api/v1/chains", "").Code)
+	assert.Equal(t, http.StatusServiceUnavailable, serve("GET", "/api/v1/tasks/1", "").Code)
+}
+
+func TestChainsAPI(t *testing.T) {
+	store := newChainStore()
+	restsrv.ChainStore = store
+	defer func() { restsrv.ChainStore = nil }()
+
+	w := serve("GET", "/api/v1/chains", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `[{"id": 1, "name": "foo", "schedule": "* * * * *", "live": false}]`, w.Body.String())
+
+	w = serve("GET", "/api/v1/chains/1", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	var chain Chain
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &chain))
+	assert.Equal(t, "SELECT $1", chain.Tasks[0].Command)
+
+	assert.Equal(t, http.StatusNotFound, serve("GET", "/api/v1/chains/42", "").Code)
+	assert.Equal(t, http.StatusBadRequest, serve("GET", "/api/v1/chains/foo", "").Code)
+	assert.Equal(t, http.StatusMethodNotAllowed, serve("PATCH", "/api/v1/chains/1", "").Code)
+
+	w = serve("POST", "/api/v1/chains", `{"name": "bar", "schedule": "@every 1 minute"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "chain must have at least one task")
+
+	w = serve("POST", "/api/v1/chains", `{"name": "bar", "unknown": true}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "invalid request body")
+
+	w = serve("POST", "/api/v1/chains", `{"name": "foo", "tasks": [{"command": "SELECT 1"}]}`)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	w = serve("POST", "/api/v1/chains", `{"name": "bar", "tasks": [{"command": "SELECT 1"}]}`)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "/api/v1/chains/2", w.Header().Get("Location"))
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &chain))
+	assert.Equal(t, "* * * * *", chain.Schedule, "default schedule expected")
+	assert.Equal(t, "SQL", chain.Tasks[0].Kind, "default kind expected")
+
+	w = serve("PUT", "/api/v1/chains/2", `{"name": "baz", "schedule": "0 * * * *", "tasks": [{"command": "SELECT 2"}]}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "baz", store.chain.ChainName)
+	w = serve("PUT", "/api/v1/chains/2", `{"name": "baz", "tasks": [{"id": 7, "command": "SELECT 2"}]}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 7, store.chain.Tasks[0].TaskID, "task ID should be passed to update the task in place")
+	assert.Equal(t, http.StatusNotFound, serve("PUT", "/api/v1/chains/42", `{"name": "baz", "tasks": [{"command": "SELECT 2"}]}`).Code)
+
+	assert.Equal(t, http.StatusNoContent, serve("POST", "/api/v1/chains/2/resume", "").Code)
+	assert.True(t, store.chain.Live)
+	assert.Equal(t, http.StatusNoContent, serve("POST", "/api/v1/chains/2/pause", "").Code)
+	assert.False(t, store.chain.Live)
+	assert.Equal(t, http.StatusNotFound, serve("POST", "/api/v1/chains/42/pause", "").Code)
+
+	assert.Equal(t, http.StatusNoContent, serve("DELETE", "/api/v1/chains/2", "").Code)
+	assert.Equal(t, http.StatusNotFound, serve("DELETE", "/api/v1/chains/42", "").Code)
+}
+
+func TestTasksAPI(t *testing.T) {
+	restsrv.ChainStore = newChainStore()
+	defer func() { restsrv.ChainStore = nil }()
+
+	w := serve("GET", "/api/v1/chains/1/tasks", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `[{"id": 1, "kind": "SQL", "command": "SELECT $1", "live": true}]`, w.Body.String())
+	assert.Equal(t, http.StatusNotFound, serve("GET", "/api/v1/chains/42/tasks", "").Code)
+
+	w = serve("POST", "/api/v1/chains/1/tasks", `{"command": "ls", "kind": "PROGRAM", "assert": {"min_rows": 1}}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "only supported for SQL tasks")
+
+	w = serve("POST", "/api/v1/chains/1/tasks", `{"command": "ls", "kind": "PROGRAM"}`)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "/api/v1/tasks/2", w.Header().Get("Location"))
+	assert.Equal(t, http.StatusNotFound, serve("POST", "/api/v1/chains/42/tasks", `{"command": "ls"}`).Code)
+
+	assert.Equal(t, http.StatusOK, serve("GET", "/api/v1/tasks/2", "").Code)
+	assert.Equal(t, http.StatusNotFound, serve("GET", "/api/v1/tasks/42", "").Code)
+	assert.Equal(t, http.StatusInternalServerError, serve("PUT", "/api/v1/tasks/2", `{"command": "ls"}`).Code)
+	assert.Equal(t, http.StatusNoContent, serve("DELETE", "/api/v1/tasks/2", "").Code)
+	assert.Equal(t, http.StatusNotFound, serve("DELETE", "/api/v1/tasks/42", "").Code)
+}
+
+func TestParametersAPI(t *testing.T) {
+	restsrv.ChainStore = newChainStore()
+	defer func() { restsrv.ChainStore = nil }()
+
+	w := serve("GET", "/api/v1/tasks/1/parameters", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `["bar"]`, w.Body.String())
+
+	w = serve("PUT", "/api/v1/tasks/1/parameters", `[{"foo": 1}, [1, 2]]`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `[{"foo": 1}, [1, 2]]`, serve("GET", "/api/v1/tasks/1/parameters", "").Body.String())
+
+	assert.Equal(t, http.StatusBadRequest, serve("PUT", "/api/v1/tasks/1/parameters", `{"foo": 1}`).Code)
+}
@@ -0,0 +1,102 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cybertec-postgresql/pg_timetable/internal/scheduler"
+)
+
+// EventSource provides scheduler events to stream, see scheduler.EventBus
+type EventSource interface {
+	Subscribe(size int) (<-chan scheduler.Event, func())
+}
+
+const (
+	eventBufferSize = 256              // events buffered for the slow client before they are dropped
+	eventKeepAlive  = 15 * time.Second // comment sent to idle streams, so proxies don't close them
+)
+
+// eventFilter selects events of the chain and of the types requested, all events if empty
+type eventFilter struct {
+	chainID int
+	types   []scheduler.EventType
+}
+
+func parseEventFilter(r *http.Request) (f eventFilter, err error) {
+	query := r.URL.Query()
+	if s := query.Get("chain_id"); s != "" {
+		if f.chainID, err = strconv.Atoi(s); err != nil {
+			return f, fmt.Errorf("invalid chain_id: %w", err)
+		}
+	}
+	if s := query.Get("type"); s != "" {
+		for t := range strings.SplitSeq(s, ",") {
+			if !slices.Contains(scheduler.EventTypes, scheduler.EventType(t)) {
+				return f, fmt.Errorf("unknown event type %q", t)
+			}
+			f.types = append(f.types, scheduler.EventType(t))
+		}
+	}
+	return f, nil
+}
+
+func (f eventFilter) match(e scheduler.Event) bool {
+	return (f.chainID == 0 || f.chainID == e.ChainID) && (len(f.types) == 0 || slices.Contains(f.types, e.Type))
+}
+
+// eventsHandler streams scheduler events as Server-Sent Events until the client disconnects or the server stops.
+// Users restricted to chains get events of their chains only
+func (Server *RestAPIServer) eventsHandler(w http.ResponseWriter, r *http.Request) {
+	Server.l.Debug("Received /api/v1/events REST API request")
+	if Server.Events == nil {
+		http.Error(w, "scheduler is not running", http.StatusServiceUnavailable)
+		return
+	}
+	f, err := parseEventFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	rc := http.NewResponseController(w)
+	_ = rc.SetWriteDeadline(time.Time{}) // the stream outlives the server write timeout
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no") // disable buffering in nginx
+	w.WriteHeader(http.StatusOK)
+	if rc.Flush() != nil {
+		return
+	}
+
+	events, unsubscribe := Server.Events.Subscribe(eventBufferSize)
+	defer unsubscribe()
+	keepAlive := time.NewTicker(eventKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case e, ok := <-events:
+			if !ok {
+				return
+			}
+			if !f.match(e) || !canAccess(r, e.ChainID) {
+				continue
+			}
+			data, _ := json.Marshal(e)
+			_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.ID, e.Type, data)
+		case <-keepAlive.C:
+			_, err = fmt.Fprint(w, ": keep-alive\n\n")
+		case <-r.Context().Done():
+			return
+		case <-Server.stopping:
+			return
+		}
+		if err != nil || rc.Flush() != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/cybertec-postgresql/pg_timetable/internal/scheduler"
+	"github.com/stretchr/testify/assert"
+)
+
+// eventsource returns the channel with events published already, the channel is closed to finish the stream
+type eventsource struct {
+	events []scheduler.Event
+}
+
+func (s *eventsource) Subscribe(size int) (<-chan scheduler.Event, func()) {
+	ch := make(chan scheduler.Event, size)
+	for _, e := range s.events {
+		ch <- e
+	}
+	close(ch)
+	return ch, func() {}
+}
+
+func TestEventsAPI(t *testing.T) {
+	restsrv.Events = nil
+	assert.Equal(t, http.StatusServiceUnavailable, serve("GET", "/api/v1/events", "").Code)
+
+	restsrv.Events = &eventsource{events: []scheduler.Event{
+		{ID: 1, Type: scheduler.EventChainStarted, ChainID: 42, RunID: 7},
+		{ID: 2, Type: scheduler.EventLog, ChainID: 24, Message: "foo"},
+		{ID: 3, Type: scheduler.EventChainFinished, ChainID: 42, RunID: 7, Status: "SUCCEEDED"},
+	}}
+	defer func() { restsrv.Events = nil }()
+
+	w := serve("GET", "/api/v1/events", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/event-stream", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Body.String(), "id: 1\nevent: chain_started\ndata: {\"id\":1,\"type\":\"chain_started\"")
+	assert.Contains(t, w.Body.String(), "event: log\n")
+
+	w = serve("GET", "/api/v1/events?chain_id=42&type=chain_finished,chain_failed", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), "event: chain_started")
+	assert.NotContains(t, w.Body.String(), "event: log")
+	assert.Contains(t, w.Body.String(), `"status":"SUCCEEDED"`)
+
+	assert.Equal(t, http.StatusBadRequest, serve("GET", "/api/v1/events?type=foo", "").Code)
+	assert.Equal(t, http.StatusBadRequest, serve("GET", "/api/v1/events?chain_id=foo", "").Code)
+}
@@ -0,0 +1,171 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cybertec-postgresql/pg_timetable/internal/pgengine"
+	pgx "github.com/jackc/pgx/v5"
+)
+
+// HistoryStore returns chain runs, task executions and log entries page by page.
+// The cursor returned is empty for the last page
+type HistoryStore interface {
+	SelectChainRuns(ctx context.Context, f pgengine.HistoryFilter) ([]pgengine.ChainRun, string, error)
+	SelectExecutions(ctx context.Context, f pgengine.HistoryFilter) ([]pgengine.Execution, string, error)
+	SelectLogEntries(ctx context.Context, f pgengine.HistoryFilter) ([]pgengine.LogEntry, string, error)
+	SelectChainOverview(ctx context.Context) ([]pgengine.ChainOverview, error)
+	SelectRunChainID(ctx context.Context, runID int64) (int, error)
+}
+
+// Page is the JSON representation of history entries, use NextCursor to get the next page
+type Page[T any] struct {
+	Items      []T    `json:"items"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+var (
+	runStatuses       = []string{"running", "succeeded", "failed", "cancelled"}
+	executionStatuses = []string{"succeeded", "warning", "failed"}
+	logLevels         = []string{"debug", "notice", "info", "error", "panic", "user"}
+)
+
+// handleHistory registers the versioned API to browse run history and logs
+func (Server *RestAPIServer) handleHistory(mux *http.ServeMux) {
+	Server.handle(mux, "GET /api/v1/runs", RoleReadOnly, chainFromQuery("chain_id"), Server.runsHandler)
+	Server.handle(mux, "GET /api/v1/runs/{id}/executions", RoleReadOnly, Server.chainFromRun, Server.runExecutionsHandler)
+	Server.handle(mux, "GET /api/v1/executions", RoleReadOnly, chainFromQuery("chain_id"), Server.executionsHandler)
+	Server.handle(mux, "GET /api/v1/logs", RoleReadOnly, chainFromQuery("chain_id"), Server.logsHandler)
+	Server.handle(mux, "GET /api/v1/overview", RoleReadOnly, listScope, Server.overviewHandler)
+}
+
+// chainFromRun returns the ID of the chain the run from the {id} path wildcard belongs to
+func (Server *RestAPIServer) chainFromRun(r *http.Request) (int, bool) {
+	runID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil || Server.HistoryStore == nil {
+		return 0, false
+	}
+	chainID, err := Server.HistoryStore.SelectRunChainID(r.Context(), runID)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		Server.l.WithError(err).Error("Cannot resolve the chain of the run")
+	}
+	return chainID, err == nil
+}
+
+func (Server *RestAPIServer) runsHandler(w http.ResponseWriter, r *http.Request) {
+	if f, ok := Server.historyFilter(w, r, runStatuses); ok {
+		runs, cursor, err := Server.HistoryStore.SelectChainRuns(r.Context(), f)
+		writePage(Server, w, runs, cursor, err)
+	}
+}
+
+func (Server *RestAPIServer) runExecutionsHandler(w http.ResponseWriter, r *http.Request) {
+	f, ok := Server.historyFilter(w, r, executionStatuses)
+	if !ok {
+		return
+	}
+	var err error
+	if f.RunID, err = strconv.ParseInt(r.PathValue("id"), 10, 64); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	execs, cursor, err := Server.HistoryStore.SelectExecutions(r.Context(), f)
+	writePage(Server, w, execs, cursor, err)
+}
+
+func (Server *RestAPIServer) executionsHandler(w http.ResponseWriter, r *http.Request) {
+	if f, ok := Server.historyFilter(w, r, executionStatuses); ok {
+		execs, cursor, err := Server.HistoryStore.SelectExecutions(r.Context(), f)
+		writePage(Server, w, execs, cursor, err)
+	}
+}
+
+func (Server *RestAPIServer) logsHandler(w http.ResponseWriter, r *http.Request) {
+	if f, ok := Server.historyFilter(w, r, logLevels); ok {
+		entries, cursor, err := Server.HistoryStore.SelectLogEntries(r.Context(), f)
+		writePage(Server, w, entries, cursor, err)
+	}
+}
+
+// overviewHandler lists all chains with the next run time and the latest run, used by the dashboard
+func (Server *RestAPIServer) overviewHandler(w http.ResponseWriter, r *http.Request) {
+	Server.l.Debug("Received /api/v1/overview REST API request")
+	if Server.HistoryStore == nil {
+		http.Error(w, "history store is not available", http.StatusServiceUnavailable)
+		return
+	}
+	chains, err := Server.HistoryStore.SelectChainOverview(r.Context())
+	if err != nil {
+		Server.storeError(w, err)
+		return
+	}
+	chains = slices.DeleteFunc(chains, func(c pgengine.ChainOverview) bool { return !canAccess(r, c.ChainID) })
+	if chains == nil {
+		chains = []pgengine.ChainOverview{}
+	}
+	writeJSON(w, http.StatusOK, chains)
+}
+
+// historyFilter parses query parameters of the history request, responds with 400 if they are invalid
+func (Server *RestAPIServer) historyFilter(w http.ResponseWriter, r *http.Request, statuses []string) (f pgengine.HistoryFilter, ok bool) {
+	Server.l.WithField("path", r.URL.Path).Debug("Received history REST API request")
+	if Server.HistoryStore == nil {
+		http.Error(w, "history store is not available", http.StatusServiceUnavailable)
+		return f, false
+	}
+	f, err := parseHistoryFilter(r.URL.Query(), statuses)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return f, false
+	}
+	return f, true
+}
+
+func parseHistoryFilter(query url.Values, statuses []string) (f pgengine.HistoryFilter, err error) {
+	f.Client, f.Search, f.Cursor = query.Get("client"), query.Get("q"), query.Get("cursor")
+	if s := query.Get("chain_id"); s != "" {
+		if f.ChainID, err = strconv.Atoi(s); err != nil {
+			return f, fmt.Errorf("invalid chain_id: %w", err)
+		}
+	}
+	if s := query.Get("limit"); s != "" {
+		if f.Limit, err = strconv.Atoi(s); err != nil || f.Limit < 1 || f.Limit > pgengine.MaxHistoryLimit {
+			return f, fmt.Errorf("limit must be between 1 and %d", pgengine.MaxHistoryLimit)
+		}
+	}
+	if s := query.Get("from"); s != "" {
+		if f.From, err = time.Parse(time.RFC3339, s); err != nil {
+			return f, fmt.Errorf("invalid from: %w", err)
+		}
+	}
+	if s := query.Get("to"); s != "" {
+		if f.To, err = time.Parse(time.RFC3339, s); err != nil {
+			return f, fmt.Errorf("invalid to: %w", err)
+		}
+	}
+	if f.Status = strings.ToLower(query.Get("status")); f.Status != "" && !slices.Contains(statuses, f.Status) {
+		return f, fmt.Errorf("status must be one of: %s", strings.Join(statuses, ", "))
+	}
+	return f, nil
+}
+
+func writePage[T any](Server *RestAPIServer, w http.ResponseWriter, items []T, cursor string, err error) {
+	switch {
+	case errors.Is(err, pgengine.ErrInvalidCursor):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case err != nil:
+		Server.storeError(w, err)
+	default:
+		if items == nil {
+			items = []T{}
+		}
+		writeJSON(w, http.StatusOK, Page[T]{Items: items, NextCursor: cursor})
+	}
+}
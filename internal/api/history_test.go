@@ -0,0 +1,93 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/cybertec-postgresql/pg_timetable/internal/pgengine"
+	pgx "github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+// historystore remembers the last filter and returns a single entry with the next page cursor
+type historystore struct {
+	filter pgengine.HistoryFilter
+}
+
+func (s *historystore) SelectChainRuns(_ context.Context, f pgengine.HistoryFilter) ([]pgengine.ChainRun, string, error) {
+	s.filter = f
+	if f.Cursor == "bad" {
+		return nil, "", pgengine.ErrInvalidCursor
+	}
+	return []pgengine.ChainRun{{RunID: 1, ChainID: 42, Status: "FAILED"}}, "next", nil
+}
+
+func (s *historystore) SelectExecutions(_ context.Context, f pgengine.HistoryFilter) ([]pgengine.Execution, string, error) {
+	s.filter = f
+	return nil, "", nil
+}
+
+func (s *historystore) SelectLogEntries(_ context.Context, f pgengine.HistoryFilter) ([]pgengine.LogEntry, string, error) {
+	s.filter = f
+	return []pgengine.LogEntry{{Message: "foo"}}, "", nil
+}
+
+func (s *historystore) SelectChainOverview(context.Context) ([]pgengine.ChainOverview, error) {
+	return []pgengine.ChainOverview{{ChainID: 42, ChainName: "foo", Schedule: "* * * * *", Live: true,
+		LastRun: &pgengine.ChainRun{RunID: 1, ChainID: 42, Status: "FAILED"}}}, nil
+}
+
+func (s *historystore) SelectRunChainID(_ context.Context, runID int64) (int, error) {
+	if runID != 1 {
+		return 0, pgx.ErrNoRows
+	}
+	return 42, nil
+}
+
+func TestHistoryAPI(t *testing.T) {
+	assert.Equal(t, http.StatusServiceUnavailable, serve("GET", "/api/v1/runs", "").Code)
+
+	store := &historystore{}
+	restsrv.HistoryStore = store
+	defer func() { restsrv.HistoryStore = nil }()
+
+	w := serve("GET", "/api/v1/runs?chain_id=42&status=Failed&client=worker&q=disk&limit=10&from=2026-01-02T00:00:00Z", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"items": [{"run_id": 1, "chain_id": 42, "trigger": "", "started_at": "0001-01-01T00:00:00Z",
+		"status": "FAILED", "client_name": ""}], "next_cursor": "next"}`, w.Body.String())
+	assert.Equal(t, pgengine.HistoryFilter{ChainID: 42, Status: "failed", Client: "worker", Search: "disk", Limit: 10,
+		From: time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)}, store.filter)
+
+	assert.Equal(t, http.StatusBadRequest, serve("GET", "/api/v1/runs?cursor=bad", "").Code)
+	assert.Equal(t, http.StatusBadRequest, serve("GET", "/api/v1/runs?status=warning", "").Code)
+	assert.Equal(t, http.StatusBadRequest, serve("GET", "/api/v1/runs?limit=0", "").Code)
+	assert.Equal(t, http.StatusBadRequest, serve("GET", "/api/v1/runs?from=yesterday", "").Code)
+	assert.Equal(t, http.StatusBadRequest, serve("GET", "/api/v1/runs?chain_id=foo", "").Code)
+
+	w = serve("GET", "/api/v1/runs/7/executions?status=warning", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"items": []}`, w.Body.String())
+	assert.Equal(t, pgengine.HistoryFilter{RunID: 7, Status: "warning"}, store.filter)
+	assert.Equal(t, http.StatusBadRequest, serve("GET", "/api/v1/runs/foo/executions", "").Code)
+
+	assert.Equal(t, http.StatusOK, serve("GET", "/api/v1/executions?to=2026-01-02T00:00:00Z", "").Code)
+
+	w = serve("GET", "/api/v1/logs?status=error", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"message":"foo"`)
+	assert.Equal(t, http.StatusBadRequest, serve("GET", "/api/v1/logs?status=failed", "").Code)
+}
+
+func TestOverviewAPI(t *testing.T) {
+	assert.Equal(t, http.StatusServiceUnavailable, serve("GET", "/api/v1/overview", "").Code)
+
+	restsrv.HistoryStore = &historystore{}
+	defer func() { restsrv.HistoryStore = nil }()
+	w := serve("GET", "/api/v1/overview", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `[{"chain_id": 42, "chain_name": "foo", "schedule": "* * * * *", "live": true,
+		"last_run": {"run_id": 1, "chain_id": 42, "trigger": "", "started_at": "0001-01-01T00:00:00Z",
+		"status": "FAILED", "client_name": ""}}]`, w.Body.String())
+}
@@ -0,0 +1,116 @@
+package api
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+)
+
+// openAPIDocument describes every route of the server, keep it in sync with handlers.
+// The Go client in the client package is generated from it
+//
+//go:embed openapi.json
+var openAPIDocument []byte
+
+// openAPISpec returns the parsed and validated document
+var openAPISpec = sync.OnceValues(func() (*openapi3.T, error) {
+	loader := openapi3.NewLoader()
+	doc, err := loader.LoadFromData(openAPIDocument)
+	if err == nil {
+		err = doc.Validate(loader.Context)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid OpenAPI document: %w", err)
+	}
+	return doc, nil
+})
+
+// routeOf returns the documented operation of the route pattern, e.g. "GET /api/v1/chains/{id}"
+func routeOf(pattern string) (*routers.Route, error) {
+	doc, err := openAPISpec()
+	if err != nil {
+		return nil, err
+	}
+	method, path, _ := strings.Cut(pattern, " ")
+	item := doc.Paths.Value(path)
+	if item == nil || item.GetOperation(method) == nil {
+		return nil, fmt.Errorf("REST API route is not documented in openapi.json: %s", pattern)
+	}
+	return &routers.Route{Spec: doc, Path: path, PathItem: item, Method: method, Operation: item.GetOperation(method)}, nil
+}
+
+// validationOptions skip the authentication checked before the validation and keep requests unchanged
+var validationOptions = &openapi3filter.Options{
+	AuthenticationFunc:  openapi3filter.NoopAuthenticationFunc,
+	SkipSettingDefaults: true,
+}
+
+// validationInput returns the input of openapi3filter for the request routed by http.ServeMux to the route
+func validationInput(route *routers.Route, r *http.Request) *openapi3filter.RequestValidationInput {
+	params := make(map[string]string)
+	for _, p := range slices.Concat(route.PathItem.Parameters, route.Operation.Parameters) {
+		if p.Value != nil && p.Value.In == openapi3.ParameterInPath {
+			params[p.Value.Name] = r.PathValue(p.Value.Name)
+		}
+	}
+	return &openapi3filter.RequestValidationInput{Request: r, PathParams: params, Route: route, Options: validationOptions}
+}
+
+// validateRequest checks parameters and the body of the request against the route.
+// Responds with 400, or 413 if the body is too large, and returns false if the request is invalid
+func (Server *RestAPIServer) validateRequest(w http.ResponseWriter, r *http.Request, route *routers.Route) bool {
+	if route.Operation.RequestBody != nil && r.ContentLength != 0 {
+		r.Header.Set("Content-Type", "application/json") // the only content type of bodies, curl -d sends a form type
+	}
+	err := openapi3filter.ValidateRequest(r.Context(), validationInput(route, r))
+	if err == nil {
+		return true
+	}
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+		return false
+	}
+	http.Error(w, validationMessage(err), http.StatusBadRequest)
+	return false
+}
+
+// validationMessage returns the short description of the invalid request without schema dumps of openapi3filter,
+// e.g. `invalid request body: name: minimum string length is 1`
+func validationMessage(err error) string {
+	var reqErr *openapi3filter.RequestError
+	if !errors.As(err, &reqErr) {
+		return err.Error()
+	}
+	prefix := "invalid request"
+	switch {
+	case reqErr.Parameter != nil:
+		prefix = fmt.Sprintf("%s parameter %q", reqErr.Parameter.In, reqErr.Parameter.Name)
+	case reqErr.RequestBody != nil:
+		prefix = "invalid request body"
+	}
+	var schemaErr *openapi3.SchemaError
+	if errors.As(err, &schemaErr) {
+		if path := schemaErr.JSONPointer(); len(path) > 0 {
+			return prefix + ": " + strings.Join(path, ".") + ": " + schemaErr.Reason
+		}
+		return prefix + ": " + schemaErr.Reason
+	}
+	if reqErr.Err != nil {
+		return prefix + ": " + reqErr.Err.Error()
+	}
+	return prefix + ": " + reqErr.Reason
+}
+
+func (Server *RestAPIServer) openAPIHandler(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(openAPIDocument)
+}
@@ -0,0 +1,132 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cybertec-postgresql/pg_timetable/internal/scheduler"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	legacyrouter "github.com/getkin/kin-openapi/routers/legacy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenAPIDocument(t *testing.T) {
+	w := serve("GET", "/api/v1/openapi.json", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	assert.Equal(t, openAPIDocument, w.Body.Bytes())
+
+	// every route registered with handle is documented
+	assert.NoError(t, restsrv.routeErr)
+	// every documented operation is routed, the mux responds with "404 page not found" otherwise
+	doc, err := openAPISpec()
+	require.NoError(t, err)
+	for path, item := range doc.Paths.Map() {
+		for method := range item.Operations() {
+			target := strings.ReplaceAll(path, "{id}", "1")
+			assert.NotEqual(t, "404 page not found\n", serve(method, target, "").Body.String(), method+" "+path)
+		}
+	}
+
+	s := &RestAPIServer{}
+	mux := http.NewServeMux()
+	s.handle(mux, "GET /undocumented", RoleReadOnly, listScope, func(http.ResponseWriter, *http.Request) {})
+	s.handle(mux, "GET /api/v1/status", RoleReadOnly, listScope, func(http.ResponseWriter, *http.Request) {})
+	assert.EqualError(t, s.routeErr, "REST API route is not documented in openapi.json: GET /undocumented")
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/undocumented", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code, "undocumented routes are not registered")
+}
+
+func TestRequestValidation(t *testing.T) {
+	restsrv.ChainStore = newChainStore()
+	defer func() { restsrv.ChainStore = nil }()
+
+	w := serve("POST", "/api/v1/chains", `{"name": "", "tasks": [{"command": "SELECT 1"}]}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "invalid request body: name: minimum string length is 1\n", w.Body.String())
+
+	w = serve("POST", "/api/v1/chains/1/tasks", `{"command": "SELECT 1", "timeout": "1s"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "timeout: value must be an integer")
+
+	w = serve("PUT", "/api/v1/tasks/1", `{"command": "SELECT 1", "assert": {"min_rows": 1, "foo": 2}}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `assert: property "foo" is unsupported`)
+
+	w = serve("POST", "/stopchain", "")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "query parameter \"id\": value is required but missing\n", w.Body.String())
+
+	w = serve("GET", "/api/v1/logs?limit=1001", "")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "number must be at most 1000")
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge,
+		serve("PUT", "/api/v1/tasks/1/parameters", `["`+strings.Repeat("x", defaultMaxBodySize)+`"]`).Code)
+}
+
+func TestResponsesMatchOpenAPI(t *testing.T) {
+	restsrv.APIHandler = &apihandler{}
+	restsrv.ChainStore = newChainStore()
+	restsrv.HistoryStore = &historystore{}
+	restsrv.Events = &eventsource{events: []scheduler.Event{{ID: 1, Type: scheduler.EventChainStarted, ChainID: 1}}}
+	defer func() { restsrv.ChainStore, restsrv.HistoryStore, restsrv.Events = nil, nil, nil }()
+	doc, err := openAPISpec()
+	require.NoError(t, err)
+	router, err := legacyrouter.NewRouter(doc)
+	require.NoError(t, err)
+
+	for _, req := range []struct{ method, target, body string }{
+		{"GET", "/liveness", ""},
+		{"GET", "/readiness", ""},
+		{"GET", "/api/v1/status", ""},
+		{"GET", "/api/v1/events", ""},
+		{"POST", "/startchain?id=1", `{"1": {"foo": "bar"}}`},
+		{"POST", "/startchain?id=0", ""},
+		{"POST", "/stopchain?id=1", ""},
+		{"GET", "/api/v1/chains", ""},
+		{"GET", "/api/v1/chains/1", ""},
+		{"GET", "/api/v1/chains/42", ""},
+		{"GET", "/api/v1/chains/foo", ""},
+		{"POST", "/api/v1/chains", `{"name": "bar", "tasks": [{"command": "SELECT 1"}]}`},
+		{"POST", "/api/v1/chains", `{"name": "foo", "tasks": [{"command": "SELECT 1"}]}`},
+		{"PUT", "/api/v1/chains/1", `{"name": "foo", "schedule": "@reboot", "tasks": [{"command": "SELECT 1"}]}`},
+		{"POST", "/api/v1/chains/1/pause", ""},
+		{"POST", "/api/v1/chains/1/resume", ""},
+		{"GET", "/api/v1/chains/1/tasks", ""},
+		{"POST", "/api/v1/chains/1/tasks", `{"command": "SELECT 2", "live": null}`},
+		{"GET", "/api/v1/tasks/1", ""},
+		{"PUT", "/api/v1/tasks/1", `{"command": "SELECT 3"}`},
+		{"GET", "/api/v1/tasks/1/parameters", ""},
+		{"PUT", "/api/v1/tasks/1/parameters", `[1, "two", {"three": 3}]`},
+		{"DELETE", "/api/v1/tasks/1", ""},
+		{"DELETE", "/api/v1/chains/1", ""},
+		{"GET", "/api/v1/runs?chain_id=1", ""},
+		{"GET", "/api/v1/runs/1/executions", ""},
+		{"GET", "/api/v1/executions", ""},
+		{"GET", "/api/v1/logs", ""},
+		{"GET", "/api/v1/overview", ""},
+		{"GET", "/api/v1/openapi.json", ""},
+	} {
+		w := serve(req.method, req.target, req.body)
+		r := httptest.NewRequest(req.method, req.target, nil)
+		route, params, err := router.FindRoute(r)
+		require.NoError(t, err, req.target)
+		input := &openapi3filter.ResponseValidationInput{
+			RequestValidationInput: &openapi3filter.RequestValidationInput{Request: r, PathParams: params, Route: route},
+			Status:                 w.Code,
+			Header:                 w.Header(),
+			Options: &openapi3filter.Options{
+				IncludeResponseStatus: true,
+				ExcludeResponseBody:   w.Header().Get("Content-Type") == "text/event-stream", // no decoder of the stream
+			},
+		}
+		input.SetBodyBytes(w.Body.Bytes())
+		assert.NoError(t, openapi3filter.ValidateResponse(context.Background(), input), req.method+" "+req.target)
+	}
+}
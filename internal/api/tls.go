@@ -0,0 +1,85 @@
+package api
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/cybertec-postgresql/pg_timetable/internal/log"
+)
+
+// certReloader serves the server certificate and reloads it as soon as the certificate or the key file changes,
+// so renewed certificates are used without restarting pg_timetable
+type certReloader struct {
+	certFile, keyFile string
+	l                 log.LoggerIface
+	mu                sync.Mutex
+	cert              *tls.Certificate
+	modTime           time.Time // the latest modification time of both files when the certificate was loaded
+}
+
+func newCertReloader(certFile, keyFile string, l log.LoggerIface) (*certReloader, error) {
+	c := &certReloader{certFile: certFile, keyFile: keyFile, l: l}
+	modTime, err := c.filesModTime()
+	if err != nil {
+		return nil, err
+	}
+	if err = c.load(modTime); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *certReloader) filesModTime() (modTime time.Time, err error) {
+	for _, name := range []string{c.certFile, c.keyFile} {
+		fi, err := os.Stat(name)
+		if err != nil {
+			return modTime, err
+		}
+		if fi.ModTime().After(modTime) {
+			modTime = fi.ModTime()
+		}
+	}
+	return modTime, nil
+}
+
+func (c *certReloader) load(modTime time.Time) error {
+	cert, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+	if err != nil {
+		return err
+	}
+	c.cert, c.modTime = &cert, modTime
+	return nil
+}
+
+// GetCertificate returns the current certificate, reloading changed files. The previous certificate is kept
+// if the new files cannot be loaded, e.g. when only one of them is replaced yet
+func (c *certReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if modTime, err := c.filesModTime(); err == nil && !modTime.Equal(c.modTime) {
+		if err = c.load(modTime); err != nil {
+			c.modTime = modTime // retry on the next change only
+			c.l.WithError(err).Error("Cannot reload REST API certificate, the previous one is used")
+		} else {
+			c.l.WithField("file", c.certFile).Info("REST API certificate reloaded")
+		}
+	}
+	return c.cert, nil
+}
+
+// loadCertPool reads PEM encoded CA certificates from the file
+func loadCertPool(filename string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no certificates found in %s", filename)
+	}
+	return pool, nil
+}
@@ -0,0 +1,71 @@
+package api
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cybertec-postgresql/pg_timetable/internal/config"
+	"github.com/cybertec-postgresql/pg_timetable/internal/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeCert creates the self-signed certificate for the common name and returns the certificate file
+func writeCert(t *testing.T, dir, cn string, modTime time.Time) (certFile, keyFile string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IsCA:         true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	certFile, keyFile = filepath.Join(dir, "server.crt"), filepath.Join(dir, "server.key")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600))
+	require.NoError(t, os.Chtimes(certFile, modTime, modTime))
+	require.NoError(t, os.Chtimes(keyFile, modTime, modTime))
+	return
+}
+
+func TestCertReloader(t *testing.T) {
+	l := log.Init(config.LoggingOpts{LogLevel: "panic"})
+	dir := t.TempDir()
+	_, err := newCertReloader(filepath.Join(dir, "server.crt"), filepath.Join(dir, "server.key"), l)
+	assert.Error(t, err)
+
+	certFile, keyFile := writeCert(t, dir, "foo", time.Now().Add(-time.Minute))
+	c, err := newCertReloader(certFile, keyFile, l)
+	require.NoError(t, err)
+	cert, err := c.GetCertificate(nil)
+	require.NoError(t, err)
+	assert.Equal(t, "foo", cert.Leaf.Subject.CommonName)
+
+	writeCert(t, dir, "bar", time.Now())
+	cert, _ = c.GetCertificate(nil)
+	assert.Equal(t, "bar", cert.Leaf.Subject.CommonName, "changed certificate must be reloaded")
+
+	require.NoError(t, os.WriteFile(keyFile, []byte("garbage"), 0600))
+	cert, _ = c.GetCertificate(nil)
+	assert.Equal(t, "bar", cert.Leaf.Subject.CommonName, "previous certificate must be kept")
+
+	pool, err := loadCertPool(certFile)
+	assert.NoError(t, err)
+	assert.NotNil(t, pool)
+	_, err = loadCertPool(keyFile)
+	assert.Error(t, err)
+}
@@ -0,0 +1,25 @@
+package api
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+)
+
+//go:embed ui
+var uiAssets embed.FS
+
+// handleUI serves the dashboard. Static assets are public, the data is requested from the API
+// with the token entered by the user, so the authorization rules apply to the dashboard as well
+func (Server *RestAPIServer) handleUI(mux *http.ServeMux) {
+	assets, _ := fs.Sub(uiAssets, "ui")
+	files := http.StripPrefix("/ui/", http.FileServerFS(assets))
+	mux.HandleFunc("GET /ui/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Security-Policy", "default-src 'self'; img-src 'self' data:; frame-ancestors 'none'")
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		files.ServeHTTP(w, r)
+	})
+	mux.HandleFunc("GET /{$}", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/ui/", http.StatusFound)
+	})
+}
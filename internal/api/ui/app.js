@@ -0,0 +1,244 @@
+'use strict';
+
+// The dashboard uses the REST API with the token kept for the browser session only.
+// fetch() is used for the event stream as well, since EventSource cannot send the Authorization header.
+
+const tokenKey = 'pg_timetable_token';
+const timelineHours = 24;
+const logLimit = 100;
+
+let selectedChain = 0;
+let chainNames = new Map();
+let refreshTimer = null;
+
+const $ = (selector) => document.querySelector(selector);
+
+function el(tag, props = {}, ...children) {
+	const e = Object.assign(document.createElement(tag), props);
+	e.append(...children);
+	return e;
+}
+
+function showMessage(text) {
+	const m = $('#message');
+	m.textContent = text;
+	m.hidden = !text;
+}
+
+function formatTime(value) {
+	return value ? new Date(value).toLocaleString() : '';
+}
+
+function formatDuration(ms) {
+	const s = Math.round(ms / 1000);
+	return s < 60 ? `${s}s` : s < 3600 ? `${Math.floor(s / 60)}m ${s % 60}s` : `${Math.floor(s / 3600)}h ${Math.floor(s % 3600 / 60)}m`;
+}
+
+function authHeaders() {
+	const token = sessionStorage.getItem(tokenKey);
+	return token ? { Authorization: `Bearer ${token}` } : {};
+}
+
+async function api(method, path) {
+	const resp = await fetch(path, { method, headers: authHeaders() });
+	if (!resp.ok) {
+		const text = (await resp.text()).trim();
+		throw new Error(resp.status === 401 ? 'Sign in with the API token' : `${method} ${path}: ${text || resp.statusText}`);
+	}
+	return resp.headers.get('Content-Type')?.startsWith('application/json') ? resp.json() : null;
+}
+
+async function chainAction(method, path) {
+	try {
+		await api(method, path);
+		showMessage('');
+		scheduleRefresh(500);
+	} catch (err) {
+		showMessage(err.message);
+	}
+}
+
+function actionButton(label, title, method, path) {
+	const b = el('button', { type: 'button', textContent: label, title });
+	b.addEventListener('click', (e) => {
+		e.stopPropagation();
+		chainAction(method, path);
+	});
+	return b;
+}
+
+async function loadChains() {
+	const chains = await api('GET', '/api/v1/overview');
+	chainNames = new Map(chains.map((c) => [c.chain_id, c.chain_name]));
+	const rows = chains.map((c) => {
+		const run = c.last_run;
+		const status = run ? run.status.toLowerCase() : '';
+		const tr = el('tr', { className: c.chain_id === selectedChain ? 'selected' : '' },
+			el('td', { textContent: c.chain_id }),
+			el('td', { textContent: c.chain_name, className: c.live ? '' : 'paused', title: c.live ? '' : 'paused' }),
+			el('td', { textContent: c.schedule }),
+			el('td', { textContent: formatTime(c.next_run) }),
+			el('td', { textContent: run ? formatTime(run.started_at) : '' }),
+			el('td', { textContent: status, className: status, title: run?.error ?? '' }),
+			el('td', { className: 'actions' },
+				actionButton('Start', 'Start the chain now', 'POST', `/startchain?id=${c.chain_id}`),
+				actionButton('Stop', 'Stop the running chain', 'POST', `/stopchain?id=${c.chain_id}`),
+				c.live
+					? actionButton('Pause', 'Stop scheduling the chain', 'POST', `/api/v1/chains/${c.chain_id}/pause`)
+					: actionButton('Resume', 'Schedule the chain again', 'POST', `/api/v1/chains/${c.chain_id}/resume`)));
+		tr.addEventListener('click', () => selectChain(c.chain_id === selectedChain ? 0 : c.chain_id));
+		return tr;
+	});
+	$('#chains tbody').replaceChildren(...rows);
+}
+
+async function loadTimeline() {
+	const now = Date.now();
+	const from = now - timelineHours * 3600 * 1000;
+	const runs = [];
+	let cursor = '';
+	do {
+		const page = await api('GET', `/api/v1/runs?limit=1000&from=${new Date(from).toISOString()}` +
+			(cursor ? `&cursor=${encodeURIComponent(cursor)}` : ''));
+		runs.push(...page.items);
+		cursor = page.next_cursor;
+	} while (cursor);
+
+	const byChain = Map.groupBy(runs, (r) => r.chain_id);
+	const rows = [...byChain.keys()].sort((a, b) => a - b).map((chainID) => {
+		const bars = byChain.get(chainID).map((r) => {
+			const start = Math.max(new Date(r.started_at).getTime(), from);
+			const finish = r.finished_at ? new Date(r.finished_at).getTime() : now;
+			const status = r.status.toLowerCase();
+			const bar = el('div', {
+				className: `bar ${status}`,
+				title: `${formatTime(r.started_at)}, ${formatDuration(finish - new Date(r.started_at).getTime())}, ${status}` +
+					(r.error ? `\n${r.error}` : ''),
+			});
+			// CSSOM properties are allowed by the Content-Security-Policy, unlike inline style attributes
+			bar.style.left = `${(start - from) / (now - from) * 100}%`;
+			bar.style.width = `${(finish - start) / (now - from) * 100}%`;
+			return bar;
+		});
+		const name = chainNames.get(chainID) ?? `chain ${chainID}`;
+		return el('div', { className: 'row' },
+			el('div', { className: 'label', textContent: name, title: name }),
+			el('div', { className: 'track' }, ...bars));
+	});
+	$('#timeline').replaceChildren(...rows);
+}
+
+function logRow(entry) {
+	const level = entry.log_level.toLowerCase();
+	return el('tr', {},
+		el('td', { textContent: formatTime(entry.ts) }),
+		el('td', { textContent: level, className: level === 'error' || level === 'panic' ? 'failed' : '' }),
+		el('td', { textContent: entry.client_name }),
+		el('td', { className: 'message', textContent: entry.message }));
+}
+
+async function loadLogs() {
+	const page = await api('GET', `/api/v1/logs?limit=${logLimit}` + (selectedChain ? `&chain_id=${selectedChain}` : ''));
+	$('#logs tbody').replaceChildren(...page.items.map(logRow));
+}
+
+function selectChain(chainID) {
+	selectedChain = chainID;
+	$('#log-chain').textContent = chainID ? chainNames.get(chainID) ?? `chain ${chainID}` : 'all chains';
+	for (const tr of $('#chains tbody').children) {
+		tr.classList.toggle('selected', Number(tr.firstChild.textContent) === chainID);
+	}
+	loadLogs().catch((err) => showMessage(err.message));
+}
+
+async function refresh() {
+	try {
+		await loadChains();
+		await Promise.all([loadTimeline(), loadLogs()]);
+		showMessage('');
+	} catch (err) {
+		showMessage(err.message);
+	}
+}
+
+// scheduleRefresh coalesces reloads caused by bursts of events
+function scheduleRefresh(delay = 1000) {
+	clearTimeout(refreshTimer);
+	refreshTimer = setTimeout(refresh, delay);
+}
+
+function handleEvent(type, event) {
+	if (type === 'log') {
+		if (!selectedChain || event.chain_id === selectedChain) {
+			const tbody = $('#logs tbody');
+			tbody.prepend(logRow({ ts: event.time, log_level: event.level, client_name: '', message: event.message }));
+			while (tbody.children.length > logLimit) {
+				tbody.lastChild.remove();
+			}
+		}
+	} else if (type.startsWith('chain_')) {
+		scheduleRefresh();
+	}
+}
+
+// streamEvents keeps the live connection to the event stream, reconnecting after errors
+async function streamEvents() {
+	const live = $('#live');
+	for (;;) {
+		try {
+			const resp = await fetch('/api/v1/events?type=chain_started,chain_finished,chain_failed,log', { headers: authHeaders() });
+			if (!resp.ok) {
+				throw new Error(resp.statusText);
+			}
+			live.textContent = 'live';
+			live.classList.add('online');
+			const reader = resp.body.pipeThrough(new TextDecoderStream()).getReader();
+			let buffer = '';
+			for (;;) {
+				const { value, done } = await reader.read();
+				if (done) {
+					break;
+				}
+				buffer += value;
+				let end;
+				while ((end = buffer.indexOf('\n\n')) >= 0) {
+					const block = buffer.slice(0, end);
+					buffer = buffer.slice(end + 2);
+					let type = '';
+					let data = '';
+					for (const line of block.split('\n')) {
+						if (line.startsWith('event: ')) {
+							type = line.slice(7);
+						} else if (line.startsWith('data: ')) {
+							data += line.slice(6);
+						}
+					}
+					if (type && data) {
+						handleEvent(type, JSON.parse(data));
+					}
+				}
+			}
+		} catch {
+			// reconnect below
+		}
+		live.textContent = 'offline';
+		live.classList.remove('online');
+		await new Promise((resolve) => setTimeout(resolve, 5000));
+	}
+}
+
+document.addEventListener('DOMContentLoaded', () => {
+	$('#login').addEventListener('submit', (e) => {
+		e.preventDefault();
+		sessionStorage.setItem(tokenKey, $('#token').value);
+		$('#token').value = '';
+		refresh();
+	});
+	$('#logout').addEventListener('click', () => {
+		sessionStorage.removeItem(tokenKey);
+		refresh();
+	});
+	$('#refresh').addEventListener('click', () => refresh());
+	refresh();
+	streamEvents();
+});
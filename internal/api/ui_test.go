@@ -0,0 +1,25 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDashboard(t *testing.T) {
+	w := serve("GET", "/", "")
+	assert.Equal(t, http.StatusFound, w.Code)
+	assert.Equal(t, "/ui/", w.Header().Get("Location"))
+
+	w = serve("GET", "/ui/", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `<script src="app.js"`)
+	assert.Contains(t, w.Header().Get("Content-Security-Policy"), "default-src 'self'")
+
+	for _, asset := range []string{"/ui/app.js", "/ui/style.css"} {
+		assert.Equal(t, http.StatusOK, serve("GET", asset, "").Code, asset)
+	}
+	assert.Equal(t, http.StatusNotFound, serve("GET", "/ui/foo.js", "").Code)
+	assert.Equal(t, http.StatusNotFound, serve("GET", "/foo", "").Code)
+}
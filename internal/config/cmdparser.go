@@ -9,14 +9,18 @@ import (
 
 // LoggingOpts specifies the logging configuration
 type LoggingOpts struct {
-	LogLevel      string `long:"log-level" mapstructure:"log-level" description:"Verbosity level for stdout and log file" choice:"debug" choice:"info" choice:"error" default:"info"`
-	LogDBLevel    string `long:"log-database-level" mapstructure:"log-database-level" description:"Verbosity level for database storing" choice:"debug" choice:"info" choice:"error" choice:"none" default:"info"`
-	LogFile       string `long:"log-file" mapstructure:"log-file" description:"File name to store logs"`
-	LogFileFormat string `long:"log-file-format" mapstructure:"log-file-format" description:"Format of file logs" choice:"json" choice:"text" default:"json"`
-	LogFileRotate bool   `long:"log-file-rotate" mapstructure:"log-file-rotate" description:"Rotate log files"`
-	LogFileSize   int    `long:"log-file-size" mapstructure:"log-file-size" description:"Maximum size in MB of the log file before it gets rotated" default:"100"`
-	LogFileAge    int    `long:"log-file-age" mapstructure:"log-file-age" description:"Number of days to retain old log files, 0 means forever" default:"0"`
-	LogFileNumber int    `long:"log-file-number" mapstructure:"log-file-number" description:"Maximum number of old log files to retain, 0 to retain all" default:"0"`
+	LogLevel          string   `long:"log-level" mapstructure:"log-level" description:"Verbosity level for stdout and log file" choice:"debug" choice:"info" choice:"error" default:"info"`
+	LogDBLevel        string   `long:"log-database-level" mapstructure:"log-database-level" description:"Verbosity level for database storing" choice:"debug" choice:"info" choice:"error" choice:"none" default:"info"`
+	LogFile           string   `long:"log-file" mapstructure:"log-file" description:"File name to store logs"`
+	LogFileFormat     string   `long:"log-file-format" mapstructure:"log-file-format" description:"Format of file logs" choice:"json" choice:"text" default:"json"`
+	LogFileRotate     bool     `long:"log-file-rotate" mapstructure:"log-file-rotate" description:"Rotate log files"`
+	LogFileSize       int      `long:"log-file-size" mapstructure:"log-file-size" description:"Maximum size in MB of the log file before it gets rotated" default:"100"`
+	LogFileAge        int      `long:"log-file-age" mapstructure:"log-file-age" description:"Number of days to retain old log files, 0 means forever" default:"0"`
+	LogFileNumber     int      `long:"log-file-number" mapstructure:"log-file-number" description:"Maximum number of old log files to retain, 0 to retain all" default:"0"`
+	LogSpoolDir       string   `long:"log-spool-dir" mapstructure:"log-spool-dir" description:"Directory to spool database log entries to while the database is unavailable"`
+	LogSpoolSize      int      `long:"log-spool-size" mapstructure:"log-spool-size" description:"Maximum size in MB of the database log spool" default:"100"`
+	LogRedactKeys     []string `long:"log-redact-key" mapstructure:"log-redact-key" description:"Mask values of fields and parameters with names containing the text; may be specified multiple times" default:"password" default:"token" default:"secret"`
+	LogRedactPatterns []string `long:"log-redact-pattern" mapstructure:"log-redact-pattern" description:"Mask text matching the regular expression, only the first group if any; may be specified multiple times"`
 }
 
 // StartOpts specifies the application startup options
@@ -27,6 +31,7 @@ type StartOpts struct {
 	Init     bool   `long:"init" description:"Initialize database schema to the latest version and exit. Can be used with --upgrade"`
 	Upgrade  bool   `long:"upgrade" description:"Upgrade database to the latest version"`
 	Debug    bool   `long:"debug" description:"Run in debug mode. Only asynchronous chains will be executed"`
+	Standby  bool   `long:"standby" description:"Wait as standby while another client with the same name is active and take over once it fails"`
 }
 
 // ResourceOpts specifies the maximum resources available to application
@@ -37,9 +42,37 @@ type ResourceOpts struct {
 	TaskTimeout     int `long:"task-timeout" mapstructure:"task-timeout" description:"Abort any task within a chain that takes more than the specified number of milliseconds"`
 }
 
+// RetentionOpts specifies how long entries of log tables are kept
+type RetentionOpts struct {
+	Days      int  `long:"retention-days" mapstructure:"retention-days" description:"Number of days to keep entries of log, execution_log and chain_run tables, 0 means forever" default:"0"`
+	MaxRows   int  `long:"retention-max-rows" mapstructure:"retention-max-rows" description:"Maximum number of entries to keep in each of log tables, 0 means unlimited" default:"0"`
+	Partition bool `long:"retention-partition" mapstructure:"retention-partition" description:"Convert log and execution_log tables to daily partitions dropped after retention days"`
+}
+
+// RemoteOpts specifies settings for named connections to remote databases
+type RemoteOpts struct {
+	Passwords map[string]string `long:"remote-passwords" mapstructure:"remote-passwords" description:"Passwords for named connections with config credentials" no-flag:"true"`
+}
+
+// SecretsOpts specifies sources of secrets referenced by tasks
+type SecretsOpts struct {
+	EnvPrefix string `long:"secrets-env-prefix" mapstructure:"secrets-env-prefix" description:"Prefix of environment variables holding secrets" default:"PGTT_SECRET_"`
+	Dir       string `long:"secrets-dir" mapstructure:"secrets-dir" description:"Directory with secret files, e.g. /run/secrets"`
+	Key       string `long:"secrets-key" mapstructure:"secrets-key" description:"Encryption key for secrets stored in timetable.secret" env:"PGTT_SECRETSKEY"`
+}
+
 // RestAPIOpts fot internal web server impleenting REST API
 type RestAPIOpts struct {
-	Port int `long:"rest-port" mapstructure:"rest-port" description:"REST API port" env:"PGTT_RESTPORT" default:"0"`
+	Port        int      `long:"rest-port" mapstructure:"rest-port" description:"REST API port" env:"PGTT_RESTPORT" default:"0"`
+	Address     string   `long:"rest-address" mapstructure:"rest-address" description:"REST API bind address, all interfaces if empty" env:"PGTT_RESTADDRESS"`
+	AuthFile    string   `long:"rest-auth-file" mapstructure:"rest-auth-file" description:"YAML file with REST API users, their roles and chain scopes" env:"PGTT_RESTAUTHFILE"`
+	TLSCert     string   `long:"rest-tls-cert" mapstructure:"rest-tls-cert" description:"REST API server certificate file, enables HTTPS, reloaded on change" env:"PGTT_RESTTLSCERT"`
+	TLSKey      string   `long:"rest-tls-key" mapstructure:"rest-tls-key" description:"REST API server private key file, reloaded on change" env:"PGTT_RESTTLSKEY"`
+	ClientCA    string   `long:"rest-client-ca" mapstructure:"rest-client-ca" description:"CA certificate file to verify REST API client certificates" env:"PGTT_RESTCLIENTCA"`
+	CORSOrigins []string `long:"rest-cors-origin" mapstructure:"rest-cors-origin" description:"Origin allowed to call REST API from browsers, * for any; may be specified multiple times"`
+	MaxBodySize int64    `long:"rest-max-body-size" mapstructure:"rest-max-body-size" description:"Maximum size of REST API request body in bytes" default:"1048576"`
+	GRPC        bool     `long:"grpc" mapstructure:"grpc" description:"Serve gRPC API on the REST API port" env:"PGTT_GRPC"`
+	GRPCPort    int      `long:"grpc-port" mapstructure:"grpc-port" description:"Serve gRPC API on the separate port instead of the REST API port" env:"PGTT_GRPCPORT" default:"0"`
 }
 
 // OTelOpts specifies OpenTelemetry configuration
@@ -57,17 +90,23 @@ type OTelOpts struct {
 
 // CmdOptions holds command line options passed
 type CmdOptions struct {
-	ClientName     string       `short:"c" long:"clientname" description:"Unique name for application instance" env:"PGTT_CLIENTNAME"`
-	Config         string       `long:"config" description:"YAML configuration file"`
-	ConnStr        string       `long:"connstr" description:"Connection string" env:"PGTT_CONNSTR"`
-	Logging        LoggingOpts  `group:"Logging" mapstructure:"Logging"`
-	Start          StartOpts    `group:"Start" mapstructure:"Start"`
-	Resource       ResourceOpts `group:"Resource" mapstructure:"Resource"`
-	RESTApi        RestAPIOpts  `group:"REST" mapstructure:"REST"`
-	OTel           OTelOpts     `group:"OTel" mapstructure:"OTel"`
-	NoProgramTasks bool         `long:"no-program-tasks" mapstructure:"no-program-tasks" description:"Disable executing of PROGRAM tasks" env:"PGTT_NOPROGRAMTASKS"`
-	NoHelpMessage  bool         `long:"no-help" mapstructure:"no-help" hidden:"system use"`
-	Version        bool         `short:"v" long:"version" mapstructure:"version" description:"Output detailed version information" env:"PGTT_VERSION"`
+	ClientName       string        `short:"c" long:"clientname" description:"Unique name for application instance" env:"PGTT_CLIENTNAME"`
+	Cluster          string        `long:"cluster" mapstructure:"cluster" description:"Name of the pool of clients sharing chains without client name, every scheduled run is executed by one client of the pool" env:"PGTT_CLUSTER"`
+	Config           string        `long:"config" description:"YAML configuration file"`
+	ConnStr          string        `long:"connstr" description:"Connection string" env:"PGTT_CONNSTR"`
+	Logging          LoggingOpts   `group:"Logging" mapstructure:"Logging"`
+	Start            StartOpts     `group:"Start" mapstructure:"Start"`
+	Resource         ResourceOpts  `group:"Resource" mapstructure:"Resource"`
+	Retention        RetentionOpts `group:"Retention" mapstructure:"Retention"`
+	Remote           RemoteOpts    `group:"Remote" mapstructure:"Remote"`
+	Secrets          SecretsOpts   `group:"Secrets" mapstructure:"Secrets"`
+	RESTApi          RestAPIOpts   `group:"REST" mapstructure:"REST"`
+	OTel             OTelOpts      `group:"OTel" mapstructure:"OTel"`
+	NoProgramTasks   bool          `long:"no-program-tasks" mapstructure:"no-program-tasks" description:"Disable executing of PROGRAM and SHELL tasks" env:"PGTT_NOPROGRAMTASKS"`
+	ProgramPolicy    string        `long:"program-policy" mapstructure:"program-policy" description:"YAML file with the allow-list of executables for PROGRAM and SHELL tasks" env:"PGTT_PROGRAMPOLICY"`
+	ShellInterpreter string        `long:"shell-interpreter" mapstructure:"shell-interpreter" description:"Interpreter for SHELL tasks without shebang line" default:"sh" env:"PGTT_SHELLINTERPRETER"`
+	NoHelpMessage    bool          `long:"no-help" mapstructure:"no-help" hidden:"system use"`
+	Version          bool          `short:"v" long:"version" mapstructure:"version" description:"Output detailed version information" env:"PGTT_VERSION"`
 }
 
 // Verbose returns true if the debug log is enabled
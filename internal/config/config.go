@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"net/url"
+	"regexp"
 	"strings"
 
 	flags "github.com/jessevdk/go-flags"
@@ -124,9 +125,65 @@ func NewConfig(writer io.Writer) (*CmdOptions, error) {
 	if err := ValidateOTel(conf.OTel); err != nil {
 		return conf, err
 	}
+	if err := ValidateLogging(conf.Logging); err != nil {
+		return conf, err
+	}
+	if err := ValidateRetention(conf.Retention); err != nil {
+		return conf, err
+	}
+	if err := ValidateRestAPI(conf.RESTApi); err != nil {
+		return conf, err
+	}
 	return conf, nil
 }
 
+// ValidateRestAPI validates RestAPIOpts fields and returns an error for invalid values.
+func ValidateRestAPI(opts RestAPIOpts) error {
+	if (opts.TLSCert == "") != (opts.TLSKey == "") {
+		return errors.New("rest-tls-cert and rest-tls-key must be specified together")
+	}
+	if opts.ClientCA != "" && opts.TLSCert == "" {
+		return errors.New("rest-client-ca requires rest-tls-cert and rest-tls-key")
+	}
+	if opts.MaxBodySize < 0 {
+		return errors.New("rest-max-body-size must be >= 0")
+	}
+	if opts.GRPCPort < 0 {
+		return errors.New("grpc-port must be >= 0")
+	}
+	if opts.GRPC && opts.GRPCPort == 0 && opts.Port == 0 {
+		return errors.New("grpc requires rest-port, use grpc-port to serve gRPC API without REST API")
+	}
+	if opts.GRPCPort > 0 && opts.GRPCPort == opts.Port {
+		return errors.New("grpc-port must differ from rest-port, use grpc to share the REST API port")
+	}
+	return nil
+}
+
+// ValidateRetention validates RetentionOpts fields and returns an error for invalid values.
+func ValidateRetention(opts RetentionOpts) error {
+	if opts.Days < 0 {
+		return errors.New("retention-days must be >= 0")
+	}
+	if opts.MaxRows < 0 {
+		return errors.New("retention-max-rows must be >= 0")
+	}
+	return nil
+}
+
+// ValidateLogging validates LoggingOpts fields and returns an error for invalid values.
+func ValidateLogging(opts LoggingOpts) error {
+	for _, p := range opts.LogRedactPatterns {
+		if _, err := regexp.Compile(p); err != nil {
+			return fmt.Errorf("invalid log-redact-pattern %q: %w", p, err)
+		}
+	}
+	if opts.LogSpoolDir != "" && opts.LogSpoolSize <= 0 {
+		return errors.New("log-spool-size must be > 0")
+	}
+	return nil
+}
+
 // filterEmpty returns a new slice with blank (empty or whitespace-only)
 // strings removed and surrounding whitespace trimmed from the rest.
 func filterEmpty(in []string) []string {
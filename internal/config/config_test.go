@@ -107,3 +107,29 @@ func TestValidateOTel(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateLogging(t *testing.T) {
+	assert.NoError(t, ValidateLogging(LoggingOpts{LogRedactPatterns: []string{`token=(\w+)`}}))
+	assert.ErrorContains(t, ValidateLogging(LoggingOpts{LogRedactPatterns: []string{"("}}), "invalid log-redact-pattern")
+	assert.Error(t, ValidateLogging(LoggingOpts{LogSpoolDir: "/tmp", LogSpoolSize: 0}))
+}
+
+func TestValidateRetention(t *testing.T) {
+	assert.NoError(t, ValidateRetention(RetentionOpts{Days: 30, MaxRows: 1000}))
+	assert.Error(t, ValidateRetention(RetentionOpts{Days: -1}))
+	assert.Error(t, ValidateRetention(RetentionOpts{MaxRows: -1}))
+}
+
+func TestValidateRestAPI(t *testing.T) {
+	assert.NoError(t, ValidateRestAPI(RestAPIOpts{Port: 8008}))
+	assert.NoError(t, ValidateRestAPI(RestAPIOpts{TLSCert: "cert.pem", TLSKey: "key.pem", ClientCA: "ca.pem"}))
+	assert.Error(t, ValidateRestAPI(RestAPIOpts{TLSCert: "cert.pem"}))
+	assert.Error(t, ValidateRestAPI(RestAPIOpts{ClientCA: "ca.pem"}))
+	assert.Error(t, ValidateRestAPI(RestAPIOpts{MaxBodySize: -1}))
+	assert.NoError(t, ValidateRestAPI(RestAPIOpts{Port: 8008, GRPC: true}))
+	assert.NoError(t, ValidateRestAPI(RestAPIOpts{Port: 8008, GRPCPort: 9090}))
+	assert.NoError(t, ValidateRestAPI(RestAPIOpts{GRPCPort: 9090}))
+	assert.Error(t, ValidateRestAPI(RestAPIOpts{GRPC: true}))
+	assert.Error(t, ValidateRestAPI(RestAPIOpts{Port: 8008, GRPCPort: 8008}))
+	assert.Error(t, ValidateRestAPI(RestAPIOpts{Port: 8008, GRPCPort: -1}))
+}
@@ -0,0 +1,129 @@
+package grpcapi
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/cybertec-postgresql/pg_timetable/internal/api"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// methodRoles lists roles required to call Timetable methods. Other methods are denied
+var methodRoles = map[string]api.Role{
+	Timetable_StartChain_FullMethodName:   api.RoleOperator,
+	Timetable_StopChain_FullMethodName:    api.RoleOperator,
+	Timetable_ListChains_FullMethodName:   api.RoleReadOnly,
+	Timetable_StreamEvents_FullMethodName: api.RoleReadOnly,
+}
+
+// openServices are prefixes of methods allowed without authentication, i.e. health checks and reflection
+// like /readiness and the OpenAPI document of the REST API
+var openServices = []string{
+	"/grpc.health.v1.Health/",
+	"/grpc.reflection.v1.ServerReflection/",
+	"/grpc.reflection.v1alpha.ServerReflection/",
+}
+
+type userKey struct{}
+
+// userOf returns the authenticated user of the call, nil if authentication is not configured
+func userOf(ctx context.Context) *api.User {
+	u, _ := ctx.Value(userKey{}).(*api.User)
+	return u
+}
+
+// canAccess returns true if the user of the call is not restricted to chains or the chain is one of them
+func canAccess(ctx context.Context, chainID int) bool {
+	u := userOf(ctx)
+	return u == nil || u.CanAccess(chainID)
+}
+
+// authenticate returns the user identified by the bearer token in the authorization metadata
+// or by the verified client certificate
+func (s *Server) authenticate(ctx context.Context) *api.User {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		for _, v := range md.Get("authorization") {
+			if token, ok := strings.CutPrefix(v, "Bearer "); ok {
+				return s.auth.AuthenticateToken(token)
+			}
+		}
+	}
+	if p, ok := peer.FromContext(ctx); ok {
+		if info, ok := p.AuthInfo.(credentials.TLSInfo); ok {
+			return s.auth.AuthenticateCert(&info.State)
+		}
+	}
+	return nil
+}
+
+// authorize checks the user is allowed to call the method and returns the context with the user
+func (s *Server) authorize(ctx context.Context, method string) (context.Context, error) {
+	role, ok := methodRoles[method]
+	if !ok {
+		if slices.ContainsFunc(openServices, func(prefix string) bool { return strings.HasPrefix(method, prefix) }) {
+			return ctx, nil
+		}
+		return ctx, status.Error(codes.PermissionDenied, "method is not allowed")
+	}
+	if s.auth == nil {
+		return ctx, nil
+	}
+	u := s.authenticate(ctx)
+	if u == nil {
+		return ctx, status.Error(codes.Unauthenticated, "unauthorized")
+	}
+	ctx = context.WithValue(ctx, userKey{}, u)
+	if u.Role < role {
+		return ctx, status.Error(codes.PermissionDenied, fmt.Sprintf("forbidden for the %s role", u.Role))
+	}
+	return ctx, nil
+}
+
+// unaryInterceptor authorizes calls and audits those changing the state, i.e. above read-only
+func (s *Server) unaryInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+	ctx, err = s.authorize(ctx, info.FullMethod)
+	if err == nil {
+		resp, err = handler(ctx, req)
+	}
+	if methodRoles[info.FullMethod] > api.RoleReadOnly {
+		user, remote := "anonymous", ""
+		if u := userOf(ctx); u != nil {
+			user = u.Name
+		}
+		if p, ok := peer.FromContext(ctx); ok {
+			remote = p.Addr.String()
+		}
+		s.l.WithField("user", user).
+			WithField("method", info.FullMethod).
+			WithField("remote", remote).
+			WithField("code", status.Code(err).String()).
+			Info("gRPC API call audited")
+	}
+	return resp, err
+}
+
+// streamInterceptor authorizes streaming calls, e.g. event streams
+func (s *Server) streamInterceptor(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := s.authorize(ss.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+	return handler(srv, &authorizedStream{ss, ctx})
+}
+
+// authorizedStream passes the context with the user to the stream handler
+type authorizedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (ss *authorizedStream) Context() context.Context {
+	return ss.ctx
+}
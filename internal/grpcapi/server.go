@@ -0,0 +1,329 @@
+// Package grpcapi implements the gRPC control API alongside the REST API: the Timetable service defined in
+// timetable.proto, the standard health service and server reflection. Users, roles and certificates of the
+// REST API are used for gRPC calls as well
+package grpcapi
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative timetable.proto
+//go:generate protoc --go-grpc_out=. --go-grpc_opt=paths=source_relative timetable.proto
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/cybertec-postgresql/pg_timetable/internal/api"
+	"github.com/cybertec-postgresql/pg_timetable/internal/config"
+	"github.com/cybertec-postgresql/pg_timetable/internal/log"
+	"github.com/cybertec-postgresql/pg_timetable/internal/pgengine"
+	"github.com/cybertec-postgresql/pg_timetable/internal/scheduler"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// ServiceName is the full name of the Timetable service, e.g. for health checks
+var ServiceName = Timetable_ServiceDesc.ServiceName
+
+// ChainLister returns chains with the next run time and the latest run, see pgengine.PgEngine
+type ChainLister interface {
+	SelectChainOverview(ctx context.Context) ([]pgengine.ChainOverview, error)
+}
+
+const (
+	eventBufferSize = 256             // events buffered for the slow client before they are dropped
+	healthInterval  = 5 * time.Second // how often the scheduler readiness is reported to the health service
+	shutdownTimeout = 5 * time.Second
+)
+
+// Server serves the gRPC API on the REST API port or on the separate port
+type Server struct {
+	UnimplementedTimetableServer
+	APIHandler  api.RestHandler
+	ChainLister ChainLister
+	Events      api.EventSource
+	l           log.LoggerIface
+	auth        *api.AuthConfig // nil if authentication is not configured
+	grpc        *grpc.Server
+	health      *health.Server
+	listener    net.Listener // nil if the REST API port is shared
+	started     bool
+	stopping    chan struct{} // closed on shutdown to finish event streams
+}
+
+// Init starts the gRPC API server if enabled. The scheduler, the chain store and the event source
+// are taken from the REST API server, so it must be initialized first. Users and certificates are shared
+// with the running REST API server, otherwise the separate port loads them on its own
+func Init(opts config.RestAPIOpts, rest *api.RestAPIServer, logger log.LoggerIface) *Server {
+	if !opts.GRPC && opts.GRPCPort == 0 {
+		return &Server{l: logger}
+	}
+	auth, tlsConfig := rest.Auth(), rest.ServerTLSConfig()
+	switch {
+	case rest.Started():
+		// pass
+	case opts.GRPCPort > 0:
+		auth = api.LoadAuth(opts, logger, "gRPC API")
+		if opts.TLSCert != "" {
+			var err error
+			if tlsConfig, err = api.NewServerTLSConfig(opts, logger); err != nil {
+				logger.WithError(err).Error("Cannot load gRPC API certificate, gRPC API server is not started")
+				return &Server{l: logger}
+			}
+		}
+	default:
+		logger.Error("REST API server is not running, gRPC API server is not started")
+		return &Server{l: logger}
+	}
+	var serverOpts []grpc.ServerOption
+	if opts.GRPCPort > 0 && tlsConfig != nil {
+		serverOpts = append(serverOpts, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+	s := newServer(auth, logger, serverOpts...)
+	s.APIHandler, s.ChainLister, s.Events = rest.APIHandler, rest.HistoryStore, rest.Events
+	if opts.GRPCPort == 0 {
+		logger.Info("Serving gRPC API on the REST API port...")
+		rest.GRPCHandler = s.grpc
+	} else {
+		addr := net.JoinHostPort(opts.Address, strconv.Itoa(opts.GRPCPort))
+		listener, err := net.Listen("tcp", addr)
+		if err != nil {
+			logger.WithError(err).Error("Cannot listen on gRPC API port, gRPC API server is not started")
+			return &Server{l: logger}
+		}
+		logger.WithField("address", addr).WithField("tls", tlsConfig != nil).Info("Starting gRPC API server...")
+		s.listener = listener
+		go func() {
+			if err := s.grpc.Serve(listener); err != nil {
+				logger.Error(err)
+			}
+		}()
+	}
+	s.started = true
+	go s.watchHealth()
+	return s
+}
+
+// newServer returns the gRPC server with the Timetable, health and reflection services registered
+func newServer(auth *api.AuthConfig, logger log.LoggerIface, opts ...grpc.ServerOption) *Server {
+	s := &Server{l: logger, auth: auth, health: health.NewServer(), stopping: make(chan struct{})}
+	opts = append(opts, grpc.ChainUnaryInterceptor(s.unaryInterceptor), grpc.ChainStreamInterceptor(s.streamInterceptor))
+	s.grpc = grpc.NewServer(opts...)
+	RegisterTimetableServer(s.grpc, s)
+	healthpb.RegisterHealthServer(s.grpc, s.health)
+	reflection.Register(s.grpc)
+	s.setServingStatus(healthpb.HealthCheckResponse_NOT_SERVING)
+	return s
+}
+
+// Stop finishes event streams and stops the server waiting for active calls to complete
+func (s *Server) Stop() {
+	if !s.started {
+		return
+	}
+	s.l.Info("Shutting down gRPC API server...")
+	close(s.stopping)
+	s.health.Shutdown()
+	if s.listener == nil {
+		s.grpc.Stop() // graceful stop is not supported for calls served by the REST API server
+		return
+	}
+	timer := time.AfterFunc(shutdownTimeout, s.grpc.Stop)
+	defer timer.Stop()
+	s.grpc.GracefulStop()
+}
+
+func (s *Server) setServingStatus(st healthpb.HealthCheckResponse_ServingStatus) {
+	s.health.SetServingStatus("", st)
+	s.health.SetServingStatus(ServiceName, st)
+}
+
+// watchHealth reports the scheduler readiness to the health service until the server stops
+func (s *Server) watchHealth() {
+	ticker := time.NewTicker(healthInterval)
+	defer ticker.Stop()
+	for {
+		if s.APIHandler != nil && s.APIHandler.IsReady() {
+			s.setServingStatus(healthpb.HealthCheckResponse_SERVING)
+		} else {
+			s.setServingStatus(healthpb.HealthCheckResponse_NOT_SERVING)
+		}
+		select {
+		case <-ticker.C:
+		case <-s.stopping:
+			return
+		}
+	}
+}
+
+// StartChain starts the chain now, optionally with task parameter overrides
+func (s *Server) StartChain(ctx context.Context, req *StartChainRequest) (*StartChainResponse, error) {
+	if err := s.checkChain(ctx, req.GetChainId()); err != nil {
+		return nil, err
+	}
+	overrides, err := toOverrides(req.GetOverrides())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid overrides: "+err.Error())
+	}
+	if u := userOf(ctx); u != nil && len(overrides) > 0 && u.Role < api.RoleAdmin {
+		return nil, status.Errorf(codes.PermissionDenied, "parameter overrides are forbidden for the %s role", u.Role)
+	}
+	if err = s.APIHandler.StartChain(ctx, int(req.GetChainId()), overrides); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return &StartChainResponse{}, nil
+}
+
+// StopChain cancels the running chain
+func (s *Server) StopChain(ctx context.Context, req *StopChainRequest) (*StopChainResponse, error) {
+	if err := s.checkChain(ctx, req.GetChainId()); err != nil {
+		return nil, err
+	}
+	if err := s.APIHandler.StopChain(ctx, int(req.GetChainId())); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return &StopChainResponse{}, nil
+}
+
+// checkChain returns the error if the user cannot access the chain or the scheduler is not running
+func (s *Server) checkChain(ctx context.Context, chainID int32) error {
+	if !canAccess(ctx, int(chainID)) {
+		return status.Error(codes.PermissionDenied, "forbidden for the chain")
+	}
+	if s.APIHandler == nil {
+		return status.Error(codes.Unavailable, "scheduler is not running")
+	}
+	return nil
+}
+
+// ListChains returns chains the user can access with the next run time and the latest run
+func (s *Server) ListChains(ctx context.Context, _ *ListChainsRequest) (*ListChainsResponse, error) {
+	if s.ChainLister == nil {
+		return nil, status.Error(codes.Unavailable, "history store is not available")
+	}
+	chains, err := s.ChainLister.SelectChainOverview(ctx)
+	if err != nil {
+		s.l.WithError(err).Error("Cannot list chains")
+		return nil, status.Error(codes.Internal, "cannot list chains")
+	}
+	resp := &ListChainsResponse{Chains: make([]*Chain, 0, len(chains))}
+	for _, c := range chains {
+		if canAccess(ctx, c.ChainID) {
+			resp.Chains = append(resp.Chains, toChain(c))
+		}
+	}
+	return resp, nil
+}
+
+// StreamEvents sends scheduler events until the call is canceled or the server stops.
+// Users restricted to chains get events of their chains only
+func (s *Server) StreamEvents(req *StreamEventsRequest, stream grpc.ServerStreamingServer[Event]) error {
+	ctx := stream.Context()
+	if req.GetChainId() != 0 && !canAccess(ctx, int(req.GetChainId())) {
+		return status.Error(codes.PermissionDenied, "forbidden for the chain")
+	}
+	types := make([]scheduler.EventType, 0, len(req.GetTypes()))
+	for _, t := range req.GetTypes() {
+		if !slices.Contains(scheduler.EventTypes, scheduler.EventType(t)) {
+			return status.Errorf(codes.InvalidArgument, "unknown event type %q", t)
+		}
+		types = append(types, scheduler.EventType(t))
+	}
+	if s.Events == nil {
+		return status.Error(codes.Unavailable, "scheduler is not running")
+	}
+	events, unsubscribe := s.Events.Subscribe(eventBufferSize)
+	defer unsubscribe()
+	for {
+		select {
+		case e, ok := <-events:
+			if !ok {
+				return nil
+			}
+			if req.GetChainId() != 0 && int(req.GetChainId()) != e.ChainID || len(types) > 0 && !slices.Contains(types, e.Type) ||
+				!canAccess(ctx, e.ChainID) {
+				continue
+			}
+			if err := stream.Send(toEvent(e)); err != nil {
+				return err
+			}
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		case <-s.stopping:
+			return nil
+		}
+	}
+}
+
+// toOverrides converts the struct of task parameter overrides to the JSON values by the task name or ID
+func toOverrides(st *structpb.Struct) (pgengine.ParamOverrides, error) {
+	if len(st.GetFields()) == 0 {
+		return nil, nil
+	}
+	overrides := make(pgengine.ParamOverrides, len(st.GetFields()))
+	for task, value := range st.GetFields() {
+		data, err := json.Marshal(value.AsInterface())
+		if err != nil {
+			return nil, fmt.Errorf("task %s: %w", task, err)
+		}
+		overrides[task] = data
+	}
+	return overrides, nil
+}
+
+func toChain(c pgengine.ChainOverview) *Chain {
+	chain := &Chain{
+		Id:         int32(c.ChainID),
+		Name:       c.ChainName,
+		Schedule:   c.Schedule,
+		Live:       c.Live,
+		ClientName: c.ClientName,
+		NextRun:    toTimestamp(c.NextRun),
+	}
+	if r := c.LastRun; r != nil {
+		chain.LastRun = &ChainRun{
+			RunId:      r.RunID,
+			Trigger:    r.Trigger,
+			StartedAt:  timestamppb.New(r.StartedAt),
+			FinishedAt: toTimestamp(r.FinishedAt),
+			Status:     r.Status,
+			Error:      r.Error,
+			ClientName: r.ClientName,
+		}
+	}
+	return chain
+}
+
+func toEvent(e scheduler.Event) *Event {
+	return &Event{
+		Id:        e.ID,
+		Type:      string(e.Type),
+		Time:      timestamppb.New(e.Time),
+		ChainId:   int32(e.ChainID),
+		ChainName: e.ChainName,
+		RunId:     e.RunID,
+		TaskId:    int32(e.TaskID),
+		TaskName:  e.TaskName,
+		Status:    e.Status,
+		Error:     e.Error,
+		Level:     e.Level,
+		Message:   e.Message,
+		Fields:    e.Fields,
+	}
+}
+
+func toTimestamp(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+	return timestamppb.New(*t)
+}
@@ -0,0 +1,376 @@
+package grpcapi
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/cybertec-postgresql/pg_timetable/internal/api"
+	"github.com/cybertec-postgresql/pg_timetable/internal/config"
+	"github.com/cybertec-postgresql/pg_timetable/internal/log"
+	"github.com/cybertec-postgresql/pg_timetable/internal/pgengine"
+	"github.com/cybertec-postgresql/pg_timetable/internal/scheduler"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+type apihandler struct {
+	started   []int
+	stopped   []int
+	overrides pgengine.ParamOverrides
+}
+
+func (h *apihandler) IsReady() bool {
+	return true
+}
+
+func (h *apihandler) StartChain(_ context.Context, chainID int, overrides pgengine.ParamOverrides) error {
+	if chainID == 0 {
+		return errors.New("invalid chain id")
+	}
+	h.started, h.overrides = append(h.started, chainID), overrides
+	return nil
+}
+
+func (h *apihandler) StopChain(_ context.Context, chainID int) error {
+	h.stopped = append(h.stopped, chainID)
+	return nil
+}
+
+func (h *apihandler) Status() scheduler.Status {
+	return scheduler.Status{Ready: true}
+}
+
+type chainlister struct {
+	chains []pgengine.ChainOverview
+	err    error
+}
+
+func (l *chainlister) SelectChainOverview(context.Context) ([]pgengine.ChainOverview, error) {
+	return l.chains, l.err
+}
+
+// eventsource returns the channel with events published already, the channel is closed to finish the stream
+type eventsource struct {
+	events []scheduler.Event
+}
+
+func (s *eventsource) Subscribe(size int) (<-chan scheduler.Event, func()) {
+	ch := make(chan scheduler.Event, size)
+	for _, e := range s.events {
+		ch <- e
+	}
+	close(ch)
+	return ch, func() {}
+}
+
+var logger = log.Init(config.LoggingOpts{LogLevel: "panic"})
+
+// serve starts the server on the in-memory listener and returns the client connection to it
+func serve(t *testing.T, s *Server) *grpc.ClientConn {
+	listener := bufconn.Listen(1 << 20)
+	go func() { _ = s.grpc.Serve(listener) }()
+	t.Cleanup(s.grpc.Stop)
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	return conn
+}
+
+func withToken(token string) context.Context {
+	return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+token)
+}
+
+func authConfig(t *testing.T) *api.AuthConfig {
+	hash := func(token string) string {
+		h := sha256.Sum256([]byte(token))
+		return hex.EncodeToString(h[:])
+	}
+	filename := filepath.Join(t.TempDir(), "auth.yaml")
+	require.NoError(t, os.WriteFile(filename, []byte(`
+users:
+  - name: viewer
+    token_sha256: `+hash("viewer-secret")+`
+    role: read-only
+  - name: operator
+    token_sha256: `+hash("operator-secret")+`
+    role: operator
+  - name: scoped
+    token_sha256: `+hash("scoped-secret")+`
+    role: operator
+    chains: [42]
+  - name: admin
+    token_sha256: `+hash("admin-secret")+`
+    role: admin
+`), 0600))
+	auth, err := api.LoadAuthConfig(filename)
+	require.NoError(t, err)
+	return auth
+}
+
+func TestChainControl(t *testing.T) {
+	s := newServer(nil, logger)
+	c := NewTimetableClient(serve(t, s))
+	ctx := context.Background()
+
+	_, err := c.StartChain(ctx, &StartChainRequest{ChainId: 42})
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+
+	h := &apihandler{}
+	s.APIHandler = h
+	overrides, _ := structpb.NewStruct(map[string]any{"report": map[string]any{"limit": 10}, "7": "foo"})
+	_, err = c.StartChain(ctx, &StartChainRequest{ChainId: 42, Overrides: overrides})
+	assert.NoError(t, err)
+	assert.Equal(t, []int{42}, h.started)
+	assert.JSONEq(t, `{"limit": 10}`, string(h.overrides["report"]))
+	assert.JSONEq(t, `"foo"`, string(h.overrides["7"]))
+
+	_, err = c.StartChain(ctx, &StartChainRequest{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.Contains(t, status.Convert(err).Message(), "invalid chain id")
+
+	_, err = c.StopChain(ctx, &StopChainRequest{ChainId: 24})
+	assert.NoError(t, err)
+	assert.Equal(t, []int{24}, h.stopped)
+}
+
+func TestListChains(t *testing.T) {
+	s := newServer(nil, logger)
+	c := NewTimetableClient(serve(t, s))
+
+	_, err := c.ListChains(context.Background(), &ListChainsRequest{})
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+
+	s.ChainLister = &chainlister{err: errors.New("connection lost")}
+	_, err = c.ListChains(context.Background(), &ListChainsRequest{})
+	assert.Equal(t, codes.Internal, status.Code(err))
+
+	next := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	s.ChainLister = &chainlister{chains: []pgengine.ChainOverview{
+		{ChainID: 24, ChainName: "paused", Schedule: "@every 1 hour"},
+		{ChainID: 42, ChainName: "report", Schedule: "0 12 * * *", Live: true, NextRun: &next,
+			LastRun: &pgengine.ChainRun{RunID: 7, StartedAt: next.Add(-24 * time.Hour), Status: "FAILED", Error: "boom"}},
+	}}
+	resp, err := c.ListChains(context.Background(), &ListChainsRequest{})
+	require.NoError(t, err)
+	require.Len(t, resp.GetChains(), 2)
+	assert.Nil(t, resp.GetChains()[0].GetNextRun())
+	assert.Nil(t, resp.GetChains()[0].GetLastRun())
+	chain := resp.GetChains()[1]
+	assert.Equal(t, int32(42), chain.GetId())
+	assert.True(t, chain.GetLive())
+	assert.Equal(t, next, chain.GetNextRun().AsTime())
+	assert.Equal(t, int64(7), chain.GetLastRun().GetRunId())
+	assert.Equal(t, "boom", chain.GetLastRun().GetError())
+	assert.Nil(t, chain.GetLastRun().GetFinishedAt())
+}
+
+func TestStreamEvents(t *testing.T) {
+	s := newServer(nil, logger)
+	c := NewTimetableClient(serve(t, s))
+	recvAll := func(req *StreamEventsRequest) ([]*Event, error) {
+		stream, err := c.StreamEvents(context.Background(), req)
+		require.NoError(t, err)
+		var events []*Event
+		for {
+			e, err := stream.Recv()
+			if errors.Is(err, io.EOF) {
+				return events, nil
+			}
+			if err != nil {
+				return events, err
+			}
+			events = append(events, e)
+		}
+	}
+
+	_, err := recvAll(&StreamEventsRequest{})
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+
+	s.Events = &eventsource{events: []scheduler.Event{
+		{ID: 1, Type: scheduler.EventChainStarted, ChainID: 42, RunID: 7},
+		{ID: 2, Type: scheduler.EventLog, ChainID: 24, Message: "foo", Fields: map[string]string{"task": "bar"}},
+		{ID: 3, Type: scheduler.EventChainFinished, ChainID: 42, RunID: 7, Status: "SUCCEEDED"},
+	}}
+	events, err := recvAll(&StreamEventsRequest{})
+	assert.NoError(t, err)
+	require.Len(t, events, 3)
+	assert.Equal(t, "chain_started", events[0].GetType())
+	assert.Equal(t, map[string]string{"task": "bar"}, events[1].GetFields())
+
+	events, err = recvAll(&StreamEventsRequest{ChainId: 42, Types: []string{"chain_finished"}})
+	assert.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, uint64(3), events[0].GetId())
+	assert.Equal(t, "SUCCEEDED", events[0].GetStatus())
+
+	_, err = recvAll(&StreamEventsRequest{Types: []string{"foo"}})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestAuthorization(t *testing.T) {
+	s := newServer(authConfig(t), logger)
+	s.APIHandler = &apihandler{}
+	s.ChainLister = &chainlister{chains: []pgengine.ChainOverview{{ChainID: 24}, {ChainID: 42}}}
+	s.Events = &eventsource{events: []scheduler.Event{
+		{ID: 1, Type: scheduler.EventChainStarted, ChainID: 24},
+		{ID: 2, Type: scheduler.EventChainStarted, ChainID: 42},
+	}}
+	conn := serve(t, s)
+	c := NewTimetableClient(conn)
+
+	_, err := c.StartChain(context.Background(), &StartChainRequest{ChainId: 42})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	_, err = c.StartChain(withToken("wrong"), &StartChainRequest{ChainId: 42})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	_, err = c.StartChain(withToken("viewer-secret"), &StartChainRequest{ChainId: 42})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, err = c.StartChain(withToken("operator-secret"), &StartChainRequest{ChainId: 42})
+	assert.NoError(t, err)
+	overrides, _ := structpb.NewStruct(map[string]any{"report": map[string]any{"day": "2026-01-01"}})
+	_, err = c.StartChain(withToken("operator-secret"), &StartChainRequest{ChainId: 42, Overrides: overrides})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err), "overrides require admin")
+	_, err = c.StartChain(withToken("admin-secret"), &StartChainRequest{ChainId: 42, Overrides: overrides})
+	assert.NoError(t, err)
+
+	_, err = c.StopChain(withToken("scoped-secret"), &StopChainRequest{ChainId: 24})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, err = c.StopChain(withToken("scoped-secret"), &StopChainRequest{ChainId: 42})
+	assert.NoError(t, err)
+
+	resp, err := c.ListChains(withToken("viewer-secret"), &ListChainsRequest{})
+	assert.NoError(t, err)
+	assert.Len(t, resp.GetChains(), 2)
+	resp, err = c.ListChains(withToken("scoped-secret"), &ListChainsRequest{})
+	assert.NoError(t, err)
+	require.Len(t, resp.GetChains(), 1)
+	assert.Equal(t, int32(42), resp.GetChains()[0].GetId())
+
+	stream, err := c.StreamEvents(withToken("scoped-secret"), &StreamEventsRequest{})
+	require.NoError(t, err)
+	e, err := stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), e.GetId(), "events of chains out of scope are filtered")
+	_, err = stream.Recv()
+	assert.ErrorIs(t, err, io.EOF)
+	stream, err = c.StreamEvents(withToken("scoped-secret"), &StreamEventsRequest{ChainId: 24})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	stream, err = c.StreamEvents(withToken("scoped-secret"), &StreamEventsRequest{ChainId: 42})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	assert.NoError(t, err)
+
+	// health checks and reflection are open
+	_, err = healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{Service: ServiceName})
+	assert.NoError(t, err)
+	refl, err := reflectionpb.NewServerReflectionClient(conn).ServerReflectionInfo(context.Background())
+	require.NoError(t, err)
+	require.NoError(t, refl.Send(&reflectionpb.ServerReflectionRequest{
+		MessageRequest: &reflectionpb.ServerReflectionRequest_ListServices{}}))
+	info, err := refl.Recv()
+	require.NoError(t, err)
+	var services []string
+	for _, svc := range info.GetListServicesResponse().GetService() {
+		services = append(services, svc.GetName())
+	}
+	assert.Contains(t, services, ServiceName)
+	assert.Contains(t, services, "grpc.health.v1.Health")
+
+	// methods without the role are denied even if authentication is not configured
+	for _, srv := range []*Server{s, newServer(nil, logger)} {
+		_, err = srv.authorize(withToken("admin-secret"), "/"+ServiceName+"/NewMethod")
+		assert.Equal(t, codes.PermissionDenied, status.Code(err))
+		_, err = srv.authorize(context.Background(), "/grpc.reflection.v1alpha.ServerReflection/ServerReflectionInfo")
+		assert.NoError(t, err)
+	}
+}
+
+func TestHealth(t *testing.T) {
+	s := newServer(nil, logger)
+	health := healthpb.NewHealthClient(serve(t, s))
+	check := func() healthpb.HealthCheckResponse_ServingStatus {
+		resp, err := health.Check(context.Background(), &healthpb.HealthCheckRequest{Service: ServiceName})
+		require.NoError(t, err)
+		return resp.GetStatus()
+	}
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, check())
+
+	s.APIHandler = &apihandler{}
+	go s.watchHealth()
+	assert.Eventually(t, func() bool { return check() == healthpb.HealthCheckResponse_SERVING }, time.Second, 10*time.Millisecond)
+	close(s.stopping)
+}
+
+// freePort returns the TCP port not used at the moment
+func freePort(t *testing.T) int {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	return listener.Addr().(*net.TCPAddr).Port
+}
+
+func TestInit(t *testing.T) {
+	rest := api.Init(config.RestAPIOpts{}, logger)
+	s := Init(config.RestAPIOpts{}, rest, logger)
+	assert.False(t, s.started)
+	s.Stop()
+
+	s = Init(config.RestAPIOpts{GRPC: true}, rest, logger)
+	assert.False(t, s.started, "REST API server is not running")
+
+	// the separate port loads users and certificates on its own if REST API is not running
+	authFile := filepath.Join(t.TempDir(), "auth.yaml")
+	require.NoError(t, os.WriteFile(authFile, []byte("users: []"), 0600))
+	s = Init(config.RestAPIOpts{Address: "127.0.0.1", GRPCPort: freePort(t), AuthFile: authFile}, rest, logger)
+	require.True(t, s.started)
+	assert.NotNil(t, s.auth)
+	s.Stop()
+	s = Init(config.RestAPIOpts{Address: "127.0.0.1", GRPCPort: freePort(t), TLSCert: "missing.pem", TLSKey: "missing.key"}, rest, logger)
+	assert.False(t, s.started, "certificate cannot be loaded")
+
+	for _, opts := range []config.RestAPIOpts{
+		{Address: "127.0.0.1", Port: freePort(t), GRPC: true},
+		{Address: "127.0.0.1", Port: freePort(t), GRPCPort: freePort(t)},
+		{Address: "127.0.0.1", GRPCPort: freePort(t)},
+	} {
+		rest := api.Init(opts, logger)
+		rest.APIHandler = &apihandler{}
+		s := Init(opts, rest, logger)
+		require.True(t, s.started)
+		port := opts.GRPCPort
+		if port == 0 {
+			port = opts.Port
+		}
+		conn, err := grpc.NewClient(net.JoinHostPort(opts.Address, strconv.Itoa(port)),
+			grpc.WithTransportCredentials(insecure.NewCredentials()))
+		require.NoError(t, err)
+		assert.Eventually(t, func() bool {
+			_, err = NewTimetableClient(conn).StopChain(context.Background(), &StopChainRequest{ChainId: 42})
+			return err == nil
+		}, 5*time.Second, 50*time.Millisecond, "gRPC API on port %d", port)
+		_ = conn.Close()
+		s.Stop()
+		rest.Stop()
+	}
+}
@@ -0,0 +1,795 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: timetable.proto
+
+package grpcapi
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	structpb "google.golang.org/protobuf/types/known/structpb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type StartChainRequest struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	ChainId int32                  `protobuf:"varint,1,opt,name=chain_id,json=chainId,proto3" json:"chain_id,omitempty"`
+	// Parameter values by the task name or ID. An object is merged into object parameters, any other value replaces them
+	Overrides     *structpb.Struct `protobuf:"bytes,2,opt,name=overrides,proto3" json:"overrides,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StartChainRequest) Reset() {
+	*x = StartChainRequest{}
+	mi := &file_timetable_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StartChainRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StartChainRequest) ProtoMessage() {}
+
+func (x *StartChainRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_timetable_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StartChainRequest.ProtoReflect.Descriptor instead.
+func (*StartChainRequest) Descriptor() ([]byte, []int) {
+	return file_timetable_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *StartChainRequest) GetChainId() int32 {
+	if x != nil {
+		return x.ChainId
+	}
+	return 0
+}
+
+func (x *StartChainRequest) GetOverrides() *structpb.Struct {
+	if x != nil {
+		return x.Overrides
+	}
+	return nil
+}
+
+type StartChainResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StartChainResponse) Reset() {
+	*x = StartChainResponse{}
+	mi := &file_timetable_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StartChainResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StartChainResponse) ProtoMessage() {}
+
+func (x *StartChainResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_timetable_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StartChainResponse.ProtoReflect.Descriptor instead.
+func (*StartChainResponse) Descriptor() ([]byte, []int) {
+	return file_timetable_proto_rawDescGZIP(), []int{1}
+}
+
+type StopChainRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ChainId       int32                  `protobuf:"varint,1,opt,name=chain_id,json=chainId,proto3" json:"chain_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StopChainRequest) Reset() {
+	*x = StopChainRequest{}
+	mi := &file_timetable_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StopChainRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StopChainRequest) ProtoMessage() {}
+
+func (x *StopChainRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_timetable_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StopChainRequest.ProtoReflect.Descriptor instead.
+func (*StopChainRequest) Descriptor() ([]byte, []int) {
+	return file_timetable_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *StopChainRequest) GetChainId() int32 {
+	if x != nil {
+		return x.ChainId
+	}
+	return 0
+}
+
+type StopChainResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StopChainResponse) Reset() {
+	*x = StopChainResponse{}
+	mi := &file_timetable_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StopChainResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StopChainResponse) ProtoMessage() {}
+
+func (x *StopChainResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_timetable_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StopChainResponse.ProtoReflect.Descriptor instead.
+func (*StopChainResponse) Descriptor() ([]byte, []int) {
+	return file_timetable_proto_rawDescGZIP(), []int{3}
+}
+
+type ListChainsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListChainsRequest) Reset() {
+	*x = ListChainsRequest{}
+	mi := &file_timetable_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListChainsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListChainsRequest) ProtoMessage() {}
+
+func (x *ListChainsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_timetable_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListChainsRequest.ProtoReflect.Descriptor instead.
+func (*ListChainsRequest) Descriptor() ([]byte, []int) {
+	return file_timetable_proto_rawDescGZIP(), []int{4}
+}
+
+type ListChainsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Chains        []*Chain               `protobuf:"bytes,1,rep,name=chains,proto3" json:"chains,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListChainsResponse) Reset() {
+	*x = ListChainsResponse{}
+	mi := &file_timetable_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListChainsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListChainsResponse) ProtoMessage() {}
+
+func (x *ListChainsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_timetable_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListChainsResponse.ProtoReflect.Descriptor instead.
+func (*ListChainsResponse) Descriptor() ([]byte, []int) {
+	return file_timetable_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ListChainsResponse) GetChains() []*Chain {
+	if x != nil {
+		return x.Chains
+	}
+	return nil
+}
+
+type Chain struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	Id         int32                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name       string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Schedule   string                 `protobuf:"bytes,3,opt,name=schedule,proto3" json:"schedule,omitempty"`
+	Live       bool                   `protobuf:"varint,4,opt,name=live,proto3" json:"live,omitempty"`
+	ClientName string                 `protobuf:"bytes,5,opt,name=client_name,json=clientName,proto3" json:"client_name,omitempty"`
+	// Live chains with cron schedules only
+	NextRun *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=next_run,json=nextRun,proto3" json:"next_run,omitempty"`
+	// Not set if the chain has never run
+	LastRun       *ChainRun `protobuf:"bytes,7,opt,name=last_run,json=lastRun,proto3" json:"last_run,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Chain) Reset() {
+	*x = Chain{}
+	mi := &file_timetable_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Chain) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Chain) ProtoMessage() {}
+
+func (x *Chain) ProtoReflect() protoreflect.Message {
+	mi := &file_timetable_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Chain.ProtoReflect.Descriptor instead.
+func (*Chain) Descriptor() ([]byte, []int) {
+	return file_timetable_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *Chain) GetId() int32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Chain) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Chain) GetSchedule() string {
+	if x != nil {
+		return x.Schedule
+	}
+	return ""
+}
+
+func (x *Chain) GetLive() bool {
+	if x != nil {
+		return x.Live
+	}
+	return false
+}
+
+func (x *Chain) GetClientName() string {
+	if x != nil {
+		return x.ClientName
+	}
+	return ""
+}
+
+func (x *Chain) GetNextRun() *timestamppb.Timestamp {
+	if x != nil {
+		return x.NextRun
+	}
+	return nil
+}
+
+func (x *Chain) GetLastRun() *ChainRun {
+	if x != nil {
+		return x.LastRun
+	}
+	return nil
+}
+
+type ChainRun struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	RunId      int64                  `protobuf:"varint,1,opt,name=run_id,json=runId,proto3" json:"run_id,omitempty"`
+	Trigger    string                 `protobuf:"bytes,2,opt,name=trigger,proto3" json:"trigger,omitempty"`
+	StartedAt  *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=started_at,json=startedAt,proto3" json:"started_at,omitempty"`
+	FinishedAt *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=finished_at,json=finishedAt,proto3" json:"finished_at,omitempty"`
+	// RUNNING, SUCCEEDED, FAILED or CANCELLED
+	Status        string `protobuf:"bytes,5,opt,name=status,proto3" json:"status,omitempty"`
+	Error         string `protobuf:"bytes,6,opt,name=error,proto3" json:"error,omitempty"`
+	ClientName    string `protobuf:"bytes,7,opt,name=client_name,json=clientName,proto3" json:"client_name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ChainRun) Reset() {
+	*x = ChainRun{}
+	mi := &file_timetable_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ChainRun) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChainRun) ProtoMessage() {}
+
+func (x *ChainRun) ProtoReflect() protoreflect.Message {
+	mi := &file_timetable_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChainRun.ProtoReflect.Descriptor instead.
+func (*ChainRun) Descriptor() ([]byte, []int) {
+	return file_timetable_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *ChainRun) GetRunId() int64 {
+	if x != nil {
+		return x.RunId
+	}
+	return 0
+}
+
+func (x *ChainRun) GetTrigger() string {
+	if x != nil {
+		return x.Trigger
+	}
+	return ""
+}
+
+func (x *ChainRun) GetStartedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.StartedAt
+	}
+	return nil
+}
+
+func (x *ChainRun) GetFinishedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.FinishedAt
+	}
+	return nil
+}
+
+func (x *ChainRun) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *ChainRun) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *ChainRun) GetClientName() string {
+	if x != nil {
+		return x.ClientName
+	}
+	return ""
+}
+
+type StreamEventsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Events of the chain only, all chains if 0
+	ChainId int32 `protobuf:"varint,1,opt,name=chain_id,json=chainId,proto3" json:"chain_id,omitempty"`
+	// chain_started, chain_finished, chain_failed, task_started, task_finished or log, all types if empty
+	Types         []string `protobuf:"bytes,2,rep,name=types,proto3" json:"types,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamEventsRequest) Reset() {
+	*x = StreamEventsRequest{}
+	mi := &file_timetable_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamEventsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamEventsRequest) ProtoMessage() {}
+
+func (x *StreamEventsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_timetable_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamEventsRequest.ProtoReflect.Descriptor instead.
+func (*StreamEventsRequest) Descriptor() ([]byte, []int) {
+	return file_timetable_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *StreamEventsRequest) GetChainId() int32 {
+	if x != nil {
+		return x.ChainId
+	}
+	return 0
+}
+
+func (x *StreamEventsRequest) GetTypes() []string {
+	if x != nil {
+		return x.Types
+	}
+	return nil
+}
+
+type Event struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            uint64                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Type          string                 `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"`
+	Time          *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=time,proto3" json:"time,omitempty"`
+	ChainId       int32                  `protobuf:"varint,4,opt,name=chain_id,json=chainId,proto3" json:"chain_id,omitempty"`
+	ChainName     string                 `protobuf:"bytes,5,opt,name=chain_name,json=chainName,proto3" json:"chain_name,omitempty"`
+	RunId         int64                  `protobuf:"varint,6,opt,name=run_id,json=runId,proto3" json:"run_id,omitempty"`
+	TaskId        int32                  `protobuf:"varint,7,opt,name=task_id,json=taskId,proto3" json:"task_id,omitempty"`
+	TaskName      string                 `protobuf:"bytes,8,opt,name=task_name,json=taskName,proto3" json:"task_name,omitempty"`
+	Status        string                 `protobuf:"bytes,9,opt,name=status,proto3" json:"status,omitempty"`
+	Error         string                 `protobuf:"bytes,10,opt,name=error,proto3" json:"error,omitempty"`
+	Level         string                 `protobuf:"bytes,11,opt,name=level,proto3" json:"level,omitempty"`
+	Message       string                 `protobuf:"bytes,12,opt,name=message,proto3" json:"message,omitempty"`
+	Fields        map[string]string      `protobuf:"bytes,13,rep,name=fields,proto3" json:"fields,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Event) Reset() {
+	*x = Event{}
+	mi := &file_timetable_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Event) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Event) ProtoMessage() {}
+
+func (x *Event) ProtoReflect() protoreflect.Message {
+	mi := &file_timetable_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Event.ProtoReflect.Descriptor instead.
+func (*Event) Descriptor() ([]byte, []int) {
+	return file_timetable_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *Event) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Event) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *Event) GetTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Time
+	}
+	return nil
+}
+
+func (x *Event) GetChainId() int32 {
+	if x != nil {
+		return x.ChainId
+	}
+	return 0
+}
+
+func (x *Event) GetChainName() string {
+	if x != nil {
+		return x.ChainName
+	}
+	return ""
+}
+
+func (x *Event) GetRunId() int64 {
+	if x != nil {
+		return x.RunId
+	}
+	return 0
+}
+
+func (x *Event) GetTaskId() int32 {
+	if x != nil {
+		return x.TaskId
+	}
+	return 0
+}
+
+func (x *Event) GetTaskName() string {
+	if x != nil {
+		return x.TaskName
+	}
+	return ""
+}
+
+func (x *Event) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *Event) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *Event) GetLevel() string {
+	if x != nil {
+		return x.Level
+	}
+	return ""
+}
+
+func (x *Event) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *Event) GetFields() map[string]string {
+	if x != nil {
+		return x.Fields
+	}
+	return nil
+}
+
+var File_timetable_proto protoreflect.FileDescriptor
+
+const file_timetable_proto_rawDesc = "" +
+	"\n" +
+	"\x0ftimetable.proto\x12\x0epgtimetable.v1\x1a\x1cgoogle/protobuf/struct.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"e\n" +
+	"\x11StartChainRequest\x12\x19\n" +
+	"\bchain_id\x18\x01 \x01(\x05R\achainId\x125\n" +
+	"\toverrides\x18\x02 \x01(\v2\x17.google.protobuf.StructR\toverrides\"\x14\n" +
+	"\x12StartChainResponse\"-\n" +
+	"\x10StopChainRequest\x12\x19\n" +
+	"\bchain_id\x18\x01 \x01(\x05R\achainId\"\x13\n" +
+	"\x11StopChainResponse\"\x13\n" +
+	"\x11ListChainsRequest\"C\n" +
+	"\x12ListChainsResponse\x12-\n" +
+	"\x06chains\x18\x01 \x03(\v2\x15.pgtimetable.v1.ChainR\x06chains\"\xe8\x01\n" +
+	"\x05Chain\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x05R\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x1a\n" +
+	"\bschedule\x18\x03 \x01(\tR\bschedule\x12\x12\n" +
+	"\x04live\x18\x04 \x01(\bR\x04live\x12\x1f\n" +
+	"\vclient_name\x18\x05 \x01(\tR\n" +
+	"clientName\x125\n" +
+	"\bnext_run\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\anextRun\x123\n" +
+	"\blast_run\x18\a \x01(\v2\x18.pgtimetable.v1.ChainRunR\alastRun\"\x82\x02\n" +
+	"\bChainRun\x12\x15\n" +
+	"\x06run_id\x18\x01 \x01(\x03R\x05runId\x12\x18\n" +
+	"\atrigger\x18\x02 \x01(\tR\atrigger\x129\n" +
+	"\n" +
+	"started_at\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\tstartedAt\x12;\n" +
+	"\vfinished_at\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"finishedAt\x12\x16\n" +
+	"\x06status\x18\x05 \x01(\tR\x06status\x12\x14\n" +
+	"\x05error\x18\x06 \x01(\tR\x05error\x12\x1f\n" +
+	"\vclient_name\x18\a \x01(\tR\n" +
+	"clientName\"F\n" +
+	"\x13StreamEventsRequest\x12\x19\n" +
+	"\bchain_id\x18\x01 \x01(\x05R\achainId\x12\x14\n" +
+	"\x05types\x18\x02 \x03(\tR\x05types\"\xb6\x03\n" +
+	"\x05Event\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x04R\x02id\x12\x12\n" +
+	"\x04type\x18\x02 \x01(\tR\x04type\x12.\n" +
+	"\x04time\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\x04time\x12\x19\n" +
+	"\bchain_id\x18\x04 \x01(\x05R\achainId\x12\x1d\n" +
+	"\n" +
+	"chain_name\x18\x05 \x01(\tR\tchainName\x12\x15\n" +
+	"\x06run_id\x18\x06 \x01(\x03R\x05runId\x12\x17\n" +
+	"\atask_id\x18\a \x01(\x05R\x06taskId\x12\x1b\n" +
+	"\ttask_name\x18\b \x01(\tR\btaskName\x12\x16\n" +
+	"\x06status\x18\t \x01(\tR\x06status\x12\x14\n" +
+	"\x05error\x18\n" +
+	" \x01(\tR\x05error\x12\x14\n" +
+	"\x05level\x18\v \x01(\tR\x05level\x12\x18\n" +
+	"\amessage\x18\f \x01(\tR\amessage\x129\n" +
+	"\x06fields\x18\r \x03(\v2!.pgtimetable.v1.Event.FieldsEntryR\x06fields\x1a9\n" +
+	"\vFieldsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x012\xd5\x02\n" +
+	"\tTimetable\x12S\n" +
+	"\n" +
+	"StartChain\x12!.pgtimetable.v1.StartChainRequest\x1a\".pgtimetable.v1.StartChainResponse\x12P\n" +
+	"\tStopChain\x12 .pgtimetable.v1.StopChainRequest\x1a!.pgtimetable.v1.StopChainResponse\x12S\n" +
+	"\n" +
+	"ListChains\x12!.pgtimetable.v1.ListChainsRequest\x1a\".pgtimetable.v1.ListChainsResponse\x12L\n" +
+	"\fStreamEvents\x12#.pgtimetable.v1.StreamEventsRequest\x1a\x15.pgtimetable.v1.Event0\x01B>Z<github.com/cybertec-postgresql/pg_timetable/internal/grpcapib\x06proto3"
+
+var (
+	file_timetable_proto_rawDescOnce sync.Once
+	file_timetable_proto_rawDescData []byte
+)
+
+func file_timetable_proto_rawDescGZIP() []byte {
+	file_timetable_proto_rawDescOnce.Do(func() {
+		file_timetable_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_timetable_proto_rawDesc), len(file_timetable_proto_rawDesc)))
+	})
+	return file_timetable_proto_rawDescData
+}
+
+var file_timetable_proto_msgTypes = make([]protoimpl.MessageInfo, 11)
+var file_timetable_proto_goTypes = []any{
+	(*StartChainRequest)(nil),     // 0: pgtimetable.v1.StartChainRequest
+	(*StartChainResponse)(nil),    // 1: pgtimetable.v1.StartChainResponse
+	(*StopChainRequest)(nil),      // 2: pgtimetable.v1.StopChainRequest
+	(*StopChainResponse)(nil),     // 3: pgtimetable.v1.StopChainResponse
+	(*ListChainsRequest)(nil),     // 4: pgtimetable.v1.ListChainsRequest
+	(*ListChainsResponse)(nil),    // 5: pgtimetable.v1.ListChainsResponse
+	(*Chain)(nil),                 // 6: pgtimetable.v1.Chain
+	(*ChainRun)(nil),              // 7: pgtimetable.v1.ChainRun
+	(*StreamEventsRequest)(nil),   // 8: pgtimetable.v1.StreamEventsRequest
+	(*Event)(nil),                 // 9: pgtimetable.v1.Event
+	nil,                           // 10: pgtimetable.v1.Event.FieldsEntry
+	(*structpb.Struct)(nil),       // 11: google.protobuf.Struct
+	(*timestamppb.Timestamp)(nil), // 12: google.protobuf.Timestamp
+}
+var file_timetable_proto_depIdxs = []int32{
+	11, // 0: pgtimetable.v1.StartChainRequest.overrides:type_name -> google.protobuf.Struct
+	6,  // 1: pgtimetable.v1.ListChainsResponse.chains:type_name -> pgtimetable.v1.Chain
+	12, // 2: pgtimetable.v1.Chain.next_run:type_name -> google.protobuf.Timestamp
+	7,  // 3: pgtimetable.v1.Chain.last_run:type_name -> pgtimetable.v1.ChainRun
+	12, // 4: pgtimetable.v1.ChainRun.started_at:type_name -> google.protobuf.Timestamp
+	12, // 5: pgtimetable.v1.ChainRun.finished_at:type_name -> google.protobuf.Timestamp
+	12, // 6: pgtimetable.v1.Event.time:type_name -> google.protobuf.Timestamp
+	10, // 7: pgtimetable.v1.Event.fields:type_name -> pgtimetable.v1.Event.FieldsEntry
+	0,  // 8: pgtimetable.v1.Timetable.StartChain:input_type -> pgtimetable.v1.StartChainRequest
+	2,  // 9: pgtimetable.v1.Timetable.StopChain:input_type -> pgtimetable.v1.StopChainRequest
+	4,  // 10: pgtimetable.v1.Timetable.ListChains:input_type -> pgtimetable.v1.ListChainsRequest
+	8,  // 11: pgtimetable.v1.Timetable.StreamEvents:input_type -> pgtimetable.v1.StreamEventsRequest
+	1,  // 12: pgtimetable.v1.Timetable.StartChain:output_type -> pgtimetable.v1.StartChainResponse
+	3,  // 13: pgtimetable.v1.Timetable.StopChain:output_type -> pgtimetable.v1.StopChainResponse
+	5,  // 14: pgtimetable.v1.Timetable.ListChains:output_type -> pgtimetable.v1.ListChainsResponse
+	9,  // 15: pgtimetable.v1.Timetable.StreamEvents:output_type -> pgtimetable.v1.Event
+	12, // [12:16] is the sub-list for method output_type
+	8,  // [8:12] is the sub-list for method input_type
+	8,  // [8:8] is the sub-list for extension type_name
+	8,  // [8:8] is the sub-list for extension extendee
+	0,  // [0:8] is the sub-list for field type_name
+}
+
+func init() { file_timetable_proto_init() }
+func file_timetable_proto_init() {
+	if File_timetable_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_timetable_proto_rawDesc), len(file_timetable_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   11,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_timetable_proto_goTypes,
+		DependencyIndexes: file_timetable_proto_depIdxs,
+		MessageInfos:      file_timetable_proto_msgTypes,
+	}.Build()
+	File_timetable_proto = out.File
+	file_timetable_proto_goTypes = nil
+	file_timetable_proto_depIdxs = nil
+}
@@ -0,0 +1,251 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: timetable.proto
+
+package grpcapi
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Timetable_StartChain_FullMethodName   = "/pgtimetable.v1.Timetable/StartChain"
+	Timetable_StopChain_FullMethodName    = "/pgtimetable.v1.Timetable/StopChain"
+	Timetable_ListChains_FullMethodName   = "/pgtimetable.v1.Timetable/ListChains"
+	Timetable_StreamEvents_FullMethodName = "/pgtimetable.v1.Timetable/StreamEvents"
+)
+
+// TimetableClient is the client API for Timetable service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Timetable controls the pg_timetable scheduler, operations follow the REST API
+type TimetableClient interface {
+	// StartChain starts the chain now, optionally with task parameter overrides
+	StartChain(ctx context.Context, in *StartChainRequest, opts ...grpc.CallOption) (*StartChainResponse, error)
+	// StopChain cancels the running chain
+	StopChain(ctx context.Context, in *StopChainRequest, opts ...grpc.CallOption) (*StopChainResponse, error)
+	// ListChains returns all chains with the next run time and the latest run
+	ListChains(ctx context.Context, in *ListChainsRequest, opts ...grpc.CallOption) (*ListChainsResponse, error)
+	// StreamEvents sends scheduler events until the call is canceled or the server stops
+	StreamEvents(ctx context.Context, in *StreamEventsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Event], error)
+}
+
+type timetableClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewTimetableClient(cc grpc.ClientConnInterface) TimetableClient {
+	return &timetableClient{cc}
+}
+
+func (c *timetableClient) StartChain(ctx context.Context, in *StartChainRequest, opts ...grpc.CallOption) (*StartChainResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(StartChainResponse)
+	err := c.cc.Invoke(ctx, Timetable_StartChain_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *timetableClient) StopChain(ctx context.Context, in *StopChainRequest, opts ...grpc.CallOption) (*StopChainResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(StopChainResponse)
+	err := c.cc.Invoke(ctx, Timetable_StopChain_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *timetableClient) ListChains(ctx context.Context, in *ListChainsRequest, opts ...grpc.CallOption) (*ListChainsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListChainsResponse)
+	err := c.cc.Invoke(ctx, Timetable_ListChains_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *timetableClient) StreamEvents(ctx context.Context, in *StreamEventsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Event], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Timetable_ServiceDesc.Streams[0], Timetable_StreamEvents_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[StreamEventsRequest, Event]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Timetable_StreamEventsClient = grpc.ServerStreamingClient[Event]
+
+// TimetableServer is the server API for Timetable service.
+// All implementations must embed UnimplementedTimetableServer
+// for forward compatibility.
+//
+// Timetable controls the pg_timetable scheduler, operations follow the REST API
+type TimetableServer interface {
+	// StartChain starts the chain now, optionally with task parameter overrides
+	StartChain(context.Context, *StartChainRequest) (*StartChainResponse, error)
+	// StopChain cancels the running chain
+	StopChain(context.Context, *StopChainRequest) (*StopChainResponse, error)
+	// ListChains returns all chains with the next run time and the latest run
+	ListChains(context.Context, *ListChainsRequest) (*ListChainsResponse, error)
+	// StreamEvents sends scheduler events until the call is canceled or the server stops
+	StreamEvents(*StreamEventsRequest, grpc.ServerStreamingServer[Event]) error
+	mustEmbedUnimplementedTimetableServer()
+}
+
+// UnimplementedTimetableServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedTimetableServer struct{}
+
+func (UnimplementedTimetableServer) StartChain(context.Context, *StartChainRequest) (*StartChainResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method StartChain not implemented")
+}
+func (UnimplementedTimetableServer) StopChain(context.Context, *StopChainRequest) (*StopChainResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method StopChain not implemented")
+}
+func (UnimplementedTimetableServer) ListChains(context.Context, *ListChainsRequest) (*ListChainsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListChains not implemented")
+}
+func (UnimplementedTimetableServer) StreamEvents(*StreamEventsRequest, grpc.ServerStreamingServer[Event]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamEvents not implemented")
+}
+func (UnimplementedTimetableServer) mustEmbedUnimplementedTimetableServer() {}
+func (UnimplementedTimetableServer) testEmbeddedByValue()                   {}
+
+// UnsafeTimetableServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to TimetableServer will
+// result in compilation errors.
+type UnsafeTimetableServer interface {
+	mustEmbedUnimplementedTimetableServer()
+}
+
+func RegisterTimetableServer(s grpc.ServiceRegistrar, srv TimetableServer) {
+	// If the following call pancis, it indicates UnimplementedTimetableServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Timetable_ServiceDesc, srv)
+}
+
+func _Timetable_StartChain_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StartChainRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TimetableServer).StartChain(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Timetable_StartChain_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TimetableServer).StartChain(ctx, req.(*StartChainRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Timetable_StopChain_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StopChainRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TimetableServer).StopChain(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Timetable_StopChain_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TimetableServer).StopChain(ctx, req.(*StopChainRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Timetable_ListChains_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListChainsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TimetableServer).ListChains(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Timetable_ListChains_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TimetableServer).ListChains(ctx, req.(*ListChainsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Timetable_StreamEvents_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamEventsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(TimetableServer).StreamEvents(m, &grpc.GenericServerStream[StreamEventsRequest, Event]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Timetable_StreamEventsServer = grpc.ServerStreamingServer[Event]
+
+// Timetable_ServiceDesc is the grpc.ServiceDesc for Timetable service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Timetable_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "pgtimetable.v1.Timetable",
+	HandlerType: (*TimetableServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "StartChain",
+			Handler:    _Timetable_StartChain_Handler,
+		},
+		{
+			MethodName: "StopChain",
+			Handler:    _Timetable_StopChain_Handler,
+		},
+		{
+			MethodName: "ListChains",
+			Handler:    _Timetable_ListChains_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamEvents",
+			Handler:       _Timetable_StreamEvents_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "timetable.proto",
+}
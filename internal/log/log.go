@@ -47,6 +47,13 @@ func Init(opts config.LoggingOpts) LoggerHookerIface {
 	var err error
 	l := logrus.New()
 	l.Out = os.Stdout
+	r, err := NewRedactor(opts)
+	if err != nil {
+		l.WithError(err).Error("Redact patterns ignored")
+		opts.LogRedactPatterns = nil
+		r, _ = NewRedactor(opts)
+	}
+	l.AddHook(RedactHook{r}) // must be the first hook to mask entries for file and database hooks
 	if opts.LogFile > "" {
 		l.AddHook(lfshook.NewHook(getLogFileWriter(opts), getLogFileFormatter(opts)))
 	}
@@ -49,6 +49,13 @@ func (p *Provider) registerMetrics() error {
 		return err
 	}
 
+	p.taskWarnings, err = m.Int64Counter("pgtimetable.task.warnings",
+		metric.WithDescription("Number of tasks finished with a warning exit code"),
+		metric.WithUnit("{execution}"))
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -89,3 +96,49 @@ func (p *Provider) RecordTaskExecuted(ctx context.Context, clientName, taskKind
 		))
 	}
 }
+
+// RecordTaskWarning increments the task warnings counter.
+func (p *Provider) RecordTaskWarning(ctx context.Context, clientName, taskKind string) {
+	if p.taskWarnings != nil {
+		p.taskWarnings.Add(ctx, 1, metric.WithAttributes(
+			attribute.String("client.name", clientName),
+			attribute.String("task.kind", taskKind),
+		))
+	}
+}
+
+// RegisterLogHookMetrics registers counters of database log entries dropped, spooled to disk and replayed
+// from the spool. Values are observed with the stats function on every metrics collection.
+func (p *Provider) RegisterLogHookMetrics(clientName string, stats func() (dropped, spooled, replayed int64)) error {
+	if p.mp == nil {
+		return nil
+	}
+	m := p.Meter()
+	dropped, err := m.Int64ObservableCounter("pgtimetable.log.dropped",
+		metric.WithDescription("Number of database log entries dropped"),
+		metric.WithUnit("{entry}"))
+	if err != nil {
+		return err
+	}
+	spooled, err := m.Int64ObservableCounter("pgtimetable.log.spooled",
+		metric.WithDescription("Number of database log entries spooled to disk while the database was unavailable"),
+		metric.WithUnit("{entry}"))
+	if err != nil {
+		return err
+	}
+	replayed, err := m.Int64ObservableCounter("pgtimetable.log.replayed",
+		metric.WithDescription("Number of spooled database log entries replayed"),
+		metric.WithUnit("{entry}"))
+	if err != nil {
+		return err
+	}
+	attrs := metric.WithAttributes(attribute.String("client.name", clientName))
+	_, err = m.RegisterCallback(func(_ context.Context, o metric.Observer) error {
+		d, s, r := stats()
+		o.ObserveInt64(dropped, d, attrs)
+		o.ObserveInt64(spooled, s, attrs)
+		o.ObserveInt64(replayed, r, attrs)
+		return nil
+	}, dropped, spooled, replayed)
+	return err
+}
@@ -45,6 +45,7 @@ func TestMetricRecordMethodsDoNotPanic(t *testing.T) {
 		p.RecordTaskExecuted(ctx, "worker1", "SQL")
 		p.RecordTaskExecuted(ctx, "worker1", "PROGRAM")
 		p.RecordTaskExecuted(ctx, "worker1", "BUILTIN")
+		p.RecordTaskWarning(ctx, "worker1", "PROGRAM")
 	})
 }
 
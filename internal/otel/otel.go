@@ -40,6 +40,7 @@ type Provider struct {
 	chainFailed    metric.Int64Counter
 	chainDuration  metric.Float64Histogram
 	taskExecuted   metric.Int64Counter
+	taskWarnings   metric.Int64Counter
 }
 
 // New initialises an OTel Provider from the given options.
@@ -160,4 +160,29 @@ func TestRegisterMetricsRecordsMeasurements(t *testing.T) {
 
 	require.NoError(t, mp.Shutdown(ctx))
 	assert.NoError(t, (&Provider{tp: sdktrace.NewTracerProvider(), mp: sdkmetric.NewMeterProvider()}).Shutdown(ctx))
-}
\ No newline at end of file
+}
+func TestRegisterLogHookMetrics(t *testing.T) {
+	assert.NoError(t, NewNoop().RegisterLogHookMetrics("worker-a", nil), "Noop provider should ignore stats")
+
+	reader := sdkmetric.NewManualReader()
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	p := &Provider{mp: mp}
+	require.NoError(t, p.RegisterLogHookMetrics("worker-a", func() (int64, int64, int64) { return 1, 2, 3 }))
+
+	ctx := context.Background()
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(ctx, &rm))
+	require.Len(t, rm.ScopeMetrics, 1)
+	values := make(map[string]int64)
+	for _, m := range rm.ScopeMetrics[0].Metrics {
+		sum := m.Data.(metricdata.Sum[int64])
+		require.Len(t, sum.DataPoints, 1)
+		values[m.Name] = sum.DataPoints[0].Value
+	}
+	assert.Equal(t, map[string]int64{
+		"pgtimetable.log.dropped":  1,
+		"pgtimetable.log.spooled":  2,
+		"pgtimetable.log.replayed": 3,
+	}, values)
+	require.NoError(t, mp.Shutdown(ctx))
+}
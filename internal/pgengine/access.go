@@ -1,11 +1,16 @@
 package pgengine
 
 import (
+	"bytes"
+	"cmp"
 	"context"
+	"encoding/json"
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/cybertec-postgresql/pg_timetable/internal/log"
 	"github.com/jackc/pgx/v5"
 )
 
@@ -34,22 +39,66 @@ func (pge *PgEngine) LogTaskExecution(ctx context.Context, task *ChainTask, retC
 	case "none":
 		return
 	case "error":
-		if task.IgnoreError || retCode == 0 {
+		if task.IgnoreError || task.IsSuccessCode(retCode) && !task.Warning {
 			return
 		}
 	}
+	if logged, ok := task.LoggedParams[params]; ok {
+		params = string(pge.redactJSON(task, []byte(logged), true))
+	} else {
+		params = pge.Redact(task.Redact(params))
+	}
 	_, err := pge.ConfigDb.Exec(ctx, `INSERT INTO timetable.execution_log (
-chain_id, task_id, command, kind, last_run, finished, returncode, pid, output, client_name, txid, ignore_error, params) 
-VALUES ($1, $2, $3, $4, clock_timestamp() - $5 :: interval, clock_timestamp(), $6, $7, NULLIF($8, ''), $9, $10, $11, $12)`,
-		task.ChainID, task.TaskID, task.Command, task.Kind,
+chain_id, task_id, command, kind, last_run, finished, returncode, pid, output, client_name, txid, ignore_error, params, warning, result, run_id) 
+VALUES ($1, $2, $3, $4, clock_timestamp() - $5 :: interval, clock_timestamp(), $6, $7, NULLIF($8, ''), $9, $10, $11, $12, $13, $14, NULLIF($15, 0))`,
+		task.ChainID, task.TaskID, pge.Redact(task.Redact(task.Command)), task.Kind,
 		fmt.Sprintf("%f seconds", time.Since(task.StartedAt).Seconds()),
-		retCode, pge.Getsid(), strings.TrimSpace(output), pge.ClientName, task.Vxid,
-		task.IgnoreError, params)
+		retCode, pge.Getsid(), pge.Redact(task.Redact(strings.TrimSpace(output))), pge.ClientName, task.Vxid,
+		task.IgnoreError, params, task.Warning, pge.redactJSON(task, task.Result, false), task.RunID)
 	if err != nil {
 		pge.l.WithError(err).Error("Failed to log chain element execution status")
 	}
 }
 
+// redactJSON masks sensitive values of JSON data keeping it valid, i.e. values of sensitive keys and
+// values matching secrets or redact patterns. Secret references are kept if keepRefs is set
+func (pge *PgEngine) redactJSON(task *ChainTask, data []byte, keepRefs bool) []byte {
+	if len(data) == 0 {
+		return data
+	}
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+	var v any
+	if err := d.Decode(&v); err != nil {
+		return nil
+	}
+	data, _ = json.Marshal(pge.redactValue(task, v, keepRefs))
+	return data
+}
+
+func (pge *PgEngine) redactValue(task *ChainTask, v any, keepRefs bool) any {
+	switch v := v.(type) {
+	case string:
+		return pge.Redact(task.Redact(v))
+	case []any:
+		for i := range v {
+			v[i] = pge.redactValue(task, v[i], keepRefs)
+		}
+	case map[string]any:
+		if _, ok := v[SecretRefKey].(string); ok && len(v) == 1 && keepRefs {
+			return v
+		}
+		for k := range v {
+			if pge.redactor != nil && pge.redactor.IsSensitiveKey(k) {
+				v[k] = log.RedactMask
+			} else {
+				v[k] = pge.redactValue(task, v[k], keepRefs)
+			}
+		}
+	}
+	return v
+}
+
 // InsertChainRunStatus inits the execution run log, which will be use to effectively control scheduler concurrency
 func (pge *PgEngine) InsertChainRunStatus(ctx context.Context, chainID int, maxInstances int) bool {
 	const sqlInsertRunStatus = `INSERT INTO timetable.active_chain (chain_id, client_name) 
@@ -63,17 +112,107 @@ SELECT $1, $2 WHERE
 		pge.l.WithError(err).Error("Cannot save information about the chain run status")
 		return false
 	}
-	return res.RowsAffected() == 1
+	if res.RowsAffected() != 1 {
+		return false
+	}
+	pge.runningChains.add(chainID, 1)
+	return true
 }
 
 func (pge *PgEngine) RemoveChainRunStatus(ctx context.Context, chainID int) {
 	const sqlRemoveRunStatus = `DELETE FROM timetable.active_chain WHERE chain_id = $1 and client_name = $2`
+	pge.runningChains.add(chainID, -1)
 	_, err := pge.ConfigDb.Exec(ctx, sqlRemoveRunStatus, chainID, pge.ClientName)
 	if err != nil {
 		pge.l.WithError(err).Error("Cannot save information about the chain run status")
 	}
 }
 
+// runningChains counts instances of chains registered in timetable.active_chain by this client
+type runningChains struct {
+	sync.Mutex
+	m map[int]int
+}
+
+func (rc *runningChains) add(chainID int, delta int) {
+	rc.Lock()
+	defer rc.Unlock()
+	if rc.m == nil {
+		rc.m = make(map[int]int)
+	}
+	if rc.m[chainID] += delta; rc.m[chainID] <= 0 {
+		delete(rc.m, chainID)
+	}
+}
+
+// ids returns chain IDs repeated by the number of running instances
+func (rc *runningChains) ids() []int {
+	rc.Lock()
+	defer rc.Unlock()
+	ids := []int{}
+	for id, n := range rc.m {
+		for range n {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// ReconcileActiveChains replaces active chains of the client with the ones still running after reconnect,
+// e.g. unlogged timetable.active_chain is empty after failover to a standby
+func (pge *PgEngine) ReconcileActiveChains(ctx context.Context) error {
+	chainIDs := pge.runningChains.ids()
+	const sqlReconcileActiveChains = `WITH del_ch AS (DELETE FROM timetable.active_chain WHERE client_name = $1)
+INSERT INTO timetable.active_chain (chain_id, client_name) SELECT unnest($2::int8[]), $1`
+	_, err := pge.ConfigDb.Exec(ctx, sqlReconcileActiveChains, pge.ClientName, chainIDs)
+	return err
+}
+
+// Statuses of the chain run stored in timetable.chain_run
+const (
+	RunSucceeded = "SUCCEEDED"
+	RunFailed    = "FAILED"
+	RunCancelled = "CANCELLED"
+)
+
+// StartChainRun registers the chain run in timetable.chain_run and returns its ID, or 0 if it cannot be saved.
+// The run queue entry requested the run is deleted in the same statement
+func (pge *PgEngine) StartChainRun(ctx context.Context, chain Chain) (runID int64) {
+	const sqlStartChainRun = `WITH q AS (DELETE FROM timetable.run_queue WHERE queue_id = $6)
+INSERT INTO timetable.chain_run (chain_id, trigger, scheduled_at, client_name, overrides) 
+VALUES ($1, $2, $3, $4, $5) RETURNING run_id`
+	var scheduledAt any // NULL if unknown
+	if !chain.ScheduledAt.IsZero() {
+		scheduledAt = chain.ScheduledAt
+	}
+	var overrides any // NULL if none, sensitive values are masked
+	if o, ok := chain.Overrides.jsonb().(string); ok {
+		if o = pge.Redact(o); json.Valid([]byte(o)) {
+			overrides = o
+		}
+	}
+	err := pge.ConfigDb.QueryRow(ctx, sqlStartChainRun, chain.ChainID, cmp.Or(chain.Trigger, "manual"),
+		scheduledAt, pge.ClientName, overrides, chain.QueueID).Scan(&runID)
+	if err != nil {
+		pge.l.WithError(err).Error("Cannot save information about the chain run")
+		return 0
+	}
+	return runID
+}
+
+// FinishChainRun sets the final status of the chain run with the error message if any
+func (pge *PgEngine) FinishChainRun(ctx context.Context, runID int64, status string, errMsg string) {
+	if runID == 0 {
+		return
+	}
+	const sqlFinishChainRun = `UPDATE timetable.chain_run 
+SET finished_at = clock_timestamp(), status = $2, error = NULLIF($3, '') WHERE run_id = $1`
+	_, err := pge.ConfigDb.Exec(ctx, sqlFinishChainRun, runID, status, pge.Redact(errMsg))
+	if err != nil {
+		pge.l.WithError(err).Error("Cannot save information about the chain run status")
+	}
+}
+
 // Select live chains with proper client_name value
 const sqlSelectLiveChains = `SELECT chain_id, chain_name, self_destruct, exclusive_execution, 
 COALESCE(max_instances, 16) as max_instances, COALESCE(timeout, 0) as timeout, COALESCE(on_error, '') as on_error
@@ -90,10 +229,22 @@ func (pge *PgEngine) SelectRebootChains(ctx context.Context, dest *[]Chain) erro
 	return err
 }
 
-// SelectChains returns a list of chains should be executed at the current moment
+// SelectChains returns a list of chains should be executed at the current moment.
+// In the cluster mode chains without client name are returned only if claimed by this client
 func (pge *PgEngine) SelectChains(ctx context.Context, dest *[]Chain) error {
 	const sqlSelectChains = sqlSelectLiveChains + ` AND NOT COALESCE(starts_with(run_at, '@'), FALSE) AND timetable.is_cron_in_time(run_at, now())`
-	rows, err := pge.ConfigDb.Query(ctx, sqlSelectChains, pge.ClientName)
+	const sqlSelectClusterChains = `WITH due AS MATERIALIZED (` + sqlSelectChains + `)
+SELECT due.* FROM due JOIN timetable.chain c USING (chain_id)
+WHERE CASE WHEN c.client_name IS NULL THEN timetable.claim_chain($2, chain_id, date_trunc('minute', now()), $1) ELSE TRUE END`
+	var (
+		rows pgx.Rows
+		err  error
+	)
+	if pge.Cluster == "" {
+		rows, err = pge.ConfigDb.Query(ctx, sqlSelectChains, pge.ClientName)
+	} else {
+		rows, err = pge.ConfigDb.Query(ctx, sqlSelectClusterChains, pge.ClientName, pge.Cluster)
+	}
 	if err != nil {
 		return err
 	}
@@ -106,7 +257,7 @@ func (pge *PgEngine) SelectIntervalChains(ctx context.Context, dest *[]IntervalC
 	const sqlSelectIntervalChains = `SELECT chain_id, chain_name, self_destruct, exclusive_execution, 
 COALESCE(max_instances, 16), COALESCE(timeout, 0), COALESCE(on_error, '') as on_error,
 EXTRACT(EPOCH FROM (substr(run_at, 7) :: interval)) :: int4 as interval_seconds,
-starts_with(run_at, '@after') as repeat_after
+starts_with(run_at, '@after') as repeat_after, client_name IS NULL as shared
 FROM timetable.chain WHERE live AND (client_name = $1 or client_name IS NULL) AND substr(run_at, 1, 6) IN ('@every', '@after')`
 	rows, err := pge.ConfigDb.Query(ctx, sqlSelectIntervalChains, pge.ClientName)
 	if err != nil {
@@ -116,6 +267,49 @@ FROM timetable.chain WHERE live AND (client_name = $1 or client_name IS NULL) AN
 	return err
 }
 
+// ClaimIntervalChain returns true if the next run of the chain without client name is claimed by this client.
+// Runs of @every chains are keyed by intervals counted from the epoch, so all clients of the cluster share the same ones.
+// Runs of @after chains are keyed by the finish of the previous interval run plus the interval and are not due while
+// that run is still running. The client retrying its own claim, e.g. after the failed start, or the claim older than
+// the interval without the newer run may be claimed again
+func (pge *PgEngine) ClaimIntervalChain(ctx context.Context, ichain IntervalChain) bool {
+	if pge.Cluster == "" || !ichain.Shared {
+		return true
+	}
+	const (
+		sqlClaimChain = `SELECT timetable.claim_chain($1, $2, 
+to_timestamp(floor(extract(epoch FROM now()) / $3) * $3), $4)`
+		sqlClaimChainAfter = `WITH last_run AS (
+	SELECT status, finished_at FROM timetable.chain_run
+	WHERE chain_id = $2 AND trigger = 'interval' ORDER BY started_at DESC LIMIT 1
+), next_run AS (
+	SELECT COALESCE((SELECT finished_at FROM last_run), '-infinity') + make_interval(secs => $3) AS fire_at
+	WHERE NOT EXISTS (SELECT 1 FROM last_run WHERE status = 'RUNNING')
+), claimed AS (
+	INSERT INTO timetable.chain_claim AS cc (cluster_name, chain_id, fire_at, client_name)
+	SELECT $1, $2, fire_at, $4 FROM next_run WHERE fire_at <= now()
+	ON CONFLICT ON CONSTRAINT chain_claim_pkey DO UPDATE
+	SET fire_at = EXCLUDED.fire_at, client_name = EXCLUDED.client_name, claimed_at = clock_timestamp()
+	WHERE cc.fire_at < EXCLUDED.fire_at OR cc.client_name = EXCLUDED.client_name
+		OR cc.claimed_at < clock_timestamp() - make_interval(secs => $3)
+	RETURNING 1
+)
+SELECT count(*) > 0 FROM claimed`
+	)
+	sql := sqlClaimChain
+	if ichain.RepeatAfter {
+		sql = sqlClaimChainAfter
+	}
+	var claimed bool
+	err := pge.ConfigDb.QueryRow(ctx, sql, pge.Cluster, ichain.ChainID, max(ichain.Interval, 1),
+		pge.ClientName).Scan(&claimed)
+	if err != nil {
+		pge.l.WithError(err).Error("Cannot claim the chain run")
+		return false
+	}
+	return claimed
+}
+
 // SelectChain returns the chain with the specified ID
 func (pge *PgEngine) SelectChain(ctx context.Context, dest *Chain, chainID int) error {
 	// we accept not only live chains here because we want to run them in debug mode
@@ -141,7 +335,12 @@ func (pge *PgEngine) GetChainElements(ctx context.Context, chainTasks *[]ChainTa
 	ignore_error,
 	autonomous,
 	COALESCE(database_connection, '') as database_connection,
-	timeout
+	COALESCE(connection_name, '') as connection_name,
+	timeout,
+	COALESCE(success_codes, '{}') as success_codes,
+	COALESCE(warning_codes, '{}') as warning_codes,
+	COALESCE(capture_rows, 0) as capture_rows,
+	assertion
 FROM timetable.task WHERE chain_id = $1 AND live ORDER BY task_order ASC`
 	rows, err := pge.ConfigDb.Query(ctx, sqlSelectChainTasks, chainID)
 	if err != nil {
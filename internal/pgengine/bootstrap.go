@@ -3,10 +3,13 @@ package pgengine
 import (
 	"context"
 	"errors"
+	"maps"
 	"math/rand"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/cybertec-postgresql/pg_timetable/internal/config"
@@ -50,6 +53,7 @@ type PgxPoolIface interface {
 	PgxIface
 	Acquire(ctx context.Context) (*pgxpool.Conn, error)
 	Close()
+	Reset()
 }
 
 // PgEngine is responsible for every database-related action
@@ -61,6 +65,14 @@ type PgEngine struct {
 	chainSignalChan chan ChainSignal
 	sid             int32
 	logTypeOID      uint32
+	remotePools     remotePools
+	runningChains   runningChains
+	secretProviders []SecretProvider
+	redactor        *log.Redactor
+	logHook         *LogHook
+	lease           PgxConnIface // session holding the leader lock in the standby mode
+	leaseMutex      sync.Mutex   // the lease session is checked by every worker before the chain start
+	leadershipLost  atomic.Bool
 }
 
 // Getsid returns the pseudo-random session ID to use for the session identification.
@@ -89,6 +101,14 @@ func New(ctx context.Context, cmdOpts config.CmdOptions, logger log.LoggerHooker
 		chainSignalChan: make(chan ChainSignal, 64),
 	}
 	pge.l.WithField("sid", pge.Getsid()).Info("Starting new session... ")
+	if pge.redactor, err = log.NewRedactor(cmdOpts.Logging); err != nil {
+		return nil, err
+	}
+	if cmdOpts.Start.Standby {
+		if err = pge.ObtainLeadership(ctx); err != nil {
+			return nil, err
+		}
+	}
 	config := pge.getPgxConnConfig()
 	if err = retry.Do(ctx, backoff, func(ctx context.Context) error {
 		if pge.ConfigDb, err = pgxpool.NewWithConfig(ctx, config); err == nil {
@@ -104,6 +124,7 @@ func New(ctx context.Context, cmdOpts config.CmdOptions, logger log.LoggerHooker
 		return nil, err
 	}
 	pge.l.Info("Database connection established")
+	pge.secretProviders = pge.defaultSecretProviders()
 	if err := pge.ExecuteSchemaScripts(ctx); err != nil {
 		return nil, err
 	}
@@ -122,12 +143,20 @@ func New(ctx context.Context, cmdOpts config.CmdOptions, logger log.LoggerHooker
 // NewDB creates pgengine instance for already opened database connection, allowing to bypass a parameters based credentials.
 // We assume here all checks for proper schema validation are done beforehannd
 func NewDB(DB PgxPoolIface, args ...string) *PgEngine {
-	return &PgEngine{
+	pge := &PgEngine{
 		l:               log.Init(config.LoggingOpts{LogLevel: "error"}),
 		ConfigDb:        DB,
 		CmdOptions:      *config.NewCmdOptions(args...),
 		chainSignalChan: make(chan ChainSignal, 64),
 	}
+	pge.secretProviders = pge.defaultSecretProviders()
+	pge.redactor, _ = log.NewRedactor(pge.Logging)
+	return pge
+}
+
+// Redact masks sensitive values in s according to logging options, e.g. before storing in the database
+func (pge *PgEngine) Redact(s string) string {
+	return pge.redactor.String(s)
 }
 
 func quoteIdent(s string) string {
@@ -147,6 +176,9 @@ func (pge *PgEngine) getPgxConnConfig() *pgxpool.Config {
 	// and another connection for LogHook.send()
 	connConfig.MaxConns = int32(pge.Resource.CronWorkers) + int32(pge.Resource.IntervalWorkers) + 3
 	connConfig.ConnConfig.RuntimeParams["application_name"] = "pg_timetable"
+	if pge.Start.Standby {
+		maps.Copy(connConfig.ConnConfig.RuntimeParams, standbyKeepalives)
+	}
 	connConfig.ConnConfig.OnNotice = func(_ *pgconn.PgConn, n *pgconn.Notice) {
 		pge.l.WithField("severity", n.Severity).WithField("notice", n.Message).Info("Notice received")
 	}
@@ -192,7 +224,31 @@ func (pge *PgEngine) getPgxConnConfig() *pgxpool.Config {
 
 // AddLogHook adds a new pgx log hook to logrus logger
 func (pge *PgEngine) AddLogHook(ctx context.Context) {
-	pge.l.AddHook(NewHook(ctx, pge, pge.Logging.LogDBLevel))
+	pge.logHook = NewHook(ctx, pge, pge.Logging.LogDBLevel)
+	pge.l.AddHook(pge.logHook)
+}
+
+// LogHookStats returns the counters of database log entries not delivered right away
+func (pge *PgEngine) LogHookStats() LogHookStats {
+	if pge.logHook == nil {
+		return LogHookStats{}
+	}
+	return pge.logHook.Stats()
+}
+
+// Reconnect waits until the configuration database is available again after the connection loss.
+// Broken connections are closed, new ones obtain the client lock and LISTEN in AfterConnect
+func (pge *PgEngine) Reconnect(ctx context.Context) error {
+	pge.ConfigDb.Reset()
+	return retry.Do(ctx, backoff, func(ctx context.Context) error {
+		if err := pge.ConfigDb.Ping(ctx); err != nil {
+			pge.l.WithError(err).Error("Reconnection failed")
+			pge.l.Info("Sleeping before reconnecting...")
+			return retry.RetryableError(err)
+		}
+		pge.l.Info("Database connection re-established")
+		return nil
+	})
 }
 
 // QueryRowIface specifies interface to use QueryRow method
@@ -301,12 +357,21 @@ func (pge *PgEngine) ExecuteSchemaScripts(ctx context.Context) error {
 // Finalize closes session
 func (pge *PgEngine) Finalize() {
 	pge.l.Info("Closing session")
-	sql := `WITH del_ch AS (DELETE FROM timetable.active_chain WHERE client_name = $1)
-DELETE FROM timetable.active_session WHERE client_name = $1`
-	_, err := pge.ConfigDb.Exec(context.Background(), sql, pge.ClientName)
-	if err != nil {
-		pge.l.WithError(err).Error("Cannot finalize database session")
+	if pge.leadershipLost.Load() {
+		// the client name may be taken over by the standby client already
+		pge.l.Info("Leadership lost, leaving session information to the active client")
+	} else {
+		sql := `WITH del_ch AS (DELETE FROM timetable.active_chain WHERE client_name = $1)
+DELETE FROM timetable.active_session WHERE client_name = $1 AND client_pid = $2`
+		_, err := pge.ConfigDb.Exec(context.Background(), sql, pge.ClientName, pge.Getsid())
+		if err != nil {
+			pge.l.WithError(err).Error("Cannot finalize database session")
+		}
 	}
+	pge.CloseConnPools()
 	pge.ConfigDb.Close()
 	pge.ConfigDb = nil
+	if pge.lease != nil {
+		_ = pge.lease.Close(context.Background()) // releases the leader lock for standby clients
+	}
 }
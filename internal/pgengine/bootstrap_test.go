@@ -81,7 +81,7 @@ func TestFinalizeConnection(t *testing.T) {
 	initmockdb(t)
 	mockpge := pgengine.NewDB(mockPool, "pgengine_unit_test")
 	mockPool.ExpectExec(`DELETE FROM timetable\.active_session`).
-		WithArgs(mockpge.ClientName).
+		WithArgs(mockpge.ClientName, mockpge.Getsid()).
 		WillReturnResult(pgxmock.NewResult("EXECUTE", 0))
 	mockPool.ExpectClose()
 	mockpge.Finalize()
@@ -255,7 +255,7 @@ func TestExecuteFileScript(t *testing.T) {
 			WithArgs(anyArgs(9)...).
 			WillReturnRows(pgxmock.NewRows([]string{"chain_id"}).AddRow(1))
 		mockPool.ExpectQuery("INSERT INTO timetable\\.task").
-			WithArgs(anyArgs(11)...).
+			WithArgs(anyArgs(16)...).
 			WillReturnRows(pgxmock.NewRows([]string{"task_id"}).AddRow(1))
 
 		err = mockpge.ExecuteFileScript(context.Background(), cmdOpts, yamlFile)
@@ -333,7 +333,7 @@ func TestExecuteFileScript(t *testing.T) {
 			WithArgs(anyArgs(9)...).
 			WillReturnRows(pgxmock.NewRows([]string{"chain_id"}).AddRow(1))
 		mockPool.ExpectQuery("INSERT INTO timetable\\.task").
-			WithArgs(anyArgs(11)...).
+			WithArgs(anyArgs(16)...).
 			WillReturnRows(pgxmock.NewRows([]string{"task_id"}).AddRow(1))
 
 		err = mockpge.ExecuteFileScript(context.Background(), cmdOpts, yamlFile)
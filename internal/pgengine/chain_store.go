@@ -0,0 +1,431 @@
+package pgengine
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/cybertec-postgresql/pg_timetable/internal/log"
+	pgx "github.com/jackc/pgx/v5"
+)
+
+// ErrTaskNotInChain is returned if the updated chain lists the task of another chain
+var ErrTaskNotInChain = errors.New("task does not belong to the chain")
+
+// chainWriter is implemented by both the pool and the transaction
+type chainWriter interface {
+	executor
+	QueryRowIface
+}
+
+const sqlSelectYamlChains = `SELECT chain_id, chain_name, COALESCE(run_at, '') as run_at,
+COALESCE(max_instances, 0) as max_instances, COALESCE(timeout, 0) as timeout, live, self_destruct,
+exclusive_execution, COALESCE(client_name, '') as client_name, COALESCE(on_error, '') as on_error
+FROM timetable.chain`
+
+const sqlSelectYamlTasks = `SELECT
+	task_id,
+	COALESCE(task_name, '') as task_name,
+	command,
+	kind,
+	COALESCE(run_as, '') as run_as,
+	ignore_error,
+	autonomous,
+	COALESCE(database_connection, '') as database_connection,
+	COALESCE(connection_name, '') as connection_name,
+	timeout,
+	COALESCE(success_codes, '{}') as success_codes,
+	COALESCE(warning_codes, '{}') as warning_codes,
+	COALESCE(capture_rows, 0) as capture_rows,
+	assertion,
+	live,
+	` + sqlTaskParameters + ` as parameters
+FROM timetable.task t`
+
+// sqlTaskParameters aggregates parameter values of the task t in the execution order
+const sqlTaskParameters = `COALESCE((SELECT jsonb_agg(p.value ORDER BY p.order_id) FROM timetable.parameter p
+		WHERE p.task_id = t.task_id), '[]')`
+
+// SelectYamlChains returns definitions of all chains without tasks ordered by ID
+func (pge *PgEngine) SelectYamlChains(ctx context.Context) ([]YamlChain, error) {
+	rows, err := pge.ConfigDb.Query(ctx, sqlSelectYamlChains+" ORDER BY chain_id")
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowToStructByNameLax[YamlChain])
+}
+
+// SelectYamlChain returns the definition of the chain with tasks and parameters. Passwords in connection
+// strings and sensitive parameter values are masked. Returns pgx.ErrNoRows if there is no such chain
+func (pge *PgEngine) SelectYamlChain(ctx context.Context, chainID int) (c YamlChain, err error) {
+	rows, err := pge.ConfigDb.Query(ctx, sqlSelectYamlChains+" WHERE chain_id = $1", chainID)
+	if err != nil {
+		return
+	}
+	if c, err = pgx.CollectExactlyOneRow(rows, pgx.RowToStructByNameLax[YamlChain]); err != nil {
+		return
+	}
+	rows, err = pge.ConfigDb.Query(ctx, sqlSelectYamlTasks+" WHERE chain_id = $1 ORDER BY task_order", chainID)
+	if err != nil {
+		return
+	}
+	c.Tasks, err = pgx.CollectRows(rows, pgx.RowToStructByNameLax[YamlTask])
+	for i := range c.Tasks {
+		c.Tasks[i].ConnectString = pge.Redact(c.Tasks[i].ConnectString)
+		c.Tasks[i].Parameters = pge.maskParameters(c.Tasks[i].Parameters)
+	}
+	return
+}
+
+// AddYamlChain creates the chain with tasks and parameters in a single transaction
+func (pge *PgEngine) AddYamlChain(ctx context.Context, yamlChain *YamlChain) (chainID int64, err error) {
+	err = pge.inTransaction(ctx, func(tx pgx.Tx) error {
+		chainID, err = createChainFromYaml(ctx, tx, yamlChain)
+		return err
+	})
+	return
+}
+
+// UpdateYamlChain replaces the definition of the chain including tasks and parameters. Tasks with the ID
+// are updated in place, tasks without it are added and tasks of the chain not listed are removed.
+// Returns pgx.ErrNoRows if there is no such chain
+func (pge *PgEngine) UpdateYamlChain(ctx context.Context, chainID int, yamlChain *YamlChain) error {
+	return pge.inTransaction(ctx, func(tx pgx.Tx) error {
+		res, err := tx.Exec(ctx, `UPDATE timetable.chain SET
+			chain_name = $2, run_at = $3, max_instances = $4, timeout = $5, live = $6,
+			self_destruct = $7, exclusive_execution = $8, client_name = $9, on_error = $10
+		WHERE chain_id = $1`,
+			chainID,
+			yamlChain.ChainName,
+			yamlChain.Schedule,
+			yamlChain.MaxInstances,
+			yamlChain.Timeout,
+			yamlChain.Live,
+			yamlChain.SelfDestruct,
+			yamlChain.ExclusiveExecution,
+			nullString(yamlChain.ClientName),
+			nullString(yamlChain.OnError))
+		if err != nil {
+			return fmt.Errorf("failed to update chain: %w", err)
+		}
+		if res.RowsAffected() == 0 {
+			return pgx.ErrNoRows
+		}
+		rows, err := tx.Query(ctx, `SELECT task_id, COALESCE(database_connection, ''), `+sqlTaskParameters+`
+		FROM timetable.task t WHERE chain_id = $1 FOR UPDATE`, chainID)
+		if err != nil {
+			return fmt.Errorf("failed to select tasks: %w", err)
+		}
+		stored := make(map[int]storedTask)
+		var task storedTask
+		if _, err = pgx.ForEachRow(rows, []any{&task.taskID, &task.connectString, &task.params}, func() error {
+			stored[task.taskID] = task
+			return nil
+		}); err != nil {
+			return fmt.Errorf("failed to select tasks: %w", err)
+		}
+		for i := range yamlChain.Tasks {
+			task := &yamlChain.Tasks[i]
+			taskOrder := float64((i + 1) * 10)
+			if task.TaskID == 0 {
+				if _, err = insertYamlTask(ctx, tx, int64(chainID), taskOrder, task); err != nil {
+					return fmt.Errorf("failed to insert task %d: %w", i+1, err)
+				}
+				continue
+			}
+			s, ok := stored[task.TaskID]
+			if !ok { // also if the task is listed twice
+				return fmt.Errorf("task %d: %w", task.TaskID, ErrTaskNotInChain)
+			}
+			delete(stored, task.TaskID)
+			task.ConnectString = pge.unmaskConnectString(task.ConnectString, s.connectString)
+			task.Parameters = pge.unmaskParameters(task.Parameters, s.params)
+			if err = updateYamlTask(ctx, tx, task.TaskID, &taskOrder, task); err != nil {
+				return err
+			}
+		}
+		if len(stored) > 0 {
+			if _, err = tx.Exec(ctx, "DELETE FROM timetable.task WHERE task_id = ANY($1)",
+				slices.Sorted(maps.Keys(stored))); err != nil {
+				return fmt.Errorf("failed to delete tasks: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+// DeleteYamlChain removes the chain with tasks and parameters. Returns pgx.ErrNoRows if there is no such chain
+func (pge *PgEngine) DeleteYamlChain(ctx context.Context, chainID int) error {
+	res, err := pge.ConfigDb.Exec(ctx, "DELETE FROM timetable.chain WHERE chain_id = $1", chainID)
+	if err == nil && res.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return err
+}
+
+// SetChainLive pauses or resumes the chain. Returns pgx.ErrNoRows if there is no such chain
+func (pge *PgEngine) SetChainLive(ctx context.Context, chainID int, live bool) error {
+	res, err := pge.ConfigDb.Exec(ctx, "UPDATE timetable.chain SET live = $2 WHERE chain_id = $1", chainID, live)
+	if err == nil && res.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return err
+}
+
+// SelectTaskChainID returns the ID of the chain the task belongs to. Returns pgx.ErrNoRows if there is no such task
+func (pge *PgEngine) SelectTaskChainID(ctx context.Context, taskID int) (chainID int, err error) {
+	err = pge.ConfigDb.QueryRow(ctx, "SELECT chain_id FROM timetable.task WHERE task_id = $1", taskID).Scan(&chainID)
+	return
+}
+
+// SelectYamlTask returns the task with parameters. Passwords in the connection string and sensitive
+// parameter values are masked. Returns pgx.ErrNoRows if there is no such task
+func (pge *PgEngine) SelectYamlTask(ctx context.Context, taskID int) (YamlTask, error) {
+	rows, err := pge.ConfigDb.Query(ctx, sqlSelectYamlTasks+" WHERE task_id = $1", taskID)
+	if err != nil {
+		return YamlTask{}, err
+	}
+	task, err := pgx.CollectExactlyOneRow(rows, pgx.RowToStructByNameLax[YamlTask])
+	task.ConnectString = pge.Redact(task.ConnectString)
+	task.Parameters = pge.maskParameters(task.Parameters)
+	return task, err
+}
+
+// AddYamlTask appends the task with parameters to the end of the chain.
+// Returns pgx.ErrNoRows if there is no such chain
+func (pge *PgEngine) AddYamlTask(ctx context.Context, chainID int, task *YamlTask) (taskID int64, err error) {
+	err = pge.inTransaction(ctx, func(tx pgx.Tx) error {
+		var taskOrder float64
+		if err := tx.QueryRow(ctx, `SELECT COALESCE(max(t.task_order), 0) + 10
+		FROM timetable.chain c LEFT JOIN timetable.task t USING (chain_id)
+		WHERE c.chain_id = $1 GROUP BY c.chain_id`, chainID).Scan(&taskOrder); err != nil {
+			return err
+		}
+		taskID, err = insertYamlTask(ctx, tx, int64(chainID), taskOrder, task)
+		return err
+	})
+	return
+}
+
+// UpdateYamlTask replaces the task definition and parameters keeping its position in the chain.
+// Returns pgx.ErrNoRows if there is no such task
+func (pge *PgEngine) UpdateYamlTask(ctx context.Context, taskID int, task *YamlTask) error {
+	return pge.inTransaction(ctx, func(tx pgx.Tx) error {
+		var s storedTask
+		if err := tx.QueryRow(ctx, `SELECT COALESCE(database_connection, ''), `+sqlTaskParameters+`
+		FROM timetable.task t WHERE task_id = $1 FOR UPDATE`, taskID).Scan(&s.connectString, &s.params); err != nil {
+			return err
+		}
+		task.ConnectString = pge.unmaskConnectString(task.ConnectString, s.connectString)
+		task.Parameters = pge.unmaskParameters(task.Parameters, s.params)
+		return updateYamlTask(ctx, tx, taskID, nil, task)
+	})
+}
+
+// storedTask holds the stored values of the task replaced by masked ones in the chain store responses
+type storedTask struct {
+	taskID        int
+	connectString string
+	params        []any
+}
+
+// unmaskConnectString returns the stored connection string if the submitted one is its masked form,
+// e.g. the task returned by the REST API is sent back with changes of other fields
+func (pge *PgEngine) unmaskConnectString(submitted, stored string) string {
+	if submitted != stored && submitted == pge.Redact(stored) {
+		return stored
+	}
+	return submitted
+}
+
+// maskParameters masks sensitive values of parameters the same way as in logs, e.g. the SendMail password.
+// Secret references are kept since they are not sensitive
+func (pge *PgEngine) maskParameters(params []any) []any {
+	for i := range params {
+		params[i] = pge.redactValue(&ChainTask{}, params[i], true)
+	}
+	return params
+}
+
+// unmaskParameters returns submitted parameters with masked values replaced by stored ones at the same position,
+// e.g. the task returned by the REST API is sent back with changes of other fields
+func (pge *PgEngine) unmaskParameters(submitted, stored []any) []any {
+	for i := range min(len(submitted), len(stored)) {
+		submitted[i] = pge.unmaskValue(submitted[i], stored[i])
+	}
+	return submitted
+}
+
+func (pge *PgEngine) unmaskValue(submitted, stored any) any {
+	switch v := submitted.(type) {
+	case string:
+		if s, ok := stored.(string); ok && v != s && v == pge.Redact(s) {
+			return s
+		}
+	case []any:
+		if s, ok := stored.([]any); ok {
+			return pge.unmaskParameters(v, s)
+		}
+	case map[string]any:
+		s, ok := stored.(map[string]any)
+		if !ok {
+			break
+		}
+		for k, sv := range s {
+			switch {
+			case v[k] == nil:
+				// the key is removed or not set
+			case v[k] == log.RedactMask && pge.redactor != nil && pge.redactor.IsSensitiveKey(k):
+				v[k] = sv
+			default:
+				v[k] = pge.unmaskValue(v[k], sv)
+			}
+		}
+	}
+	return submitted
+}
+
+// updateYamlTask replaces the task definition and parameters, the position in the chain is kept if taskOrder is nil
+func updateYamlTask(ctx context.Context, tx pgx.Tx, taskID int, taskOrder *float64, task *YamlTask) error {
+	res, err := tx.Exec(ctx, `UPDATE timetable.task SET
+		task_name = $2, kind = $3::timetable.command_kind, command = $4, run_as = $5,
+		database_connection = $6, ignore_error = $7, autonomous = $8, timeout = $9, live = $10,
+		success_codes = $11, warning_codes = $12, capture_rows = NULLIF($13, 0), assertion = $14,
+		connection_name = $15, task_order = COALESCE($16, task_order)
+	WHERE task_id = $1`,
+		taskID,
+		nullString(task.TaskName),
+		task.Kind,
+		task.Command,
+		nullString(task.RunAs),
+		nullString(task.ConnectString),
+		task.IgnoreError,
+		task.Autonomous,
+		task.Timeout,
+		task.Live == nil || *task.Live,
+		task.SuccessCodes,
+		task.WarningCodes,
+		task.CaptureRows,
+		task.Assertion,
+		nullString(task.ConnectionName),
+		taskOrder)
+	if err != nil {
+		return fmt.Errorf("failed to update task: %w", err)
+	}
+	if res.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return replaceTaskParameters(ctx, tx, int64(taskID), task.Parameters)
+}
+
+// DeleteYamlTask removes the task from the chain. Returns pgx.ErrNoRows if there is no such task
+func (pge *PgEngine) DeleteYamlTask(ctx context.Context, taskID int) error {
+	var deleted bool
+	err := pge.ConfigDb.QueryRow(ctx, "SELECT timetable.delete_task($1)", taskID).Scan(&deleted)
+	if err == nil && !deleted {
+		return pgx.ErrNoRows
+	}
+	return err
+}
+
+// SelectTaskParameters returns parameter values of the task in the execution order, sensitive values are masked.
+// Returns pgx.ErrNoRows if there is no such task
+func (pge *PgEngine) SelectTaskParameters(ctx context.Context, taskID int) (params []any, err error) {
+	err = pge.ConfigDb.QueryRow(ctx, `SELECT COALESCE(jsonb_agg(p.value ORDER BY p.order_id)
+		FILTER (WHERE p.task_id IS NOT NULL), '[]')
+	FROM timetable.task t LEFT JOIN timetable.parameter p USING (task_id)
+	WHERE t.task_id = $1 GROUP BY t.task_id`, taskID).Scan(&params)
+	return pge.maskParameters(params), err
+}
+
+// SetTaskParameters replaces parameter values of the task, masked values keep the stored ones.
+// Returns pgx.ErrNoRows if there is no such task
+func (pge *PgEngine) SetTaskParameters(ctx context.Context, taskID int, params []any) error {
+	return pge.inTransaction(ctx, func(tx pgx.Tx) error {
+		var (
+			id     int64
+			stored []any
+		)
+		if err := tx.QueryRow(ctx, "SELECT task_id, "+sqlTaskParameters+" FROM timetable.task t WHERE task_id = $1 FOR UPDATE",
+			taskID).Scan(&id, &stored); err != nil {
+			return err
+		}
+		return replaceTaskParameters(ctx, tx, id, pge.unmaskParameters(params, stored))
+	})
+}
+
+// inTransaction executes fn in a new transaction, committed if fn succeeds and rolled back otherwise
+func (pge *PgEngine) inTransaction(ctx context.Context, fn func(pgx.Tx) error) error {
+	tx, err := pge.ConfigDb.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	if err = fn(tx); err != nil {
+		_ = tx.Rollback(ctx)
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// insertYamlTask adds the task with parameters to the chain at the specified position
+func insertYamlTask(ctx context.Context, db chainWriter, chainID int64, taskOrder float64, task *YamlTask) (taskID int64, err error) {
+	err = db.QueryRow(ctx, `
+			INSERT INTO timetable.task (
+				chain_id, task_order, task_name, kind, command,
+				run_as, database_connection, ignore_error, autonomous, timeout, live,
+				success_codes, warning_codes, capture_rows, assertion, connection_name
+			) VALUES ($1, $2, $3, $4::timetable.command_kind, $5, $6, $7, $8, $9, $10, $11, $12, $13, NULLIF($14, 0), $15, $16)
+			RETURNING task_id`,
+		chainID,
+		taskOrder,
+		nullString(task.TaskName),
+		task.Kind,
+		task.Command,
+		nullString(task.RunAs),
+		nullString(task.ConnectString),
+		task.IgnoreError,
+		task.Autonomous,
+		task.Timeout,
+		task.Live == nil || *task.Live,
+		task.SuccessCodes,
+		task.WarningCodes,
+		task.CaptureRows,
+		task.Assertion,
+		nullString(task.ConnectionName)).Scan(&taskID)
+	if err != nil {
+		return 0, err
+	}
+	return taskID, insertTaskParameters(ctx, db, taskID, task.Parameters)
+}
+
+// insertTaskParameters stores parameter values of the task as JSONB in the execution order
+func insertTaskParameters(ctx context.Context, db chainWriter, taskID int64, params []any) error {
+	for paramIndex, param := range params {
+		orderID := paramIndex + 1
+
+		// Convert parameter to JSON for JSONB storage
+		jsonValue, err := json.Marshal(param)
+		if err != nil {
+			return fmt.Errorf("failed to marshal parameter %d to JSON: %w", orderID, err)
+		}
+
+		_, err = db.Exec(ctx,
+			"INSERT INTO timetable.parameter (task_id, order_id, value) VALUES ($1, $2, $3::jsonb)",
+			taskID, orderID, string(jsonValue))
+		if err != nil {
+			return fmt.Errorf("failed to insert parameter %d: %w", orderID, err)
+		}
+	}
+	return nil
+}
+
+// replaceTaskParameters removes old parameter values of the task and stores the new ones
+func replaceTaskParameters(ctx context.Context, db chainWriter, taskID int64, params []any) error {
+	if _, err := db.Exec(ctx, "DELETE FROM timetable.parameter WHERE task_id = $1", taskID); err != nil {
+		return fmt.Errorf("failed to delete parameters: %w", err)
+	}
+	return insertTaskParameters(ctx, db, taskID, params)
+}
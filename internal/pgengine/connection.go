@@ -0,0 +1,179 @@
+package pgengine
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cybertec-postgresql/pg_timetable/internal/log"
+	pgx "github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Connection describes a named connection to a remote database registered in timetable.connection
+type Connection struct {
+	Name          string `db:"connection_name"`
+	ConnectString string `db:"connect_string"`
+	Credentials   string `db:"credentials"`
+	MaxConns      int    `db:"max_conns"`
+	IdleTimeout   int    `db:"idle_timeout"` // in seconds
+}
+
+// connPool is the pool of the named connection together with the definition it was created for
+type connPool struct {
+	Connection
+	password string
+	pool     *pgxpool.Pool
+}
+
+// remotePools holds pools of named connections reused across tasks
+type remotePools struct {
+	sync.Mutex
+	pools map[string]*connPool
+}
+
+// pooledConn returns the connection to the pool on Close() instead of closing the session
+type pooledConn struct {
+	*pgxpool.Conn
+}
+
+func (c pooledConn) Close(context.Context) error {
+	c.Release()
+	return nil
+}
+
+// SelectConnection returns the registered connection with the given name
+func (pge *PgEngine) SelectConnection(ctx context.Context, conn *Connection, name string) error {
+	const sqlSelectConnection = `SELECT connection_name, connect_string, COALESCE(credentials, '') AS credentials,
+max_conns, idle_timeout FROM timetable.connection WHERE connection_name = $1`
+	rows, err := pge.ConfigDb.Query(ctx, sqlSelectConnection, name)
+	if err != nil {
+		return err
+	}
+	*conn, err = pgx.CollectExactlyOneRow(rows, pgx.RowToStructByName[Connection])
+	if errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("connection %q is not registered", name)
+	}
+	return err
+}
+
+// resolveCredentials returns the password of the named connection from the environment, file, configuration or secrets.
+// The row is writable by database users, so env and file sources are restricted to the secrets namespace of the
+// scheduler, i.e. variables with --secrets-env-prefix and files in --secrets-dir, other references are rejected
+func (pge *PgEngine) resolveCredentials(ctx context.Context, conn Connection) (string, error) {
+	source, key, _ := strings.Cut(conn.Credentials, ":")
+	switch source {
+	case "":
+		return "", nil
+	case "env":
+		if pge.Secrets.EnvPrefix == "" {
+			return "", errors.New("env credentials require the secrets prefix, see --secrets-env-prefix")
+		}
+		return lookupCredentials(ctx, EnvSecrets{Prefix: pge.Secrets.EnvPrefix}, key, "environment variable")
+	case "file":
+		if pge.Secrets.Dir == "" {
+			return "", errors.New("file credentials require the secrets directory, see --secrets-dir")
+		}
+		return lookupCredentials(ctx, FileSecrets{Dir: pge.Secrets.Dir}, key, "file in the secrets directory")
+	case "config":
+		// viper lowercases map keys, so names are case-insensitive
+		if pwd, ok := pge.Remote.Passwords[strings.ToLower(cmp.Or(key, conn.Name))]; ok {
+			return pwd, nil
+		}
+		return "", fmt.Errorf("password for %q not found in configuration", cmp.Or(key, conn.Name))
+	case "secret":
+		return pge.GetSecret(ctx, key)
+	}
+	return "", fmt.Errorf("unknown credentials source %q", source)
+}
+
+func lookupCredentials(ctx context.Context, p SecretProvider, name, what string) (string, error) {
+	if name == "" {
+		return "", fmt.Errorf("%s name is required", what)
+	}
+	pwd, found, err := p.GetSecret(ctx, name)
+	if err == nil && !found {
+		err = fmt.Errorf("%s for %q not found", what, name)
+	}
+	return pwd, err
+}
+
+// getConnPool returns the pool for the connection creating it if necessary.
+// The pool is recreated if the connection definition or the password has changed
+func (pge *PgEngine) getConnPool(ctx context.Context, conn Connection) (*pgxpool.Pool, error) {
+	password, err := pge.resolveCredentials(ctx, conn)
+	if err != nil {
+		return nil, fmt.Errorf("cannot resolve credentials for connection %q: %w", conn.Name, err)
+	}
+	pge.remotePools.Lock()
+	defer pge.remotePools.Unlock()
+	if pge.remotePools.pools == nil {
+		pge.remotePools.pools = make(map[string]*connPool)
+	}
+	if cp, ok := pge.remotePools.pools[conn.Name]; ok {
+		if cp.Connection == conn && cp.password == password {
+			return cp.pool, nil
+		}
+		log.GetLogger(ctx).WithField("connection", conn.Name).Info("Connection definition changed, recreating pool")
+		go cp.pool.Close() // wait for acquired connections in background
+	}
+	config, err := pgxpool.ParseConfig(conn.ConnectString)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse connection string of %q: %w", conn.Name, err)
+	}
+	if password != "" {
+		config.ConnConfig.Password = password
+	}
+	config.MaxConns = int32(conn.MaxConns)
+	config.MaxConnIdleTime = time.Duration(conn.IdleTimeout) * time.Second
+	if conn.IdleTimeout == 0 {
+		config.MaxConnIdleTime = time.Duration(math.MaxInt64) // never close idle connections
+	}
+	if _, ok := config.ConnConfig.RuntimeParams["application_name"]; !ok { // keep the name set by the connection string
+		config.ConnConfig.RuntimeParams["application_name"] = "pg_timetable"
+	}
+	// reset session before returning connection to the pool, e.g. role set by the task
+	config.AfterRelease = func(c *pgx.Conn) bool {
+		_, err := c.Exec(context.Background(), "DISCARD ALL")
+		return err == nil
+	}
+	pool, err := pgxpool.NewWithConfig(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+	pge.remotePools.pools[conn.Name] = &connPool{Connection: conn, password: password, pool: pool}
+	return pool, nil
+}
+
+// GetNamedDBConnection acquires a connection from the pool of the registered connection
+func (pge *PgEngine) GetNamedDBConnection(ctx context.Context, name string) (PgxConnIface, error) {
+	var conn Connection
+	if err := pge.SelectConnection(ctx, &conn, name); err != nil {
+		return nil, err
+	}
+	pool, err := pge.getConnPool(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	c, err := pool.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	log.GetLogger(ctx).WithField("connection", name).Info("Remote connection acquired...")
+	return pooledConn{c}, nil
+}
+
+// CloseConnPools closes all pools of the named connections
+func (pge *PgEngine) CloseConnPools() {
+	pge.remotePools.Lock()
+	defer pge.remotePools.Unlock()
+	for name, cp := range pge.remotePools.pools {
+		cp.pool.Close()
+		delete(pge.remotePools.pools, name)
+	}
+}
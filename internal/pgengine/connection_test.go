@@ -0,0 +1,110 @@
+package pgengine
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pashagolub/pgxmock/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveCredentials(t *testing.T) {
+	pge := NewDB(nil, "--log-database-level=none")
+	pge.Remote.Passwords = map[string]string{"sales": "from_config", "other": "other_config"}
+	pge.Secrets.EnvPrefix = "PGTT_"
+	pge.Secrets.Dir = t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(pge.Secrets.Dir, "sales_password"), []byte("from_file\n"), 0600))
+	outside := filepath.Join(t.TempDir(), "outside")
+	require.NoError(t, os.WriteFile(outside, []byte("outside\n"), 0600))
+	t.Setenv("PGTT_TEST_PASSWORD", "from_env")
+	t.Setenv("OTHER_PASSWORD", "not_a_secret")
+	pge.secretProviders = []SecretProvider{EnvSecrets{Prefix: "PGTT_"}}
+
+	for _, c := range []struct {
+		credentials string
+		expected    string
+		fail        bool
+	}{
+		{"", "", false},
+		{"env:test_password", "from_env", false},
+		{"env:test_password_unknown", "", true},
+		{"env:", "", true},
+		{"env:../OTHER_PASSWORD", "", true},
+		{"file:sales_password", "from_file", false},
+		{"file:" + outside, "", true},
+		{"file:../outside", "", true},
+		{"file:/etc/passwd", "", true},
+		{"config", "from_config", false},
+		{"config:Other", "other_config", false},
+		{"config:unknown", "", true},
+		{"secret:test_password", "from_env", false},
+		{"secret:unknown", "", true},
+		{"vault:foo", "", true},
+	} {
+		pwd, err := pge.resolveCredentials(context.Background(), Connection{Name: "Sales", Credentials: c.credentials})
+		assert.Equal(t, c.fail, err != nil, c.credentials)
+		assert.Equal(t, c.expected, pwd, c.credentials)
+	}
+
+	pge.Secrets.EnvPrefix, pge.Secrets.Dir = "", ""
+	for _, credentials := range []string{"env:PGTT_TEST_PASSWORD", "file:sales_password"} {
+		_, err := pge.resolveCredentials(context.Background(), Connection{Name: "Sales", Credentials: credentials})
+		assert.Error(t, err, "secrets namespace is not configured")
+	}
+}
+
+func TestGetConnPool(t *testing.T) {
+	pge := NewDB(nil, "--log-database-level=none")
+	defer pge.CloseConnPools()
+	ctx := context.Background()
+	conn := Connection{Name: "sales", ConnectString: "host=/nonexistent dbname=sales", MaxConns: 2, IdleTimeout: 0}
+
+	pool, err := pge.getConnPool(ctx, conn)
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, pool.Config().MaxConns)
+	assert.Equal(t, "pg_timetable", pool.Config().ConnConfig.RuntimeParams["application_name"])
+	same, err := pge.getConnPool(ctx, conn)
+	assert.NoError(t, err)
+	assert.Same(t, pool, same, "Pool should be reused for the same definition")
+
+	conn.MaxConns = 3
+	changed, err := pge.getConnPool(ctx, conn)
+	assert.NoError(t, err)
+	assert.NotSame(t, pool, changed, "Pool should be recreated if definition changed")
+
+	named, err := pge.getConnPool(ctx, Connection{Name: "named", ConnectString: "host=/nonexistent application_name=reports", MaxConns: 1})
+	require.NoError(t, err)
+	assert.Equal(t, "reports", named.Config().ConnConfig.RuntimeParams["application_name"])
+
+	_, err = pge.getConnPool(ctx, Connection{Name: "bad", ConnectString: "foo=bar"})
+	assert.Error(t, err)
+	_, err = pge.getConnPool(ctx, Connection{Name: "bad", Credentials: "env:test_password_unknown"})
+	assert.Error(t, err)
+}
+
+func TestGetNamedDBConnection(t *testing.T) {
+	mockPool, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	pge := NewDB(mockPool, "--log-database-level=none")
+	defer pge.CloseConnPools()
+	ctx := context.Background()
+	columns := []string{"connection_name", "connect_string", "credentials", "max_conns", "idle_timeout"}
+
+	mockPool.ExpectQuery("SELECT connection_name").WithArgs("foo").WillReturnError(errors.New("failed"))
+	_, err = pge.GetNamedDBConnection(ctx, "foo")
+	assert.Error(t, err)
+
+	mockPool.ExpectQuery("SELECT connection_name").WithArgs("foo").WillReturnRows(pgxmock.NewRows(columns))
+	_, err = pge.GetNamedDBConnection(ctx, "foo")
+	assert.ErrorContains(t, err, "is not registered")
+
+	mockPool.ExpectQuery("SELECT connection_name").WithArgs("foo").
+		WillReturnRows(pgxmock.NewRows(columns).AddRow("foo", "host=/nonexistent dbname=foo connect_timeout=1", "", 1, 0))
+	_, err = pge.GetNamedDBConnection(ctx, "foo")
+	assert.Error(t, err, "Connection to nonexistent socket should fail")
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
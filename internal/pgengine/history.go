@@ -0,0 +1,299 @@
+package pgengine
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	pgx "github.com/jackc/pgx/v5"
+)
+
+// DefaultHistoryLimit and MaxHistoryLimit restrict the number of history entries returned at once
+const (
+	DefaultHistoryLimit = 100
+	MaxHistoryLimit     = 1000
+)
+
+// HistoryFilter restricts chain runs, task executions and log entries returned. Zero values mean no restriction
+type HistoryFilter struct {
+	ChainID int
+	RunID   int64  // task executions only
+	Status  string // run status, execution status (succeeded, warning, failed) or log level
+	Client  string
+	From    time.Time // inclusive
+	To      time.Time // exclusive
+	Search  string    // case insensitive substring of the error, command, output or log message
+	Limit   int
+	Cursor  string // returned with the previous page
+}
+
+// ChainRun is the entry of timetable.chain_run
+type ChainRun struct {
+	RunID       int64          `db:"run_id" json:"run_id"`
+	ChainID     int            `db:"chain_id" json:"chain_id"`
+	Trigger     string         `db:"trigger" json:"trigger"`
+	ScheduledAt *time.Time     `db:"scheduled_at" json:"scheduled_at,omitempty"`
+	StartedAt   time.Time      `db:"started_at" json:"started_at"`
+	FinishedAt  *time.Time     `db:"finished_at" json:"finished_at,omitempty"`
+	Status      string         `db:"status" json:"status"`
+	Error       string         `db:"error" json:"error,omitempty"`
+	ClientName  string         `db:"client_name" json:"client_name"`
+	Overrides   ParamOverrides `db:"overrides" json:"overrides,omitempty"`
+}
+
+// Execution is the entry of timetable.execution_log
+type Execution struct {
+	ChainID     int             `db:"chain_id" json:"chain_id"`
+	TaskID      int             `db:"task_id" json:"task_id"`
+	RunID       int64           `db:"run_id" json:"run_id,omitempty"`
+	Txid        int64           `db:"txid" json:"txid"`
+	LastRun     time.Time       `db:"last_run" json:"last_run"`
+	Finished    time.Time       `db:"finished" json:"finished"`
+	Pid         int64           `db:"pid" json:"pid"`
+	Returncode  int             `db:"returncode" json:"returncode"`
+	IgnoreError bool            `db:"ignore_error" json:"ignore_error"`
+	Warning     bool            `db:"warning" json:"warning"`
+	Kind        string          `db:"kind" json:"kind"`
+	Command     string          `db:"command" json:"command"`
+	Params      string          `db:"params" json:"params,omitempty"`
+	Output      string          `db:"output" json:"output,omitempty"`
+	Result      json.RawMessage `db:"result" json:"result,omitempty"`
+	ClientName  string          `db:"client_name" json:"client_name"`
+	Key         string          `db:"page_key" json:"-"`
+}
+
+// LogEntry is the entry of timetable.log
+type LogEntry struct {
+	Ts          time.Time       `db:"ts" json:"ts"`
+	Pid         int             `db:"pid" json:"pid"`
+	LogLevel    string          `db:"log_level" json:"log_level"`
+	ClientName  string          `db:"client_name" json:"client_name"`
+	Message     string          `db:"message" json:"message"`
+	MessageData json.RawMessage `db:"message_data" json:"message_data,omitempty"`
+	Key         string          `db:"page_key" json:"-"`
+}
+
+// executionStatuses maps execution status to the condition on timetable.execution_log
+var executionStatuses = map[string]string{
+	"succeeded": "returncode = 0 AND NOT warning",
+	"warning":   "warning",
+	"failed":    "returncode <> 0 AND NOT warning",
+}
+
+// ErrInvalidCursor is returned if the page cursor cannot be decoded
+var ErrInvalidCursor = errors.New("invalid page cursor")
+
+// pageCursor points to the last entry of the page, the next page starts right after it
+type pageCursor struct {
+	At  time.Time
+	Key string // ID of the entry, tie breaker for entries with the same timestamp
+}
+
+func (c pageCursor) String() string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(c.At.UnixMicro(), 10) + ":" + c.Key))
+}
+
+func parsePageCursor(s string) (c pageCursor, err error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, ErrInvalidCursor
+	}
+	at, key, ok := strings.Cut(string(b), ":")
+	micro, err := strconv.ParseInt(at, 10, 64)
+	if !ok || err != nil {
+		return c, ErrInvalidCursor
+	}
+	if _, err = strconv.ParseInt(key, 10, 64); err != nil {
+		return c, ErrInvalidCursor
+	}
+	return pageCursor{At: time.UnixMicro(micro).UTC(), Key: key}, nil
+}
+
+// historyQuery collects conditions and arguments of the history query
+type historyQuery struct {
+	conds []string
+	args  []any
+}
+
+// where adds the condition with the argument referenced as $? in the condition text
+func (q *historyQuery) where(cond string, arg any) {
+	q.args = append(q.args, arg)
+	q.conds = append(q.conds, strings.ReplaceAll(cond, "$?", "$"+strconv.Itoa(len(q.args))))
+}
+
+// filter adds common conditions on the client, time range and page cursor
+func (q *historyQuery) filter(f HistoryFilter, tsColumn, keyColumn string) error {
+	if f.Client != "" {
+		q.where("client_name = $?", f.Client)
+	}
+	if !f.From.IsZero() {
+		q.where(tsColumn+" >= $?", f.From)
+	}
+	if !f.To.IsZero() {
+		q.where(tsColumn+" < $?", f.To)
+	}
+	if f.Cursor != "" {
+		c, err := parsePageCursor(f.Cursor)
+		if err != nil {
+			return err
+		}
+		q.where(tsColumn+" <= $?", c.At) // let the planner use the timestamp index
+		q.where(fmt.Sprintf("(%s, %s) < ($%d, $?::bigint)", tsColumn, keyColumn, len(q.args)), c.Key)
+	}
+	return nil
+}
+
+// sql returns the query text ordered by the timestamp and the key descending,
+// one extra row is requested to find out if there is the next page
+func (q *historyQuery) sql(selectSQL, tsColumn, keyColumn string, limit int) string {
+	if len(q.conds) > 0 {
+		selectSQL += "\nWHERE " + strings.Join(q.conds, " AND ")
+	}
+	return fmt.Sprintf("%s\nORDER BY %s DESC, %s DESC LIMIT %d", selectSQL, tsColumn, keyColumn, limit+1)
+}
+
+// likePattern returns the pattern matching the text anywhere in the string
+func likePattern(text string) string {
+	return "%" + strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(text) + "%"
+}
+
+func historyLimit(limit int) int {
+	if limit <= 0 {
+		return DefaultHistoryLimit
+	}
+	return min(limit, MaxHistoryLimit)
+}
+
+// selectPage returns at most limit rows and the cursor of the next page, empty for the last one
+func selectPage[T any](ctx context.Context, db executor, sql string, args []any, limit int, cursor func(T) pageCursor) ([]T, string, error) {
+	rows, err := db.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, "", err
+	}
+	page, err := pgx.CollectRows(rows, pgx.RowToStructByName[T])
+	if err != nil || len(page) <= limit {
+		return page, "", err
+	}
+	page = page[:limit]
+	return page, cursor(page[limit-1]).String(), nil
+}
+
+// SelectChainRuns returns chain runs starting with the latest one
+func (pge *PgEngine) SelectChainRuns(ctx context.Context, f HistoryFilter) ([]ChainRun, string, error) {
+	const sqlSelectRuns = `SELECT run_id, chain_id, trigger, scheduled_at, started_at, finished_at,
+status::text as status, COALESCE(error, '') as error, client_name, overrides
+FROM timetable.chain_run`
+	q := &historyQuery{}
+	if f.ChainID != 0 {
+		q.where("chain_id = $?", f.ChainID)
+	}
+	if f.Status != "" {
+		q.where("status::text = $?", strings.ToUpper(f.Status))
+	}
+	if f.Search != "" {
+		q.where("error ILIKE $?", likePattern(f.Search))
+	}
+	if err := q.filter(f, "started_at", "run_id"); err != nil {
+		return nil, "", err
+	}
+	limit := historyLimit(f.Limit)
+	return selectPage(ctx, pge.ConfigDb, q.sql(sqlSelectRuns, "started_at", "run_id", limit), q.args, limit,
+		func(r ChainRun) pageCursor { return pageCursor{r.StartedAt, strconv.FormatInt(r.RunID, 10)} })
+}
+
+// SelectExecutions returns task executions starting with the latest finished one
+func (pge *PgEngine) SelectExecutions(ctx context.Context, f HistoryFilter) ([]Execution, string, error) {
+	const sqlSelectExecutions = `SELECT COALESCE(chain_id, 0) as chain_id, COALESCE(task_id, 0) as task_id,
+COALESCE(run_id, 0) as run_id, txid, COALESCE(last_run, finished) as last_run, finished, COALESCE(pid, 0) as pid,
+COALESCE(returncode, 0) as returncode, COALESCE(ignore_error, false) as ignore_error, warning,
+COALESCE(kind::text, '') as kind, COALESCE(command, '') as command, COALESCE(params, '') as params,
+COALESCE(output, '') as output, result, client_name, execution_id::text as page_key
+FROM timetable.execution_log`
+	q := &historyQuery{conds: []string{"finished IS NOT NULL"}}
+	if f.ChainID != 0 {
+		q.where("chain_id = $?", f.ChainID)
+	}
+	if f.RunID != 0 {
+		q.where("run_id = $?", f.RunID)
+	}
+	if f.Status != "" {
+		cond, ok := executionStatuses[strings.ToLower(f.Status)]
+		if !ok {
+			return nil, "", fmt.Errorf("unknown execution status: %s", f.Status)
+		}
+		q.conds = append(q.conds, cond)
+	}
+	if f.Search != "" {
+		q.where("(command ILIKE $? OR output ILIKE $?)", likePattern(f.Search))
+	}
+	if err := q.filter(f, "finished", "execution_id"); err != nil {
+		return nil, "", err
+	}
+	limit := historyLimit(f.Limit)
+	return selectPage(ctx, pge.ConfigDb, q.sql(sqlSelectExecutions, "finished", "execution_id", limit), q.args, limit,
+		func(e Execution) pageCursor { return pageCursor{e.Finished, e.Key} })
+}
+
+// SelectLogEntries returns log entries starting with the latest one
+func (pge *PgEngine) SelectLogEntries(ctx context.Context, f HistoryFilter) ([]LogEntry, string, error) {
+	const sqlSelectLog = `SELECT ts, pid, log_level::text as log_level, COALESCE(client_name, '') as client_name,
+COALESCE(message, '') as message, message_data, log_id::text as page_key
+FROM timetable.log`
+	q := &historyQuery{}
+	if f.ChainID != 0 {
+		// chain is logged either as an object or as an identifier
+		q.where("$? IN (message_data->'chain'->>'ChainID', message_data->>'chain', message_data->>'chain_id')",
+			strconv.Itoa(f.ChainID))
+	}
+	if f.Status != "" {
+		q.where("log_level::text = $?", strings.ToUpper(f.Status))
+	}
+	if f.Search != "" {
+		q.where("message ILIKE $?", likePattern(f.Search))
+	}
+	if err := q.filter(f, "ts", "log_id"); err != nil {
+		return nil, "", err
+	}
+	limit := historyLimit(f.Limit)
+	return selectPage(ctx, pge.ConfigDb, q.sql(sqlSelectLog, "ts", "log_id", limit), q.args, limit,
+		func(e LogEntry) pageCursor { return pageCursor{e.Ts, e.Key} })
+}
+
+// ChainOverview is the chain with its next scheduled start and the latest run
+type ChainOverview struct {
+	ChainID    int        `db:"chain_id" json:"chain_id"`
+	ChainName  string     `db:"chain_name" json:"chain_name"`
+	Schedule   string     `db:"run_at" json:"schedule"`
+	Live       bool       `db:"live" json:"live"`
+	ClientName string     `db:"client_name" json:"client_name,omitempty"`
+	NextRun    *time.Time `db:"next_run" json:"next_run,omitempty"` // cron schedules of live chains only
+	LastRun    *ChainRun  `db:"last_run" json:"last_run,omitempty"`
+}
+
+// SelectRunChainID returns the ID of the chain of the run. Returns pgx.ErrNoRows if there is no such run
+func (pge *PgEngine) SelectRunChainID(ctx context.Context, runID int64) (chainID int, err error) {
+	err = pge.ConfigDb.QueryRow(ctx, "SELECT chain_id FROM timetable.chain_run WHERE run_id = $1", runID).Scan(&chainID)
+	return
+}
+
+// SelectChainOverview returns all chains with the next run time calculated by timetable.next_run() and the latest run
+func (pge *PgEngine) SelectChainOverview(ctx context.Context) ([]ChainOverview, error) {
+	const sqlSelectChainOverview = `SELECT c.chain_id, c.chain_name, COALESCE(c.run_at, '') as run_at, c.live,
+COALESCE(c.client_name, '') as client_name,
+CASE WHEN c.live AND c.run_at !~ '^@' THEN timetable.next_run(c.run_at) END as next_run,
+to_jsonb(r) as last_run
+FROM timetable.chain c LEFT JOIN LATERAL (
+	SELECT * FROM timetable.chain_run WHERE chain_id = c.chain_id ORDER BY started_at DESC LIMIT 1
+) r ON true
+ORDER BY c.chain_id`
+	rows, err := pge.ConfigDb.Query(ctx, sqlSelectChainOverview)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowToStructByName[ChainOverview])
+}
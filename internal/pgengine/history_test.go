@@ -0,0 +1,159 @@
+package pgengine_test
+
+import (
+	"context"
+	"encoding/base64"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/cybertec-postgresql/pg_timetable/internal/pgengine"
+	"github.com/pashagolub/pgxmock/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var chainRunColumns = []string{"run_id", "chain_id", "trigger", "scheduled_at", "started_at", "finished_at",
+	"status", "error", "client_name", "overrides"}
+
+func TestSelectChainRuns(t *testing.T) {
+	initmockdb(t)
+	defer mockPool.Close()
+	pge := pgengine.NewDB(mockPool, "pgengine_unit_test")
+	ctx := context.Background()
+	started := time.Date(2026, 1, 2, 3, 4, 5, 6000, time.UTC)
+	from := started.Add(-time.Hour)
+
+	mockPool.ExpectQuery(`FROM timetable\.chain_run
+WHERE chain_id = \$1 AND status::text = \$2 AND error ILIKE \$3 AND client_name = \$4 AND started_at >= \$5
+ORDER BY started_at DESC, run_id DESC LIMIT 3`).
+		WithArgs(42, "FAILED", `%100\%%`, "worker", from).
+		WillReturnRows(pgxmock.NewRows(chainRunColumns).
+			AddRow(int64(3), 42, "cron", nil, started, nil, "FAILED", "100% full", "worker", nil).
+			AddRow(int64(2), 42, "cron", nil, started, nil, "FAILED", "100% full", "worker", nil).
+			AddRow(int64(1), 42, "cron", nil, started, nil, "FAILED", "100% full", "worker", nil))
+	runs, cursor, err := pge.SelectChainRuns(ctx, pgengine.HistoryFilter{
+		ChainID: 42, Status: "failed", Search: "100%", Client: "worker", From: from, Limit: 2})
+	require.NoError(t, err)
+	assert.Len(t, runs, 2)
+	require.NotEmpty(t, cursor, "there is the next page")
+
+	mockPool.ExpectQuery(`FROM timetable\.chain_run
+WHERE started_at <= \$1 AND \(started_at, run_id\) < \(\$1, \$2::bigint\)
+ORDER BY started_at DESC, run_id DESC LIMIT 3`).
+		WithArgs(started, "2").
+		WillReturnRows(pgxmock.NewRows(chainRunColumns).
+			AddRow(int64(1), 42, "cron", nil, started, nil, "FAILED", "100% full", "worker", nil))
+	runs, cursor, err = pge.SelectChainRuns(ctx, pgengine.HistoryFilter{Cursor: cursor, Limit: 2})
+	require.NoError(t, err)
+	assert.Len(t, runs, 1)
+	assert.Empty(t, cursor, "the last page")
+
+	_, _, err = pge.SelectChainRuns(ctx, pgengine.HistoryFilter{Cursor: "foo"})
+	assert.ErrorIs(t, err, pgengine.ErrInvalidCursor)
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestSelectExecutions(t *testing.T) {
+	initmockdb(t)
+	defer mockPool.Close()
+	pge := pgengine.NewDB(mockPool, "pgengine_unit_test")
+	ctx := context.Background()
+
+	mockPool.ExpectQuery(`FROM timetable\.execution_log
+WHERE finished IS NOT NULL AND run_id = \$1 AND returncode <> 0 AND NOT warning AND \(command ILIKE \$2 OR output ILIKE \$2\)
+ORDER BY finished DESC, execution_id DESC LIMIT 101`).
+		WithArgs(int64(7), "%foo%").
+		WillReturnRows(pgxmock.NewRows([]string{"chain_id", "task_id", "run_id", "txid", "last_run", "finished",
+			"pid", "returncode", "ignore_error", "warning", "kind", "command", "params", "output", "result",
+			"client_name", "page_key"}).
+			AddRow(42, 1, int64(7), int64(100), time.Now(), time.Now(), int64(1), 1, false, false, "SQL",
+				"SELECT foo", "", "error", nil, "worker", "17"))
+	execs, cursor, err := pge.SelectExecutions(ctx, pgengine.HistoryFilter{RunID: 7, Status: "Failed", Search: "foo"})
+	require.NoError(t, err)
+	assert.Len(t, execs, 1)
+	assert.Empty(t, cursor)
+
+	_, _, err = pge.SelectExecutions(ctx, pgengine.HistoryFilter{Status: "unknown"})
+	assert.Error(t, err)
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestSelectLogEntries(t *testing.T) {
+	initmockdb(t)
+	defer mockPool.Close()
+	pge := pgengine.NewDB(mockPool, "pgengine_unit_test")
+	ctx := context.Background()
+	to := time.Now()
+
+	mockPool.ExpectQuery(`FROM timetable\.log
+WHERE \$1 IN \(message_data->'chain'->>'ChainID', message_data->>'chain', message_data->>'chain_id'\) AND log_level::text = \$2 AND ts < \$3
+ORDER BY ts DESC, log_id DESC LIMIT 11`).
+		WithArgs("42", "ERROR", to).
+		WillReturnRows(pgxmock.NewRows([]string{"ts", "pid", "log_level", "client_name", "message",
+			"message_data", "page_key"}).
+			AddRow(time.Now(), 1, "ERROR", "worker", "Chain failed", []byte(`{"chain": 42}`), "17"))
+	entries, _, err := pge.SelectLogEntries(ctx, pgengine.HistoryFilter{ChainID: 42, Status: "error", To: to, Limit: 10})
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+
+	ts := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	logRows := func() *pgxmock.Rows {
+		return pgxmock.NewRows([]string{"ts", "pid", "log_level", "client_name", "message", "message_data", "page_key"})
+	}
+	mockPool.ExpectQuery(`FROM timetable\.log
+ORDER BY ts DESC, log_id DESC LIMIT 2`).
+		WillReturnRows(logRows().
+			AddRow(ts, 1, "INFO", "worker", "foo", nil, "18").
+			AddRow(ts, 1, "INFO", "worker", "bar", nil, "17"))
+	_, cursor, err := pge.SelectLogEntries(ctx, pgengine.HistoryFilter{Limit: 1})
+	require.NoError(t, err)
+	mockPool.ExpectQuery(`FROM timetable\.log
+WHERE ts <= \$1 AND \(ts, log_id\) < \(\$1, \$2::bigint\)
+ORDER BY ts DESC, log_id DESC LIMIT 2`).
+		WithArgs(ts, "18").
+		WillReturnRows(logRows().AddRow(ts, 1, "INFO", "worker", "bar", nil, "17"))
+	entries, _, err = pge.SelectLogEntries(ctx, pgengine.HistoryFilter{Cursor: cursor, Limit: 1})
+	require.NoError(t, err)
+	assert.Equal(t, "bar", entries[0].Message, "entries with the same timestamp are paged by ID")
+
+	ctidCursor := base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(ts.UnixMicro(), 10) + ":(0,1)"))
+	_, _, err = pge.SelectLogEntries(ctx, pgengine.HistoryFilter{Cursor: ctidCursor})
+	assert.ErrorIs(t, err, pgengine.ErrInvalidCursor, "cursor key must be the entry ID")
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestSelectRunChainID(t *testing.T) {
+	initmockdb(t)
+	defer mockPool.Close()
+	pge := pgengine.NewDB(mockPool, "pgengine_unit_test")
+
+	mockPool.ExpectQuery(`SELECT chain_id FROM timetable\.chain_run`).WithArgs(int64(7)).
+		WillReturnRows(pgxmock.NewRows([]string{"chain_id"}).AddRow(42))
+	chainID, err := pge.SelectRunChainID(context.Background(), 7)
+	require.NoError(t, err)
+	assert.Equal(t, 42, chainID)
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestSelectChainOverview(t *testing.T) {
+	initmockdb(t)
+	defer mockPool.Close()
+	pge := pgengine.NewDB(mockPool, "pgengine_unit_test")
+	next := time.Date(2026, 1, 2, 3, 4, 0, 0, time.UTC)
+
+	mockPool.ExpectQuery(`timetable\.next_run\(c\.run_at\).+FROM timetable\.chain c LEFT JOIN LATERAL`).
+		WillReturnRows(pgxmock.NewRows([]string{"chain_id", "chain_name", "run_at", "live", "client_name", "next_run", "last_run"}).
+			AddRow(1, "foo", "* * * * *", true, "", &next, &pgengine.ChainRun{RunID: 7, ChainID: 1, Status: "RUNNING"}).
+			AddRow(2, "bar", "@reboot", false, "", nil, (*pgengine.ChainRun)(nil)))
+	chains, err := pge.SelectChainOverview(context.Background())
+	require.NoError(t, err)
+	require.Len(t, chains, 2)
+	assert.Equal(t, next, *chains[0].NextRun)
+	require.NotNil(t, chains[0].LastRun)
+	assert.Equal(t, int64(7), chains[0].LastRun.RunID)
+	assert.Equal(t, "RUNNING", chains[0].LastRun.Status)
+	assert.Nil(t, chains[1].NextRun)
+	assert.Nil(t, chains[1].LastRun, "chain never run")
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
@@ -3,6 +3,7 @@ package pgengine
 import (
 	"context"
 	"encoding/json"
+	"sync/atomic"
 	"time"
 
 	pgx "github.com/jackc/pgx/v5"
@@ -16,11 +17,23 @@ type LogHook struct {
 	highLoadTimeout time.Duration // wait this amount of time before skip log entry
 	db              PgxPoolIface
 	input           chan logrus.Entry
+	overflow        chan logrus.Entry // entries not accepted by poll due to a huge load, nil if spooling is disabled
 	ctx             context.Context
 	lastError       chan error
 	pid             int32
 	client          string
 	level           string
+	spool           *logSpool // nil if spooling is disabled
+	dropped         atomic.Int64
+	spooled         atomic.Int64
+	replayed        atomic.Int64
+}
+
+// LogHookStats holds the number of database log entries dropped, spooled to disk and replayed from the spool
+type LogHookStats struct {
+	Dropped  int64
+	Spooled  int64
+	Replayed int64
 }
 
 // NewHook creates a LogHook to be added to an instance of logger
@@ -38,10 +51,28 @@ func NewHook(ctx context.Context, pge *PgEngine, level string) *LogHook {
 		client:          pge.ClientName,
 		level:           level,
 	}
+	if pge.Logging.LogSpoolDir > "" {
+		var err error
+		if l.spool, err = newLogSpool(pge.Logging.LogSpoolDir, pge.Logging.LogSpoolSize); err != nil {
+			pge.l.WithError(err).Error("Cannot open log spool, undelivered entries will be dropped")
+		} else {
+			l.overflow = make(chan logrus.Entry, cacheLimit)
+			go l.spillOverflow(l.overflow)
+		}
+	}
 	go l.poll(l.input)
 	return l
 }
 
+// Stats returns the counters of undelivered entries
+func (hook *LogHook) Stats() LogHookStats {
+	return LogHookStats{
+		Dropped:  hook.dropped.Load(),
+		Spooled:  hook.spooled.Load(),
+		Replayed: hook.replayed.Load(),
+	}
+}
+
 // Fire adds logrus log message to the internal queue for processing
 func (hook *LogHook) Fire(entry *logrus.Entry) error {
 	if hook.ctx.Err() != nil {
@@ -51,7 +82,13 @@ func (hook *LogHook) Fire(entry *logrus.Entry) error {
 	case hook.input <- *entry:
 		// entry sent
 	case <-time.After(hook.highLoadTimeout):
-		// entry dropped due to a huge load, check stdout or file for detailed log
+		// entry passed to the spool writer or dropped due to a huge load, check stdout or file for detailed log
+		select {
+		case hook.overflow <- *entry:
+			// entry will be spooled in the background
+		default:
+			hook.dropped.Add(1)
+		}
 	}
 	select {
 	case err := <-hook.lastError:
@@ -94,6 +131,7 @@ func (hook *LogHook) poll(input <-chan logrus.Entry) {
 	for {
 		select {
 		case <-hook.ctx.Done(): //check context with high priority
+			hook.spill(cache)
 			return
 		default:
 			select {
@@ -110,12 +148,35 @@ func (hook *LogHook) poll(input <-chan logrus.Entry) {
 				hook.send(cache)
 				cache = cache[:0]
 			case <-hook.ctx.Done():
+				hook.spill(cache)
 				return
 			}
 		}
 	}
 }
 
+// spillOverflow writes entries not accepted by poll to the spool, so Fire never waits for the disk
+func (hook *LogHook) spillOverflow(overflow <-chan logrus.Entry) {
+	cache := make([]logrus.Entry, 0, hook.cacheLimit)
+	for {
+		select {
+		case entry := <-overflow:
+			cache = append(cache, entry)
+			for len(cache) < hook.cacheLimit && len(overflow) > 0 { // write entries queued meanwhile at once
+				cache = append(cache, <-overflow)
+			}
+			hook.spill(cache)
+			cache = cache[:0]
+		case <-hook.ctx.Done():
+			for len(overflow) > 0 {
+				cache = append(cache, <-overflow)
+			}
+			hook.spill(cache)
+			return
+		}
+	}
+}
+
 func adaptEntryLevel(level logrus.Level) string {
 	switch level {
 	case logrus.TraceLevel, logrus.DebugLevel:
@@ -130,41 +191,111 @@ func adaptEntryLevel(level logrus.Level) string {
 	return "UNKNOWN"
 }
 
-// send sends cached messages to the postgres server
-func (hook *LogHook) send(cache []logrus.Entry) {
-	if len(cache) == 0 {
-		return // Nothing to do here.
+// row converts the logrus entry to the timetable.log row
+func (hook *LogHook) row(entry logrus.Entry) (logRow, error) {
+	if errVal, ok := entry.Data[logrus.ErrorKey]; ok {
+		if e, isErr := errVal.(error); isErr && e != nil {
+			entry.Data[logrus.ErrorKey] = e.Error()
+		}
 	}
+	jsonData, err := json.Marshal(entry.Data)
+	return logRow{
+		Ts:      entry.Time,
+		Client:  hook.client,
+		Pid:     hook.pid,
+		Level:   adaptEntryLevel(entry.Level),
+		Message: entry.Message,
+		Data:    jsonData,
+	}, err
+}
+
+func (hook *LogHook) rows(cache []logrus.Entry) []logRow {
+	rows := make([]logRow, 0, len(cache))
+	for _, entry := range cache {
+		if r, err := hook.row(entry); err == nil {
+			rows = append(rows, r)
+		} else {
+			hook.dropped.Add(1)
+		}
+	}
+	return rows
+}
+
+var logColumns = []string{"ts", "client_name", "pid", "log_level", "message", "message_data"}
+
+// copyRows stores rows in timetable.log
+func (hook *LogHook) copyRows(rows []logRow) error {
 	_, err := hook.db.CopyFrom(
 		hook.ctx,
 		pgx.Identifier{"timetable", "log"},
-		[]string{"ts", "client_name", "pid", "log_level", "message", "message_data"},
-		pgx.CopyFromSlice(len(cache),
+		logColumns,
+		pgx.CopyFromSlice(len(rows),
 			func(i int) ([]any, error) {
-				if errVal, ok := cache[i].Data[logrus.ErrorKey]; ok {
-					if e, isErr := errVal.(error); isErr && e != nil {
-						cache[i].Data[logrus.ErrorKey] = e.Error()
-					}
-				}
-
-				jsonData, err := json.Marshal(cache[i].Data)
-				if err != nil {
-					return nil, err
-				}
-				return []any{cache[i].Time,
-					hook.client,
-					hook.pid,
-					adaptEntryLevel(cache[i].Level),
-					cache[i].Message,
-					jsonData}, nil
+				return []any{rows[i].Ts,
+					rows[i].Client,
+					rows[i].Pid,
+					rows[i].Level,
+					rows[i].Message,
+					[]byte(rows[i].Data)}, nil
 			}),
 	)
-	if err != nil {
-		select {
-		case hook.lastError <- err:
-			//error sent to the logger
-		default:
-			//there is unprocessed error already
+	return err
+}
+
+// spill writes entries to the spool if enabled, otherwise entries are dropped
+func (hook *LogHook) spill(cache []logrus.Entry) {
+	if len(cache) > 0 {
+		hook.spillRows(hook.rows(cache))
+	}
+}
+
+func (hook *LogHook) spillRows(rows []logRow) {
+	if hook.spool != nil {
+		if ok, err := hook.spool.Write(rows); ok && err == nil {
+			hook.spooled.Add(int64(len(rows)))
+			return
 		}
 	}
+	hook.dropped.Add(int64(len(rows)))
+}
+
+// replay sends spooled entries to the database, returns false if the spool is not empty after that
+func (hook *LogHook) replay() bool {
+	if hook.spool == nil || hook.spool.Empty() {
+		return true
+	}
+	n, err := hook.spool.Replay(hook.copyRows)
+	hook.replayed.Add(int64(n))
+	if err != nil {
+		hook.reportError(err)
+		return false
+	}
+	return true
+}
+
+func (hook *LogHook) reportError(err error) {
+	select {
+	case hook.lastError <- err:
+		//error sent to the logger
+	default:
+		//there is unprocessed error already
+	}
+}
+
+// send sends cached messages to the postgres server. Spooled messages are sent first to keep the order,
+// messages are spooled if the database is unavailable
+func (hook *LogHook) send(cache []logrus.Entry) {
+	if len(cache) == 0 {
+		hook.replay() // database might be reachable again
+		return
+	}
+	rows := hook.rows(cache)
+	if !hook.replay() {
+		hook.spillRows(rows)
+		return
+	}
+	if err := hook.copyRows(rows); err != nil {
+		hook.spillRows(rows)
+		hook.reportError(err)
+	}
 }
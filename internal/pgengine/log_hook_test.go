@@ -2,13 +2,17 @@ package pgengine
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"strings"
 	"testing"
 	"time"
 
+	pgx "github.com/jackc/pgx/v5"
 	"github.com/pashagolub/pgxmock/v5"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestLogHook(t *testing.T) {
@@ -66,3 +70,87 @@ func TestFireError(t *testing.T) {
 	<-time.After(time.Second)
 	assert.Equal(t, err, h.Fire(&logrus.Entry{}))
 }
+
+func TestLogSpool(t *testing.T) {
+	s, err := newLogSpool(t.TempDir(), 1)
+	require.NoError(t, err)
+	assert.True(t, s.Empty())
+
+	rows := []logRow{{Message: "foo", Data: json.RawMessage(`{}`)}, {Message: "bar", Data: json.RawMessage(`{}`)}}
+	ok, err := s.Write(rows)
+	assert.True(t, ok)
+	assert.NoError(t, err)
+	assert.False(t, s.Empty())
+
+	ok, err = s.Write([]logRow{{Message: strings.Repeat("x", 1<<20)}})
+	assert.False(t, ok, "Rows exceeding the spool size should be rejected")
+	assert.NoError(t, err)
+
+	n, err := s.Replay(func([]logRow) error { return errors.New("database unavailable") })
+	assert.Zero(t, n)
+	assert.Error(t, err)
+	assert.False(t, s.Empty(), "Rows should stay in the spool if replay failed")
+
+	reopened, err := newLogSpool(s.dir, 1)
+	require.NoError(t, err)
+	assert.Equal(t, s.size, reopened.size, "Spool size should survive restart")
+
+	var replayed []logRow
+	n, err = reopened.Replay(func(r []logRow) error { replayed = append(replayed, r...); return nil })
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, "foo", replayed[0].Message)
+	assert.True(t, reopened.Empty())
+}
+
+func TestLogHookSpool(t *testing.T) {
+	mockPool, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	spool, err := newLogSpool(t.TempDir(), 1)
+	require.NoError(t, err)
+	h := &LogHook{ctx: context.Background(), db: mockPool, spool: spool, lastError: make(chan error, 1)}
+	entries := []logrus.Entry{{Message: "foo", Data: logrus.Fields{}}, {Message: "bar", Data: logrus.Fields{}}}
+
+	mockPool.ExpectCopyFrom(pgx.Identifier{"timetable", "log"}, logColumns).WillReturnError(errors.New("failover"))
+	h.send(entries)
+	assert.Equal(t, LogHookStats{Spooled: 2}, h.Stats())
+	assert.Error(t, <-h.lastError)
+
+	mockPool.ExpectCopyFrom(pgx.Identifier{"timetable", "log"}, logColumns).WillReturnResult(2)
+	mockPool.ExpectCopyFrom(pgx.Identifier{"timetable", "log"}, logColumns).WillReturnResult(1)
+	h.send(entries[:1])
+	assert.Equal(t, LogHookStats{Spooled: 2, Replayed: 2}, h.Stats())
+	assert.True(t, spool.Empty())
+
+	h.spool = nil
+	h.spill(entries)
+	assert.Equal(t, int64(2), h.Stats().Dropped, "Entries should be dropped without spool")
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestFireOverflow(t *testing.T) {
+	spool, err := newLogSpool(t.TempDir(), 1)
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	h := &LogHook{ctx: ctx,
+		cacheLimit:      2,
+		highLoadTimeout: time.Millisecond,
+		input:           make(chan logrus.Entry), // nobody polls, so every entry overflows
+		overflow:        make(chan logrus.Entry, 2),
+		spool:           spool,
+		lastError:       make(chan error, 1)}
+
+	assert.NoError(t, h.Fire(&logrus.Entry{Message: "foo", Data: logrus.Fields{}}))
+	assert.NoError(t, h.Fire(&logrus.Entry{Message: "bar", Data: logrus.Fields{}}))
+	assert.Zero(t, h.Stats().Spooled, "Fire should not write to the spool")
+	go h.spillOverflow(h.overflow)
+	assert.Eventually(t, func() bool { return h.Stats().Spooled == 2 }, time.Second, 10*time.Millisecond)
+	cancel()
+
+	h = &LogHook{ctx: context.Background(),
+		highLoadTimeout: time.Millisecond,
+		input:           make(chan logrus.Entry),
+		lastError:       make(chan error, 1)}
+	assert.NoError(t, h.Fire(&logrus.Entry{Message: "foo", Data: logrus.Fields{}}))
+	assert.Equal(t, LogHookStats{Dropped: 1}, h.Stats(), "Entry should be dropped without spool")
+}
@@ -0,0 +1,144 @@
+package pgengine
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+)
+
+// spoolSegmentSize is the size of the spool file after which the next one is started
+const spoolSegmentSize = 1 << 20
+
+// logRow is the entry of timetable.log table as stored in the spool
+type logRow struct {
+	Ts      time.Time       `json:"ts"`
+	Client  string          `json:"client_name"`
+	Pid     int32           `json:"pid"`
+	Level   string          `json:"log_level"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"message_data"`
+}
+
+// logSpool stores log entries undelivered to the database in local files
+// to be replayed once the database is reachable again
+type logSpool struct {
+	sync.Mutex
+	dir     string
+	maxSize int64
+	size    int64  // total size of spool files
+	current string // file the entries are appended to
+}
+
+// newLogSpool opens the spool directory creating it if necessary
+func newLogSpool(dir string, maxSizeMB int) (*logSpool, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	s := &logSpool{dir: dir, maxSize: int64(maxSizeMB) << 20}
+	files, err := s.files()
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range files {
+		if fi, err := os.Stat(f); err == nil {
+			s.size += fi.Size()
+		}
+	}
+	return s, nil
+}
+
+// files returns spool files in the order they were written
+func (s *logSpool) files() ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(s.dir, "*.jsonl"))
+	slices.Sort(files)
+	return files, err
+}
+
+// Empty returns true if there are no entries to replay
+func (s *logSpool) Empty() bool {
+	s.Lock()
+	defer s.Unlock()
+	return s.size == 0
+}
+
+// Write appends rows to the spool. Returns false if rows don't fit the maximum size of the spool
+func (s *logSpool) Write(rows []logRow) (bool, error) {
+	var buf []byte
+	for _, r := range rows {
+		b, err := json.Marshal(r)
+		if err != nil {
+			return false, err
+		}
+		buf = append(append(buf, b...), '\n')
+	}
+	s.Lock()
+	defer s.Unlock()
+	if s.size+int64(len(buf)) > s.maxSize {
+		return false, nil
+	}
+	if s.current == "" {
+		s.current = filepath.Join(s.dir, fmt.Sprintf("%020d.jsonl", time.Now().UnixNano()))
+	}
+	f, err := os.OpenFile(s.current, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return false, err
+	}
+	n, err := f.Write(buf)
+	s.size += int64(n)
+	if e := f.Close(); err == nil {
+		err = e
+	}
+	if fi, e := os.Stat(s.current); e == nil && fi.Size() >= spoolSegmentSize {
+		s.current = ""
+	}
+	return err == nil, err
+}
+
+// Replay passes spooled rows file by file to the send function and removes files sent successfully.
+// Returns the number of rows replayed and stops on the first error
+func (s *logSpool) Replay(send func([]logRow) error) (replayed int, err error) {
+	s.Lock()
+	s.current = "" // new entries go to the next file while the current ones are replayed
+	files, err := s.files()
+	s.Unlock()
+	if err != nil {
+		return 0, err
+	}
+	for _, fname := range files {
+		rows, size, err := readSpoolFile(fname)
+		if err != nil {
+			return replayed, err
+		}
+		if err = send(rows); err != nil {
+			return replayed, err
+		}
+		if err = os.Remove(fname); err != nil {
+			return replayed, err
+		}
+		s.Lock()
+		s.size -= size
+		s.Unlock()
+		replayed += len(rows)
+	}
+	return replayed, nil
+}
+
+func readSpoolFile(fname string) (rows []logRow, size int64, err error) {
+	data, err := os.ReadFile(fname)
+	if err != nil {
+		return nil, 0, err
+	}
+	for line := range bytes.Lines(data) {
+		var r logRow
+		if json.Unmarshal(line, &r) != nil {
+			continue // skip partially written entry, e.g. after crash
+		}
+		rows = append(rows, r)
+	}
+	return rows, int64(len(data)), nil
+}
@@ -165,6 +165,96 @@ var Migrations func() migrator.Option = func() migrator.Option {
 				return ExecuteMigrationScript(ctx, tx, "00797.sql")
 			},
 		},
+		&migrator.Migration{
+			Name: "00800 Add SHELL command kind",
+			Func: func(ctx context.Context, tx pgx.Tx) error {
+				return ExecuteMigrationScript(ctx, tx, "00800.sql")
+			},
+		},
+		&migrator.Migration{
+			Name: "00801 Add success and warning exit codes for PROGRAM tasks",
+			Func: func(ctx context.Context, tx pgx.Tx) error {
+				return ExecuteMigrationScript(ctx, tx, "00801.sql")
+			},
+		},
+		&migrator.Migration{
+			Name: "00802 Add result capture for SQL tasks",
+			Func: func(ctx context.Context, tx pgx.Tx) error {
+				return ExecuteMigrationScript(ctx, tx, "00802.sql")
+			},
+		},
+		&migrator.Migration{
+			Name: "00803 Add assertions for SQL tasks",
+			Func: func(ctx context.Context, tx pgx.Tx) error {
+				return ExecuteMigrationScript(ctx, tx, "00803.sql")
+			},
+		},
+		&migrator.Migration{
+			Name: "00804 Add named connections registry",
+			Func: func(ctx context.Context, tx pgx.Tx) error {
+				return ExecuteMigrationScript(ctx, tx, "00804.sql")
+			},
+		},
+		&migrator.Migration{
+			Name: "00805 Add secrets storage",
+			Func: func(ctx context.Context, tx pgx.Tx) error {
+				return ExecuteMigrationScript(ctx, tx, "00805.sql")
+			},
+		},
+		&migrator.Migration{
+			Name: "00806 Add chain run history",
+			Func: func(ctx context.Context, tx pgx.Tx) error {
+				return ExecuteMigrationScript(ctx, tx, "00806.sql")
+			},
+		},
+		&migrator.Migration{
+			Name: "00807 Add log tables partitioning",
+			Func: func(ctx context.Context, tx pgx.Tx) error {
+				return ExecuteMigrationScript(ctx, tx, "00807.sql")
+			},
+		},
+		&migrator.Migration{
+			Name: "00808 Add standby sessions",
+			Func: func(ctx context.Context, tx pgx.Tx) error {
+				return ExecuteMigrationScript(ctx, tx, "00808.sql")
+			},
+		},
+		&migrator.Migration{
+			Name: "00809 Add chain claims for clusters",
+			Func: func(ctx context.Context, tx pgx.Tx) error {
+				return ExecuteMigrationScript(ctx, tx, "00809.sql")
+			},
+		},
+		&migrator.Migration{
+			Name: "00810 Add run queue",
+			Func: func(ctx context.Context, tx pgx.Tx) error {
+				return ExecuteMigrationScript(ctx, tx, "00810.sql")
+			},
+		},
+		&migrator.Migration{
+			Name: "00811 Add task parameter overrides",
+			Func: func(ctx context.Context, tx pgx.Tx) error {
+				return ExecuteMigrationScript(ctx, tx, "00811.sql")
+			},
+		},
+		&migrator.Migration{
+			Name: "00812 Add run queue claims",
+			Func: func(ctx context.Context, tx pgx.Tx) error {
+				return ExecuteMigrationScript(ctx, tx, "00812.sql")
+			},
+		},
+		&migrator.Migration{
+			Name: "00813 Add log retention indexes",
+			Func: func(ctx context.Context, tx pgx.Tx) error {
+				return ExecuteMigrationScript(ctx, tx, "00813.sql")
+			},
+		},
+		&migrator.Migration{
+			Name: "00814 Add log entry IDs",
+			Func: func(ctx context.Context, tx pgx.Tx) error {
+				return ExecuteMigrationScript(ctx, tx, "00814.sql")
+			},
+		},
 		// adding new migration here, update "timetable"."migration" in "sql/init.sql"
 		// and "dbapi" variable in main.go!
 
@@ -18,7 +18,6 @@ type ChainSignal struct {
 	ConfigID int    // chain configuration ifentifier
 	Command  string // allowed: START, STOP
 	Ts       int64  // timestamp NOTIFY sent
-	Delay    int64  // delay in seconds before start
 }
 
 // Since there are usually multiple opened connections to the database, all of them will receive NOTIFY messages.
@@ -0,0 +1,96 @@
+package pgengine
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+)
+
+// ParamOverrides replaces or extends parameters of tasks for a single chain run. Keys are task names or IDs
+type ParamOverrides map[string]json.RawMessage
+
+// For returns the override of the task by its ID or name, nil if there is none
+func (o ParamOverrides) For(task ChainTask) json.RawMessage {
+	if v, ok := o[strconv.Itoa(task.TaskID)]; ok {
+		return v
+	}
+	if task.TaskName != "" {
+		return o[task.TaskName]
+	}
+	return nil
+}
+
+// jsonb returns the overrides as a query argument, NULL if there are none
+func (o ParamOverrides) jsonb() any {
+	if len(o) == 0 {
+		return nil
+	}
+	b, _ := json.Marshal(o)
+	return string(b)
+}
+
+// Overridable returns false for tasks whose parameters are command line arguments or environment variables,
+// i.e. PROGRAM and SHELL tasks and builtins running programs, so overrides cannot be used to run arbitrary commands.
+// Parameters of builtins accessing files and URLs of the scheduler host cannot be overridden either
+func (task ChainTask) Overridable() bool {
+	switch task.Kind {
+	case "PROGRAM", "SHELL":
+		return false
+	case "BUILTIN":
+		switch task.Command {
+		case "CopyToProgram", "CopyFromProgram", "CopyToFile", "CopyFromFile", "Download":
+			return false
+		}
+	}
+	return true
+}
+
+// Validate checks every override refers to one of the chain tasks, so a misspelled key is not silently ignored,
+// and the task accepts overrides
+func (o ParamOverrides) Validate(tasks []ChainTask) error {
+	for key := range o {
+		i := slices.IndexFunc(tasks, func(task ChainTask) bool {
+			return key == strconv.Itoa(task.TaskID) || key != "" && key == task.TaskName
+		})
+		if i < 0 {
+			return fmt.Errorf("override %q doesn't match any task of the chain", key)
+		}
+		if task := tasks[i]; !task.Overridable() {
+			kind := task.Kind
+			if kind == "BUILTIN" {
+				kind = task.Command
+			}
+			return fmt.Errorf("override %q: parameters of %s tasks cannot be overridden", key, kind)
+		}
+	}
+	return nil
+}
+
+// OverrideParamValues applies the task override to its parameter values. If the override and all the values
+// are JSON objects, the override is merged into each value with its keys taking precedence.
+// Otherwise the override replaces the parameters, so the task is executed once with the override as the value
+func OverrideParamValues(values []string, override json.RawMessage) ([]string, error) {
+	if override == nil {
+		return values, nil
+	}
+	var overrideObj map[string]json.RawMessage
+	if json.Unmarshal(override, &overrideObj) != nil || overrideObj == nil || len(values) == 0 {
+		return []string{string(override)}, nil
+	}
+	merged := make([]string, 0, len(values))
+	for _, value := range values {
+		var obj map[string]json.RawMessage
+		if json.Unmarshal([]byte(value), &obj) != nil || obj == nil {
+			return []string{string(override)}, nil
+		}
+		maps.Copy(obj, overrideObj)
+		b, err := json.Marshal(obj)
+		if err != nil {
+			return nil, err
+		}
+		merged = append(merged, string(b))
+	}
+	return merged, nil
+}
@@ -0,0 +1,62 @@
+package pgengine_test
+
+import (
+	"testing"
+
+	"github.com/cybertec-postgresql/pg_timetable/internal/pgengine"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParamOverrides(t *testing.T) {
+	o := pgengine.ParamOverrides{"42": []byte(`["by id"]`), "report": []byte(`{"day": "today"}`)}
+	assert.JSONEq(t, `["by id"]`, string(o.For(pgengine.ChainTask{TaskID: 42, TaskName: "report"})), "ID takes precedence")
+	assert.JSONEq(t, `{"day": "today"}`, string(o.For(pgengine.ChainTask{TaskID: 1, TaskName: "report"})))
+	assert.Nil(t, o.For(pgengine.ChainTask{TaskID: 1}))
+
+	tasks := []pgengine.ChainTask{{TaskID: 42}, {TaskID: 1, TaskName: "report"}}
+	assert.NoError(t, o.Validate(tasks))
+	assert.ErrorContains(t, o.Validate(tasks[:1]), `override "report"`)
+	assert.Error(t, pgengine.ParamOverrides{"": nil}.Validate([]pgengine.ChainTask{{TaskID: 1}}))
+
+	for _, task := range []pgengine.ChainTask{
+		{TaskID: 1, Kind: "PROGRAM", Command: "psql"},
+		{TaskID: 1, Kind: "SHELL", Command: "echo $1"},
+		{TaskID: 1, Kind: "BUILTIN", Command: "CopyToProgram"},
+		{TaskID: 1, Kind: "BUILTIN", Command: "CopyFromProgram"},
+		{TaskID: 1, Kind: "BUILTIN", Command: "CopyToFile"},
+		{TaskID: 1, Kind: "BUILTIN", Command: "CopyFromFile"},
+		{TaskID: 1, Kind: "BUILTIN", Command: "Download"},
+	} {
+		assert.False(t, task.Overridable(), task.Kind+" "+task.Command)
+		assert.ErrorContains(t, pgengine.ParamOverrides{"1": []byte(`["-c", "id"]`)}.Validate([]pgengine.ChainTask{task}),
+			"cannot be overridden")
+	}
+	assert.True(t, pgengine.ChainTask{Kind: "SQL"}.Overridable())
+	assert.True(t, pgengine.ChainTask{Kind: "BUILTIN", Command: "SendMail"}.Overridable())
+}
+
+func TestOverrideParamValues(t *testing.T) {
+	values := []string{`{"day": "yesterday", "format": "csv"}`, `{"day": "today"}`}
+	got, err := pgengine.OverrideParamValues(values, nil)
+	require.NoError(t, err)
+	assert.Equal(t, values, got, "no override")
+
+	got, err = pgengine.OverrideParamValues(values, []byte(`{"day": "2026-01-01"}`))
+	require.NoError(t, err)
+	require.Len(t, got, 2)
+	assert.JSONEq(t, `{"day": "2026-01-01", "format": "csv"}`, got[0])
+	assert.JSONEq(t, `{"day": "2026-01-01"}`, got[1])
+
+	got, err = pgengine.OverrideParamValues([]string{`[1, 2]`, `[3, 4]`}, []byte(`[5, 6]`))
+	require.NoError(t, err)
+	assert.Equal(t, []string{`[5, 6]`}, got, "non-object override replaces all values")
+
+	got, err = pgengine.OverrideParamValues([]string{`[1, 2]`}, []byte(`{"day": "today"}`))
+	require.NoError(t, err)
+	assert.Equal(t, []string{`{"day": "today"}`}, got, "object override replaces non-object values")
+
+	got, err = pgengine.OverrideParamValues(nil, []byte(`{"day": "today"}`))
+	require.NoError(t, err)
+	assert.Equal(t, []string{`{"day": "today"}`}, got, "override is the only value of the task without parameters")
+}
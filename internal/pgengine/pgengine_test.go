@@ -109,7 +109,7 @@ func TestSchedulerFunctions(t *testing.T) {
 		assert.Greater(t, txid, int64(0), "Should return transaction id")
 		assert.NoError(t, pge.GetChainElements(ctx, &chains, 0), "Should no error in clean database")
 		assert.Empty(t, chains, "Should be empty in clean database")
-		pge.CommitTransaction(ctx, tx)
+		assert.NoError(t, pge.CommitTransaction(ctx, tx))
 	})
 
 	t.Run("Check GetChainElements returns only live tasks", func(t *testing.T) {
@@ -137,7 +137,7 @@ func TestSchedulerFunctions(t *testing.T) {
 			TaskID:  0,
 			ChainID: 0}), "Should no error in clean database")
 		assert.Empty(t, paramVals, "Should be empty in clean database")
-		pge.CommitTransaction(ctx, tx)
+		assert.NoError(t, pge.CommitTransaction(ctx, tx))
 	})
 
 	t.Run("Check InsertChainRunStatus function", func(t *testing.T) {
@@ -163,7 +163,7 @@ func TestSchedulerFunctions(t *testing.T) {
 		assert.NoError(t, f("SELECT $1::int4", []string{"[42]", `[14]`}), "Simple query with doubled parameters")
 		assert.NoError(t, f("SELECT $1::int4, $2::text", []string{`[42, "hey"]`}), "Simple query with two parameters")
 
-		pge.CommitTransaction(ctx, tx)
+		assert.NoError(t, pge.CommitTransaction(ctx, tx))
 	})
 
 }
@@ -0,0 +1,67 @@
+package pgengine
+
+import (
+	"context"
+	"time"
+
+	pgx "github.com/jackc/pgx/v5"
+)
+
+// QueuedRun is the chain start requested in timetable.run_queue
+type QueuedRun struct {
+	QueueID   int64          `db:"queue_id"`
+	ChainID   int            `db:"chain_id"`
+	StartAt   time.Time      `db:"start_at"`
+	Overrides ParamOverrides `db:"overrides"`
+}
+
+// EnqueueChain adds the chain to the run queue of the client to be started after the delay with optional overrides
+func (pge *PgEngine) EnqueueChain(ctx context.Context, chainID int, delay time.Duration, overrides ParamOverrides) (queueID int64, err error) {
+	const sqlEnqueueChain = "SELECT timetable.notify_chain_start($1, $2, make_interval(secs => $3), $4)"
+	err = pge.ConfigDb.QueryRow(ctx, sqlEnqueueChain, chainID, pge.ClientName, delay.Seconds(), overrides.jsonb()).Scan(&queueID)
+	return
+}
+
+// ClaimQueuedChains marks due entries of the client in the run queue as picked up by this session and returns them.
+// Entries locked by another session are skipped, entries claimed by a previous session of the client are claimed again.
+// Claimed entries are deleted when the chain run is registered, see StartChainRun
+func (pge *PgEngine) ClaimQueuedChains(ctx context.Context) ([]QueuedRun, error) {
+	const sqlClaimQueuedChains = `UPDATE timetable.run_queue SET claimed_by = $2 WHERE queue_id IN (
+	SELECT queue_id FROM timetable.run_queue
+	WHERE client_name = $1 AND start_at <= now() AND claimed_by IS DISTINCT FROM $2
+	ORDER BY start_at FOR UPDATE SKIP LOCKED)
+RETURNING queue_id, chain_id, start_at, overrides`
+	rows, err := pge.ConfigDb.Query(ctx, sqlClaimQueuedChains, pge.ClientName, pge.Getsid())
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowToStructByName[QueuedRun])
+}
+
+// ReleaseQueuedChain puts the claimed entry back to the run queue, e.g. if the chain cannot be started right now
+func (pge *PgEngine) ReleaseQueuedChain(ctx context.Context, queueID int64) {
+	const sqlReleaseQueuedChain = `UPDATE timetable.run_queue SET claimed_by = NULL WHERE queue_id = $1`
+	if _, err := pge.ConfigDb.Exec(ctx, sqlReleaseQueuedChain, queueID); err != nil {
+		pge.l.WithError(err).WithField("queue_id", queueID).Error("Cannot release the run queue entry")
+	}
+}
+
+// DeleteQueuedChain removes the entry from the run queue, e.g. if the chain is not available anymore
+func (pge *PgEngine) DeleteQueuedChain(ctx context.Context, queueID int64) {
+	const sqlDeleteQueuedChain = `DELETE FROM timetable.run_queue WHERE queue_id = $1`
+	if _, err := pge.ConfigDb.Exec(ctx, sqlDeleteQueuedChain, queueID); err != nil {
+		pge.l.WithError(err).WithField("queue_id", queueID).Error("Cannot delete the run queue entry")
+	}
+}
+
+// NextQueuedStart returns the time left until the next unclaimed entry of the client in the run queue is due.
+// Returns false if the queue is empty
+func (pge *PgEngine) NextQueuedStart(ctx context.Context) (time.Duration, bool, error) {
+	const sqlNextQueuedStart = `SELECT EXTRACT(epoch FROM min(start_at) - now())::float8
+FROM timetable.run_queue WHERE client_name = $1 AND claimed_by IS DISTINCT FROM $2`
+	var secs *float64
+	if err := pge.ConfigDb.QueryRow(ctx, sqlNextQueuedStart, pge.ClientName, pge.Getsid()).Scan(&secs); err != nil || secs == nil {
+		return 0, false, err
+	}
+	return max(time.Duration(*secs*float64(time.Second)), 0), true, nil
+}
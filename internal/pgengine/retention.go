@@ -0,0 +1,115 @@
+package pgengine
+
+import (
+	"context"
+	"fmt"
+)
+
+// logTable describes the table cleaned up according to the retention settings
+type logTable struct {
+	name          string
+	key           string // timestamp column used to expire entries and to partition the table
+	partitionable bool
+}
+
+var logTables = []logTable{
+	{name: "log", key: "ts", partitionable: true},
+	{name: "execution_log", key: "finished", partitionable: true},
+	{name: "chain_run", key: "started_at"},
+}
+
+// partitionsAhead specifies the number of days to create partitions of log tables in advance
+const partitionsAhead = 3
+
+// RetentionEnabled returns true if any of retention settings is specified
+func (pge *PgEngine) RetentionEnabled() bool {
+	return pge.Retention.Days > 0 || pge.Retention.MaxRows > 0 || pge.Retention.Partition
+}
+
+// PartitionLogTables converts log tables to daily partitioned ones if not done yet and
+// creates partitions for the next days
+func (pge *PgEngine) PartitionLogTables(ctx context.Context) error {
+	for _, t := range logTables {
+		if !t.partitionable {
+			continue
+		}
+		var converted bool
+		err := pge.ConfigDb.QueryRow(ctx, "SELECT timetable.partition_log_table($1, $2)", t.name, t.key).Scan(&converted)
+		if err != nil {
+			return fmt.Errorf("cannot partition timetable.%s: %w", t.name, err)
+		}
+		if converted {
+			pge.l.WithField("table", t.name).Info("Log table converted to partitioned one")
+			continue
+		}
+		if _, err = pge.ConfigDb.Exec(ctx, "SELECT timetable.create_log_partitions($1, $2, $3)",
+			t.name, t.key, partitionsAhead); err != nil {
+			return fmt.Errorf("cannot create partitions of timetable.%s: %w", t.name, err)
+		}
+	}
+	return nil
+}
+
+// the retention lock is the transaction advisory lock held while log tables are maintained by one of the clients
+const sqlTryLockRetention = "SELECT pg_try_advisory_xact_lock(hashtext('pg_timetable_retention'))"
+
+// ApplyRetention removes entries of log tables older than retention days and above the maximum number of rows.
+// Expired partitions of partitioned tables are dropped as a whole. Log tables are shared by all clients, so
+// the retention is skipped if another client is applying it right now
+func (pge *PgEngine) ApplyRetention(ctx context.Context) error {
+	// the lock is held by the separate transaction, so the maintenance statements below
+	// are committed one by one and don't block writers of log tables till the end
+	tx, err := pge.ConfigDb.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(context.WithoutCancel(ctx)) }()
+	var locked bool
+	if err = tx.QueryRow(ctx, sqlTryLockRetention).Scan(&locked); err != nil {
+		return fmt.Errorf("cannot obtain retention lock: %w", err)
+	}
+	if !locked {
+		pge.l.Debug("Retention is being applied by another client, skipping")
+		return nil
+	}
+	return pge.applyRetention(ctx)
+}
+
+func (pge *PgEngine) applyRetention(ctx context.Context) error {
+	if pge.Retention.Partition {
+		if err := pge.PartitionLogTables(ctx); err != nil {
+			return err
+		}
+	}
+	for _, t := range logTables {
+		l := pge.l.WithField("table", t.name)
+		if pge.Retention.Days > 0 {
+			if pge.Retention.Partition && t.partitionable {
+				var dropped int
+				err := pge.ConfigDb.QueryRow(ctx, "SELECT timetable.drop_log_partitions($1, now() - make_interval(days => $2))",
+					t.name, pge.Retention.Days).Scan(&dropped)
+				if err != nil {
+					return fmt.Errorf("cannot drop partitions of timetable.%s: %w", t.name, err)
+				}
+				l.WithField("partitions", dropped).Debug("Expired partitions dropped")
+			}
+			// for partitioned tables only the default partition should have expired entries left
+			res, err := pge.ConfigDb.Exec(ctx, fmt.Sprintf("DELETE FROM timetable.%s WHERE %s < now() - make_interval(days => $1)",
+				t.name, t.key), pge.Retention.Days)
+			if err != nil {
+				return fmt.Errorf("cannot delete expired entries of timetable.%s: %w", t.name, err)
+			}
+			l.WithField("rows", res.RowsAffected()).Debug("Expired entries deleted")
+		}
+		if pge.Retention.MaxRows > 0 {
+			// the boundary is found by the backward scan of the key index, entries without timestamp are not counted
+			res, err := pge.ConfigDb.Exec(ctx, fmt.Sprintf(`DELETE FROM timetable.%[1]s
+WHERE %[2]s <= (SELECT %[2]s FROM timetable.%[1]s WHERE %[2]s IS NOT NULL ORDER BY %[2]s DESC OFFSET $1 LIMIT 1)`, t.name, t.key), pge.Retention.MaxRows)
+			if err != nil {
+				return fmt.Errorf("cannot delete excessive entries of timetable.%s: %w", t.name, err)
+			}
+			l.WithField("rows", res.RowsAffected()).Debug("Excessive entries deleted")
+		}
+	}
+	return nil
+}
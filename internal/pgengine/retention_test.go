@@ -0,0 +1,94 @@
+package pgengine_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/cybertec-postgresql/pg_timetable/internal/pgengine"
+	"github.com/pashagolub/pgxmock/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplyRetention(t *testing.T) {
+	initmockdb(t)
+	pge := pgengine.NewDB(mockPool, "pgengine_unit_test")
+	defer mockPool.Close()
+	ctx := context.Background()
+
+	assert.False(t, pge.RetentionEnabled())
+	expectLock := func(locked bool) {
+		mockPool.ExpectBegin()
+		mockPool.ExpectQuery("SELECT pg_try_advisory_xact_lock").
+			WillReturnRows(pgxmock.NewRows([]string{"locked"}).AddRow(locked))
+	}
+
+	t.Run("Check retention by days and rows", func(t *testing.T) {
+		pge.Retention.Days = 30
+		pge.Retention.MaxRows = 1000
+		assert.True(t, pge.RetentionEnabled())
+		expectLock(true)
+		for _, table := range []string{"log", "execution_log", "chain_run"} {
+			mockPool.ExpectExec("DELETE FROM timetable\\." + table + " WHERE .+ < now\\(\\)").
+				WithArgs(30).WillReturnResult(pgxmock.NewResult("DELETE", 1))
+			mockPool.ExpectExec("DELETE FROM timetable\\." + table + "\\s+WHERE .+ IS NOT NULL ORDER BY .+ DESC OFFSET").
+				WithArgs(1000).WillReturnResult(pgxmock.NewResult("DELETE", 1))
+		}
+		mockPool.ExpectRollback()
+		assert.NoError(t, pge.ApplyRetention(ctx))
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
+
+	t.Run("Check retention with partitioning", func(t *testing.T) {
+		pge.Retention.Days = 7
+		pge.Retention.MaxRows = 0
+		pge.Retention.Partition = true
+		expectLock(true)
+		mockPool.ExpectQuery("SELECT timetable\\.partition_log_table").WithArgs("log", "ts").
+			WillReturnRows(pgxmock.NewRows([]string{"converted"}).AddRow(true))
+		mockPool.ExpectQuery("SELECT timetable\\.partition_log_table").WithArgs("execution_log", "finished").
+			WillReturnRows(pgxmock.NewRows([]string{"converted"}).AddRow(false))
+		mockPool.ExpectExec("SELECT timetable\\.create_log_partitions").WithArgs("execution_log", "finished", 3).
+			WillReturnResult(pgxmock.NewResult("SELECT", 1))
+		for _, table := range []string{"log", "execution_log"} {
+			mockPool.ExpectQuery("SELECT timetable\\.drop_log_partitions").WithArgs(table, 7).
+				WillReturnRows(pgxmock.NewRows([]string{"dropped"}).AddRow(1))
+			mockPool.ExpectExec("DELETE FROM timetable\\." + table).
+				WithArgs(7).WillReturnResult(pgxmock.NewResult("DELETE", 0))
+		}
+		mockPool.ExpectExec("DELETE FROM timetable\\.chain_run").
+			WithArgs(7).WillReturnResult(pgxmock.NewResult("DELETE", 1))
+		mockPool.ExpectRollback()
+		assert.NoError(t, pge.ApplyRetention(ctx))
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
+
+	t.Run("Check retention is skipped if applied by another client", func(t *testing.T) {
+		expectLock(false)
+		mockPool.ExpectRollback()
+		assert.NoError(t, pge.ApplyRetention(ctx))
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
+
+	t.Run("Check retention if sql fails", func(t *testing.T) {
+		mockPool.ExpectBegin().WillReturnError(errors.New("error"))
+		assert.Error(t, pge.ApplyRetention(ctx))
+
+		mockPool.ExpectBegin()
+		mockPool.ExpectQuery("SELECT pg_try_advisory_xact_lock").WillReturnError(errors.New("error"))
+		mockPool.ExpectRollback()
+		assert.ErrorContains(t, pge.ApplyRetention(ctx), "cannot obtain retention lock")
+
+		expectLock(true)
+		mockPool.ExpectQuery("SELECT timetable\\.partition_log_table").WithArgs("log", "ts").WillReturnError(errors.New("error"))
+		mockPool.ExpectRollback()
+		assert.ErrorContains(t, pge.ApplyRetention(ctx), "cannot partition timetable.log")
+
+		pge.Retention.Partition = false
+		expectLock(true)
+		mockPool.ExpectExec("DELETE FROM timetable\\.log").WithArgs(7).WillReturnError(errors.New("error"))
+		mockPool.ExpectRollback()
+		assert.ErrorContains(t, pge.ApplyRetention(ctx), "cannot delete expired entries of timetable.log")
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,188 @@
+package pgengine
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	pgx "github.com/jackc/pgx/v5"
+)
+
+// SecretRefKey is the key of JSON object referencing the secret in task parameters, e.g. {"$secret": "smtp_password"}
+const SecretRefKey = "$secret"
+
+// SecretMask replaces resolved secret values in logs
+const SecretMask = "******"
+
+// SecretProvider resolves secret values by name. Found is false if provider has no such secret
+type SecretProvider interface {
+	GetSecret(ctx context.Context, name string) (value string, found bool, err error)
+}
+
+// EnvSecrets looks up secrets in environment variables, e.g. smtp_password => PGTT_SECRET_SMTP_PASSWORD
+type EnvSecrets struct {
+	Prefix string
+}
+
+func (s EnvSecrets) GetSecret(_ context.Context, name string) (string, bool, error) {
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToUpper(r)
+		}
+		return '_'
+	}, name)
+	value, ok := os.LookupEnv(s.Prefix + name)
+	return value, ok, nil
+}
+
+// FileSecrets reads secrets from files in the directory, e.g. Docker or Kubernetes secret mounts
+type FileSecrets struct {
+	Dir string
+}
+
+func (s FileSecrets) GetSecret(_ context.Context, name string) (string, bool, error) {
+	if s.Dir == "" || !filepath.IsLocal(name) {
+		return "", false, nil
+	}
+	value, err := os.ReadFile(filepath.Join(s.Dir, name))
+	if errors.Is(err, os.ErrNotExist) {
+		return "", false, nil
+	}
+	return strings.TrimRight(string(value), "\r\n"), err == nil, err
+}
+
+// TableSecrets decrypts secrets stored in timetable.secret with pgp_sym_encrypt of pgcrypto. Only the ciphertext
+// is fetched, values are decrypted by the client, so the key is never sent to the server and cannot get to its logs
+type TableSecrets struct {
+	DB  QueryRowIface
+	Key string
+}
+
+func (s TableSecrets) GetSecret(ctx context.Context, name string) (value string, found bool, err error) {
+	if s.Key == "" {
+		return "", false, nil
+	}
+	var data []byte
+	err = s.DB.QueryRow(ctx, "SELECT value FROM timetable.secret WHERE secret_name = $1", name).Scan(&data)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", false, nil
+	}
+	if err == nil {
+		value, err = decryptSecret(data, s.Key)
+	}
+	return value, err == nil, err
+}
+
+// decryptSecret decrypts the OpenPGP message encrypted with the passphrase, e.g. by pgp_sym_encrypt
+func decryptSecret(data []byte, key string) (string, error) {
+	prompted := false
+	md, err := openpgp.ReadMessage(bytes.NewReader(data), nil, func([]openpgp.Key, bool) ([]byte, error) {
+		if prompted {
+			return nil, errors.New("wrong secrets key")
+		}
+		prompted = true
+		return []byte(key), nil
+	}, nil)
+	if err != nil {
+		return "", err
+	}
+	value, err := io.ReadAll(md.UnverifiedBody) // the integrity of the message is checked at the end
+	if err != nil {
+		return "", err
+	}
+	return string(value), nil
+}
+
+// RegisterSecretProvider adds a custom secret provider with the highest priority
+func (pge *PgEngine) RegisterSecretProvider(p SecretProvider) {
+	pge.secretProviders = append([]SecretProvider{p}, pge.secretProviders...)
+}
+
+// defaultSecretProviders returns providers in order of lookup: environment, files, database table
+func (pge *PgEngine) defaultSecretProviders() []SecretProvider {
+	return []SecretProvider{
+		EnvSecrets{Prefix: pge.Secrets.EnvPrefix},
+		FileSecrets{Dir: pge.Secrets.Dir},
+		TableSecrets{DB: pge.ConfigDb, Key: pge.Secrets.Key},
+	}
+}
+
+// GetSecret returns the value of the secret from the first provider having it
+func (pge *PgEngine) GetSecret(ctx context.Context, name string) (string, error) {
+	for _, p := range pge.secretProviders {
+		value, found, err := p.GetSecret(ctx, name)
+		if err != nil {
+			return "", fmt.Errorf("cannot get secret %q: %w", name, err)
+		}
+		if found {
+			return value, nil
+		}
+	}
+	return "", fmt.Errorf("secret %q not found", name)
+}
+
+// ResolveSecrets replaces secret references in parameter values with secret values.
+// Resolved values are remembered in the task to be masked in logs
+func (pge *PgEngine) ResolveSecrets(ctx context.Context, task *ChainTask, paramValues []string) ([]string, error) {
+	task.Secrets = nil
+	resolved := make([]string, len(paramValues))
+	for i, val := range paramValues {
+		if !strings.Contains(val, SecretRefKey) {
+			resolved[i] = val
+			continue
+		}
+		var param any
+		d := json.NewDecoder(strings.NewReader(val))
+		d.UseNumber()
+		if err := d.Decode(&param); err != nil {
+			return nil, fmt.Errorf("failed to parse parameter %s: %w", val, err)
+		}
+		param, err := pge.resolveSecretRefs(ctx, task, param)
+		if err != nil {
+			return nil, err
+		}
+		var buf bytes.Buffer
+		e := json.NewEncoder(&buf)
+		e.SetEscapeHTML(false)
+		if err := e.Encode(param); err != nil {
+			return nil, err
+		}
+		resolved[i] = strings.TrimSuffix(buf.String(), "\n")
+	}
+	return resolved, nil
+}
+
+func (pge *PgEngine) resolveSecretRefs(ctx context.Context, task *ChainTask, v any) (any, error) {
+	var err error
+	switch v := v.(type) {
+	case []any:
+		for i := range v {
+			if v[i], err = pge.resolveSecretRefs(ctx, task, v[i]); err != nil {
+				return nil, err
+			}
+		}
+	case map[string]any:
+		if name, ok := v[SecretRefKey].(string); ok && len(v) == 1 {
+			value, err := pge.GetSecret(ctx, name)
+			if err != nil {
+				return nil, err
+			}
+			task.AddSecret(value)
+			return value, nil
+		}
+		for k := range v {
+			if v[k], err = pge.resolveSecretRefs(ctx, task, v[k]); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return v, nil
+}
@@ -0,0 +1,94 @@
+package pgengine_test
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cybertec-postgresql/pg_timetable/internal/pgengine"
+	"github.com/pashagolub/pgxmock/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type failingSecrets struct{}
+
+func (failingSecrets) GetSecret(context.Context, string) (string, bool, error) {
+	return "", false, errors.New("backend unavailable")
+}
+
+func TestSecretProviders(t *testing.T) {
+	ctx := context.Background()
+	t.Setenv("PGTT_SECRET_SMTP_PASSWORD", "from_env")
+	v, ok, err := pgengine.EnvSecrets{Prefix: "PGTT_SECRET_"}.GetSecret(ctx, "smtp-password")
+	assert.True(t, ok)
+	assert.NoError(t, err)
+	assert.Equal(t, "from_env", v)
+	_, ok, _ = pgengine.EnvSecrets{Prefix: "PGTT_SECRET_"}.GetSecret(ctx, "unknown")
+	assert.False(t, ok)
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "token"), []byte("from_file\n"), 0600))
+	v, ok, err = pgengine.FileSecrets{Dir: dir}.GetSecret(ctx, "token")
+	assert.True(t, ok)
+	assert.NoError(t, err)
+	assert.Equal(t, "from_file", v)
+	_, ok, _ = pgengine.FileSecrets{Dir: dir}.GetSecret(ctx, "unknown")
+	assert.False(t, ok)
+	_, ok, _ = pgengine.FileSecrets{Dir: dir}.GetSecret(ctx, "../token")
+	assert.False(t, ok, "Files outside of the directory should be ignored")
+	_, ok, _ = pgengine.FileSecrets{}.GetSecret(ctx, "token")
+	assert.False(t, ok, "Provider without directory should be disabled")
+
+	mockPool, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	_, ok, _ = pgengine.TableSecrets{DB: mockPool}.GetSecret(ctx, "token")
+	assert.False(t, ok, "Provider without key should be disabled")
+	// 'very_strong_one' encrypted with 'my_secrets_key' the way pgp_sym_encrypt does by default: AES128 with the MDC,
+	// iterated and salted SHA1 S2K without the separate session key, no compression
+	encrypted, _ := hex.DecodeString("8c0d040703027779d89d2f6c5c7960d240019245b7dd52a43a127c7bd14b562db898d70579db501f" +
+		"7d120e13f4587609c55ad49e1519de9439aae720a7ad4d97b173814bbd05fed8cd942218bbccd062df")
+	mockPool.ExpectQuery("SELECT value FROM timetable.secret").WithArgs("token").
+		WillReturnRows(pgxmock.NewRows([]string{"value"}).AddRow(encrypted))
+	v, ok, err = pgengine.TableSecrets{DB: mockPool, Key: "my_secrets_key"}.GetSecret(ctx, "token")
+	assert.True(t, ok)
+	assert.NoError(t, err)
+	assert.Equal(t, "very_strong_one", v, "decrypted by the client, the key is not sent to the server")
+	mockPool.ExpectQuery("SELECT value FROM timetable.secret").WithArgs("token").
+		WillReturnRows(pgxmock.NewRows([]string{"value"}).AddRow(encrypted))
+	_, ok, err = pgengine.TableSecrets{DB: mockPool, Key: "wrong"}.GetSecret(ctx, "token")
+	assert.False(t, ok)
+	assert.Error(t, err)
+	mockPool.ExpectQuery("SELECT value FROM timetable.secret").WithArgs("unknown").WillReturnRows(pgxmock.NewRows([]string{"value"}))
+	_, ok, err = pgengine.TableSecrets{DB: mockPool, Key: "key"}.GetSecret(ctx, "unknown")
+	assert.False(t, ok)
+	assert.NoError(t, err)
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestResolveSecrets(t *testing.T) {
+	ctx := context.Background()
+	t.Setenv("PGTT_SECRET_SMTP_PASSWORD", `pa"ss`)
+	pge := pgengine.NewDB(nil, "--log-database-level=none")
+	task := &pgengine.ChainTask{}
+
+	params := []string{`["plain"]`, `{"username": "user", "password": {"$secret": "smtp_password"}, "list": [{"$secret": "smtp_password"}, 1]}`}
+	resolved, err := pge.ResolveSecrets(ctx, task, params)
+	assert.NoError(t, err)
+	assert.Equal(t, `["plain"]`, resolved[0])
+	assert.JSONEq(t, `{"username": "user", "password": "pa\"ss", "list": ["pa\"ss", 1]}`, resolved[1])
+	assert.Equal(t, `{"list":["******",1],"password":"******","username":"user"}`, task.Redact(resolved[1]))
+	assert.Equal(t, "output with ******", task.Redact(`output with pa"ss`))
+
+	_, err = pge.ResolveSecrets(ctx, task, []string{`{"$secret": "unknown"}`})
+	assert.ErrorContains(t, err, `secret "unknown" not found`)
+	_, err = pge.ResolveSecrets(ctx, task, []string{`{"$secret": `})
+	assert.ErrorContains(t, err, "failed to parse parameter")
+
+	pge.RegisterSecretProvider(failingSecrets{})
+	_, err = pge.ResolveSecrets(ctx, task, params)
+	assert.ErrorContains(t, err, "backend unavailable")
+}
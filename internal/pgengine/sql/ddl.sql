@@ -28,7 +28,36 @@ COMMENT ON COLUMN timetable.chain.exclusive_execution IS
 COMMENT ON COLUMN timetable.chain.client_name IS
     'Only client with this name is allowed to run this chain, set to NULL to allow any client';    
 
-CREATE TYPE timetable.command_kind AS ENUM ('SQL', 'PROGRAM', 'BUILTIN');
+CREATE TYPE timetable.command_kind AS ENUM ('SQL', 'PROGRAM', 'BUILTIN', 'SHELL');
+
+CREATE TABLE timetable.connection (
+    connection_name TEXT    PRIMARY KEY,
+    connect_string  TEXT    NOT NULL,
+    credentials     TEXT    CHECK (credentials ~ '^(env:.+|file:.+|config(:.+)?|secret:.+)$'),
+    max_conns       INTEGER NOT NULL DEFAULT 4 CHECK (max_conns > 0),
+    idle_timeout    INTEGER NOT NULL DEFAULT 300 CHECK (idle_timeout >= 0)
+);
+
+COMMENT ON TABLE timetable.connection IS
+    'Holds named connections to remote databases used by tasks';
+COMMENT ON COLUMN timetable.connection.connect_string IS
+    'Connection string without password, e.g. postgres://user@host/db or host=... dbname=...';
+COMMENT ON COLUMN timetable.connection.credentials IS
+    'Password source resolved by the scheduler: env:name or file:name of the secrets namespace, config[:key] or secret:name. Connection string is used as is if NULL';
+COMMENT ON COLUMN timetable.connection.max_conns IS
+    'Maximum number of connections in the pool opened by each scheduler';
+COMMENT ON COLUMN timetable.connection.idle_timeout IS
+    'Close connections idle for more than the specified number of seconds, 0 means never';
+
+CREATE TABLE timetable.secret (
+    secret_name TEXT    PRIMARY KEY,
+    value       BYTEA   NOT NULL
+);
+
+COMMENT ON TABLE timetable.secret IS
+    'Holds secrets referenced by tasks, values are encrypted with pgp_sym_encrypt() from pgcrypto extension';
+COMMENT ON COLUMN timetable.secret.value IS
+    'Encrypted secret value, e.g. pgp_sym_encrypt(''password'', ''key''), where key is known only to the scheduler';
 
 CREATE TABLE timetable.task (
     task_id             BIGSERIAL               PRIMARY KEY,
@@ -42,7 +71,12 @@ CREATE TABLE timetable.task (
     ignore_error        BOOLEAN                 NOT NULL DEFAULT FALSE,
     autonomous          BOOLEAN                 NOT NULL DEFAULT FALSE,
     timeout             INTEGER                 DEFAULT 0,
-    live                BOOLEAN                 NOT NULL DEFAULT TRUE
+    live                BOOLEAN                 NOT NULL DEFAULT TRUE,
+    success_codes       INTEGER[],
+    warning_codes       INTEGER[],
+    capture_rows        INTEGER,
+    assertion           JSONB,
+    connection_name     TEXT                    REFERENCES timetable.connection(connection_name) ON UPDATE CASCADE
 );          
 
 COMMENT ON TABLE timetable.task IS
@@ -56,15 +90,25 @@ COMMENT ON COLUMN timetable.task.run_as IS
 COMMENT ON COLUMN timetable.task.ignore_error IS
     'Indicates whether a next task in a chain can be executed regardless of the success of the current one';
 COMMENT ON COLUMN timetable.task.kind IS
-    'Indicates whether "command" is SQL, built-in function or an external program';
+    'Indicates whether "command" is SQL, built-in function, an external program or a shell script';
 COMMENT ON COLUMN timetable.task.command IS
-    'Contains either an SQL command, or command string to be executed';
+    'Contains either an SQL command, command string to be executed or a shell script body';
 COMMENT ON COLUMN timetable.task.timeout IS
     'Abort any task within a chain that takes more than the specified number of milliseconds';
 COMMENT ON COLUMN timetable.task.autonomous IS
     'Specify if the task should be executed out of the chain transaction. Useful for VACUUM, CREATE DATABASE, CALL etc.';
 COMMENT ON COLUMN timetable.task.live IS
     'Indication that the task is ready to run, set to FALSE to skip execution';
+COMMENT ON COLUMN timetable.task.success_codes IS
+    'Exit codes of PROGRAM and SHELL tasks considered as success, only 0 if NULL';
+COMMENT ON COLUMN timetable.task.warning_codes IS
+    'Exit codes of PROGRAM and SHELL tasks considered as warning, the chain continues but the execution is flagged';
+COMMENT ON COLUMN timetable.task.capture_rows IS
+    'Maximum number of rows of SQL task result set stored as JSON, the result is not captured if NULL or 0';
+COMMENT ON COLUMN timetable.task.assertion IS
+    'Expected result of SQL task query, e.g. {"min_rows": 1, "max_rows": 10, "expr": "cnt < 100", "message": "...", "warning": false}';
+COMMENT ON COLUMN timetable.task.connection_name IS
+    'Name of the registered connection to execute the SQL task against, takes precedence over database_connection';
 
 -- parameter passing for a chain task
 CREATE TABLE timetable.parameter(
@@ -78,11 +122,14 @@ CREATE TABLE timetable.parameter(
 COMMENT ON TABLE timetable.parameter IS
     'Stores parameters passed as arguments to a chain task';
 
+CREATE TYPE timetable.session_role AS ENUM ('active', 'standby');
+
 CREATE UNLOGGED TABLE timetable.active_session(
     client_pid  BIGINT  NOT NULL,
     server_pid  BIGINT  NOT NULL,
     client_name TEXT    NOT NULL,
-    started_at  TIMESTAMPTZ DEFAULT now()
+    started_at  TIMESTAMPTZ DEFAULT now(),
+    role        timetable.session_role NOT NULL DEFAULT 'active'
 );
 
 COMMENT ON TABLE timetable.active_session IS
@@ -96,6 +143,11 @@ $$
 $$
 LANGUAGE sql;
 
+-- sequences are not owned by the columns, so dropping the "_old" partition of the partitioned table keeps them
+CREATE SEQUENCE timetable.log_id_seq;
+
+CREATE SEQUENCE timetable.execution_log_id_seq;
+
 CREATE TABLE timetable.log
 (
     ts              TIMESTAMPTZ         DEFAULT now(),
@@ -103,11 +155,17 @@ CREATE TABLE timetable.log
     log_level       timetable.log_type  NOT NULL,
     client_name     TEXT                DEFAULT timetable.get_client_name(pg_backend_pid()),
     message         TEXT,
-    message_data    jsonb
+    message_data    jsonb,
+    log_id          BIGINT              NOT NULL DEFAULT nextval('timetable.log_id_seq')
 );
 
 COMMENT ON TABLE timetable.log IS
     'Stores log entries of active sessions';
+COMMENT ON COLUMN timetable.log.log_id IS
+    'Identifies the entry, used to order entries with the same timestamp';
+
+CREATE INDEX log_ts_id_idx
+    ON timetable.log (ts, log_id);
 
 CREATE TABLE timetable.execution_log (
     chain_id        BIGINT,
@@ -122,7 +180,11 @@ CREATE TABLE timetable.execution_log (
     command         TEXT,
     output          TEXT,
     client_name     TEXT        NOT NULL,
-    params          TEXT
+    params          TEXT,
+    warning         BOOLEAN     NOT NULL DEFAULT FALSE,
+    result          JSONB,
+    run_id          BIGINT,
+    execution_id    BIGINT      NOT NULL DEFAULT nextval('timetable.execution_log_id_seq')
 );
 
 COMMENT ON TABLE timetable.execution_log IS
@@ -153,6 +215,14 @@ COMMENT ON COLUMN timetable.execution_log.client_name IS
     'Name of the client executing the task';
 COMMENT ON COLUMN timetable.execution_log.params IS
     'Contains parameters passed as arguments to a chain task';
+COMMENT ON COLUMN timetable.execution_log.warning IS
+    'Indicates whether the task finished with one of the warning exit codes';
+COMMENT ON COLUMN timetable.execution_log.result IS
+    'Contains captured result set of the SQL task as JSON array of row objects';
+COMMENT ON COLUMN timetable.execution_log.run_id IS
+    'Link to the chain run, see timetable.chain_run';
+COMMENT ON COLUMN timetable.execution_log.execution_id IS
+    'Identifies the entry, used to order entries with the same finish timestamp';
 
 CREATE INDEX execution_log_chain_id_finished_idx
     ON timetable.execution_log (chain_id, finished);
@@ -160,6 +230,152 @@ CREATE INDEX execution_log_chain_id_finished_idx
 CREATE INDEX execution_log_finished_brin_idx
     ON timetable.execution_log USING brin (finished);
 
+CREATE INDEX execution_log_finished_id_idx
+    ON timetable.execution_log (finished, execution_id);
+
+CREATE INDEX execution_log_run_id_idx
+    ON timetable.execution_log (run_id);
+
+CREATE TYPE timetable.run_status AS ENUM ('RUNNING', 'SUCCEEDED', 'FAILED', 'CANCELLED');
+
+CREATE TABLE timetable.chain_run (
+    run_id          BIGSERIAL   PRIMARY KEY,
+    chain_id        BIGINT      NOT NULL,
+    trigger         TEXT        NOT NULL,
+    scheduled_at    TIMESTAMPTZ,
+    started_at      TIMESTAMPTZ NOT NULL DEFAULT clock_timestamp(),
+    finished_at     TIMESTAMPTZ,
+    status          timetable.run_status NOT NULL DEFAULT 'RUNNING',
+    error           TEXT,
+    client_name     TEXT        NOT NULL,
+    overrides       JSONB
+);
+
+COMMENT ON TABLE timetable.chain_run IS
+    'Stores history of chain runs, one row per run';
+COMMENT ON COLUMN timetable.chain_run.chain_id IS
+    'Link to the chain executed';
+COMMENT ON COLUMN timetable.chain_run.trigger IS
+    'What started the run: cron, interval, reboot or manual';
+COMMENT ON COLUMN timetable.chain_run.scheduled_at IS
+    'Time the run was scheduled for, e.g. the cron minute or the moment of notify_chain_start() call plus delay';
+COMMENT ON COLUMN timetable.chain_run.status IS
+    'RUNNING until the chain is finished, SUCCEEDED, FAILED or CANCELLED after';
+COMMENT ON COLUMN timetable.chain_run.error IS
+    'Error message of the failed task or the reason of the cancellation';
+COMMENT ON COLUMN timetable.chain_run.client_name IS
+    'Name of the client executing the chain';
+COMMENT ON COLUMN timetable.chain_run.overrides IS
+    'Task parameter overrides passed at chain start, keyed by task name or ID';
+
+CREATE INDEX chain_run_chain_id_started_at_idx
+    ON timetable.chain_run (chain_id, started_at);
+
+CREATE INDEX chain_run_started_at_idx
+    ON timetable.chain_run (started_at);
+
+CREATE OR REPLACE FUNCTION timetable.log_partitions(tbl TEXT)
+RETURNS TABLE (partition_name TEXT, upper_bound TIMESTAMPTZ) AS $$
+    SELECT c.relname::text, (regexp_match(pg_get_expr(c.relpartbound, c.oid), 'TO \(''([^'']+)''\)'))[1]::timestamptz
+    FROM pg_catalog.pg_inherits i JOIN pg_catalog.pg_class c ON c.oid = i.inhrelid
+    WHERE i.inhparent = format('timetable.%I', tbl)::regclass
+$$ LANGUAGE sql STABLE;
+
+COMMENT ON FUNCTION timetable.log_partitions(TEXT) IS
+    'Returns partitions of the partitioned log table with their upper bounds, NULL for the default partition';
+
+CREATE OR REPLACE FUNCTION timetable.create_log_partitions(tbl TEXT, key_column TEXT, days_ahead INTEGER DEFAULT 3)
+RETURNS INTEGER AS $$
+DECLARE
+    lo      TIMESTAMPTZ;
+    part    TEXT;
+    created INTEGER := 0;
+BEGIN
+    SELECT greatest(max(upper_bound), current_date::timestamptz) INTO lo FROM timetable.log_partitions(tbl);
+    WHILE lo <= (current_date + days_ahead)::timestamptz LOOP
+        part := tbl || to_char(lo, '"_p"YYYYMMDD');
+        EXECUTE format('CREATE TABLE timetable.%I (LIKE timetable.%I INCLUDING DEFAULTS INCLUDING CONSTRAINTS)', part, tbl);
+        -- move rows stored in the default partition in the meantime, otherwise attaching fails
+        EXECUTE format('WITH moved AS (DELETE FROM timetable.%I WHERE %I >= $1 AND %I < $2 RETURNING *) INSERT INTO timetable.%I SELECT * FROM moved',
+            tbl || '_default', key_column, key_column, part) USING lo, lo + interval '1 day';
+        EXECUTE format('ALTER TABLE timetable.%I ATTACH PARTITION timetable.%I FOR VALUES FROM (%L) TO (%L)',
+            tbl, part, lo, lo + interval '1 day');
+        lo := lo + interval '1 day';
+        created := created + 1;
+    END LOOP;
+    RETURN created;
+END;
+$$ LANGUAGE plpgsql;
+
+COMMENT ON FUNCTION timetable.create_log_partitions(TEXT, TEXT, INTEGER) IS
+    'Creates daily partitions of the partitioned log table up to the specified number of days ahead';
+
+CREATE OR REPLACE FUNCTION timetable.drop_log_partitions(tbl TEXT, older_than TIMESTAMPTZ)
+RETURNS INTEGER AS $$
+DECLARE
+    part    TEXT;
+    dropped INTEGER := 0;
+BEGIN
+    FOR part IN SELECT partition_name FROM timetable.log_partitions(tbl) WHERE upper_bound <= older_than LOOP
+        EXECUTE format('DROP TABLE timetable.%I', part);
+        dropped := dropped + 1;
+    END LOOP;
+    RETURN dropped;
+END;
+$$ LANGUAGE plpgsql;
+
+COMMENT ON FUNCTION timetable.drop_log_partitions(TEXT, TIMESTAMPTZ) IS
+    'Drops partitions of the partitioned log table containing only entries older than specified';
+
+CREATE OR REPLACE FUNCTION timetable.partition_log_table(tbl TEXT, key_column TEXT)
+RETURNS BOOLEAN AS $$
+BEGIN
+    IF (SELECT relkind FROM pg_catalog.pg_class WHERE oid = format('timetable.%I', tbl)::regclass) = 'p' THEN
+        RETURN FALSE;
+    END IF;
+    EXECUTE format('LOCK TABLE timetable.%I IN ACCESS EXCLUSIVE MODE', tbl);
+    -- partition key cannot be NULL, keep such entries in the partition with existing data
+    EXECUTE format('UPDATE timetable.%I SET %I = ''-infinity'' WHERE %I IS NULL', tbl, key_column, key_column);
+    EXECUTE format('ALTER TABLE timetable.%I ALTER COLUMN %I SET NOT NULL', tbl, key_column);
+    EXECUTE format('ALTER TABLE timetable.%I RENAME TO %I', tbl, tbl || '_old');
+    EXECUTE format('CREATE TABLE timetable.%I (LIKE timetable.%I INCLUDING ALL) PARTITION BY RANGE (%I)', tbl, tbl || '_old', key_column);
+    EXECUTE format('COMMENT ON TABLE timetable.%I IS %L', tbl, obj_description(format('timetable.%I', tbl || '_old')::regclass, 'pg_class'));
+    -- existing data becomes the partition holding everything before tomorrow
+    EXECUTE format('ALTER TABLE timetable.%I ATTACH PARTITION timetable.%I FOR VALUES FROM (MINVALUE) TO (%L)',
+        tbl, tbl || '_old', (current_date + 1)::timestamptz);
+    EXECUTE format('CREATE TABLE timetable.%I PARTITION OF timetable.%I DEFAULT', tbl || '_default', tbl);
+    PERFORM timetable.create_log_partitions(tbl, key_column);
+    RETURN TRUE;
+END;
+$$ LANGUAGE plpgsql;
+
+COMMENT ON FUNCTION timetable.partition_log_table(TEXT, TEXT) IS
+    'Converts the log table to the table partitioned by day on the key column, existing entries are kept in the "_old" partition';
+
+CREATE TABLE timetable.run_queue (
+    queue_id        BIGSERIAL   PRIMARY KEY,
+    chain_id        BIGINT      NOT NULL REFERENCES timetable.chain(chain_id) ON UPDATE CASCADE ON DELETE CASCADE,
+    client_name     TEXT        NOT NULL,
+    start_at        TIMESTAMPTZ NOT NULL DEFAULT now(),
+    enqueued_at     TIMESTAMPTZ NOT NULL DEFAULT clock_timestamp(),
+    overrides       JSONB,
+    claimed_by      INTEGER
+);
+
+COMMENT ON TABLE timetable.run_queue IS
+    'Stores chain starts requested by notify_chain_start() or REST API until the client picks them up';
+COMMENT ON COLUMN timetable.run_queue.client_name IS
+    'Name of the client should start the chain';
+COMMENT ON COLUMN timetable.run_queue.start_at IS
+    'Time the chain should be started at, e.g. the moment of the request plus delay';
+COMMENT ON COLUMN timetable.run_queue.overrides IS
+    'Task parameter overrides for this run only, keyed by task name or ID';
+COMMENT ON COLUMN timetable.run_queue.claimed_by IS
+    'Session ID of the client picked up the entry, the entry is deleted once the chain run is registered';
+
+CREATE INDEX run_queue_client_name_start_at_idx
+    ON timetable.run_queue (client_name, start_at);
+
 CREATE UNLOGGED TABLE timetable.active_chain(
     chain_id    BIGINT  NOT NULL,
     client_name TEXT    NOT NULL,
@@ -169,6 +385,39 @@ CREATE UNLOGGED TABLE timetable.active_chain(
 COMMENT ON TABLE timetable.active_chain IS
     'Stores information about active chains within session';
 
+CREATE TABLE timetable.chain_claim(
+    cluster_name    TEXT        NOT NULL,
+    chain_id        BIGINT      NOT NULL REFERENCES timetable.chain(chain_id) ON UPDATE CASCADE ON DELETE CASCADE,
+    fire_at         TIMESTAMPTZ NOT NULL,
+    client_name     TEXT        NOT NULL,
+    claimed_at      TIMESTAMPTZ NOT NULL DEFAULT clock_timestamp(),
+    PRIMARY KEY (cluster_name, chain_id)
+);
+
+COMMENT ON TABLE timetable.chain_claim IS
+    'Stores the last scheduled run of chains without client name claimed by a client of the cluster';
+COMMENT ON COLUMN timetable.chain_claim.fire_at IS
+    'Scheduled time of the claimed run, e.g. the cron minute or the start of the interval';
+
+CREATE OR REPLACE FUNCTION timetable.claim_chain(cluster TEXT, chain BIGINT, scheduled_at TIMESTAMPTZ, worker_name TEXT)
+RETURNS BOOLEAN AS
+$CODE$
+    WITH claimed AS (
+        INSERT INTO timetable.chain_claim AS cc (cluster_name, chain_id, fire_at, client_name)
+        VALUES (cluster, chain, scheduled_at, worker_name)
+        ON CONFLICT ON CONSTRAINT chain_claim_pkey DO UPDATE
+        SET fire_at = EXCLUDED.fire_at, client_name = EXCLUDED.client_name, claimed_at = clock_timestamp()
+        WHERE cc.fire_at < EXCLUDED.fire_at
+        RETURNING 1
+    )
+    SELECT count(*) > 0 FROM claimed
+$CODE$
+STRICT
+LANGUAGE sql;
+
+COMMENT ON FUNCTION timetable.claim_chain(TEXT, BIGINT, TIMESTAMPTZ, TEXT) IS
+    'Claims the run of the chain scheduled at the given time for the client. Returns FALSE if the run is claimed by another client of the cluster already';
+
 CREATE OR REPLACE FUNCTION timetable.try_lock_client_name(worker_pid BIGINT, worker_name TEXT)
 RETURNS bool AS
 $CODE$
@@ -188,7 +437,12 @@ BEGIN
     DELETE 
         FROM timetable.active_chain 
         WHERE client_name NOT IN (
-            SELECT client_name FROM timetable.active_session
+            SELECT client_name FROM timetable.active_session WHERE role = 'active'
+        );
+    UPDATE timetable.chain_run
+        SET status = 'CANCELLED', finished_at = clock_timestamp(), error = 'client session terminated'
+        WHERE status = 'RUNNING' AND client_name NOT IN (
+            SELECT client_name FROM timetable.active_session WHERE role = 'active'
         );
     -- check if there any active sessions with the client name but different client pid
     PERFORM 1
@@ -196,6 +450,7 @@ BEGIN
         WHERE
             s.client_pid <> worker_pid
             AND s.client_name = worker_name
+            AND s.role = 'active'
         LIMIT 1;
     IF FOUND THEN
         RAISE NOTICE 'Another client is already connected to server with name: %', worker_name;
@@ -209,3 +464,15 @@ $CODE$
 STRICT
 LANGUAGE plpgsql;
 
+CREATE OR REPLACE FUNCTION timetable.register_standby(worker_pid BIGINT, worker_name TEXT)
+RETURNS void AS
+$CODE$
+    INSERT INTO timetable.active_session(client_pid, client_name, server_pid, role)
+        VALUES (worker_pid, worker_name, pg_backend_pid(), 'standby');
+$CODE$
+STRICT
+LANGUAGE sql;
+
+COMMENT ON FUNCTION timetable.register_standby(BIGINT, TEXT) IS
+    'Registers the session of the client waiting to take over the client name once the active client fails';
+
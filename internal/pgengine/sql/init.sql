@@ -30,4 +30,19 @@ VALUES
     (14, '00721 Add more job control functions'),
     (15, '00733 Add params column to timetable.execution_log table'),
     (16, '00792 Add ability to enable and disable tasks'),
-    (17, '00797 Add indexes to timetable.execution_log');
+    (17, '00797 Add indexes to timetable.execution_log'),
+    (18, '00800 Add SHELL command kind'),
+    (19, '00801 Add success and warning exit codes for PROGRAM tasks'),
+    (20, '00802 Add result capture for SQL tasks'),
+    (21, '00803 Add assertions for SQL tasks'),
+    (22, '00804 Add named connections registry'),
+    (23, '00805 Add secrets storage'),
+    (24, '00806 Add chain run history'),
+    (25, '00807 Add log tables partitioning'),
+    (26, '00808 Add standby sessions'),
+    (27, '00809 Add chain claims for clusters'),
+    (28, '00810 Add run queue'),
+    (29, '00811 Add task parameter overrides'),
+    (30, '00812 Add run queue claims'),
+    (31, '00813 Add log retention indexes'),
+    (32, '00814 Add log entry IDs');
@@ -48,23 +48,34 @@ $$ LANGUAGE SQL;
 
 COMMENT ON FUNCTION timetable.add_job IS 'Add one-task chain (aka job) to the system';
 
--- notify_chain_start() will send notification to the worker to start the chain
+-- notify_chain_start() will add the chain to the run queue and notify the worker to start the chain
 CREATE OR REPLACE FUNCTION timetable.notify_chain_start(
     chain_id    BIGINT, 
     worker_name TEXT,
-    start_delay INTERVAL DEFAULT NULL
-) RETURNS void AS $$
-    SELECT pg_notify(
+    start_delay INTERVAL DEFAULT NULL,
+    overrides   JSONB DEFAULT NULL
+) RETURNS BIGINT AS $$
+DECLARE
+    v_queue_id BIGINT;
+BEGIN
+    IF jsonb_typeof(overrides) <> 'object' THEN
+        RAISE EXCEPTION 'overrides must be a JSON object keyed by task name or ID';
+    END IF;
+    INSERT INTO timetable.run_queue (chain_id, client_name, start_at, overrides)
+        VALUES (notify_chain_start.chain_id, worker_name, now() + COALESCE(start_delay, INTERVAL '0'), overrides)
+        RETURNING queue_id INTO v_queue_id;
+    PERFORM pg_notify(
         worker_name, 
-        format('{"ConfigID": %s, "Command": "START", "Ts": %s, "Delay": %s}', 
-            chain_id, 
-            EXTRACT(epoch FROM clock_timestamp())::bigint,
-            COALESCE(EXTRACT(epoch FROM start_delay)::bigint, 0)
+        format('{"ConfigID": %s, "Command": "START", "Ts": %s}', 
+            notify_chain_start.chain_id, 
+            EXTRACT(epoch FROM clock_timestamp())::bigint
         )
-    )
-$$ LANGUAGE SQL;
+    );
+    RETURN v_queue_id;
+END;
+$$ LANGUAGE plpgsql;
 
-COMMENT ON FUNCTION timetable.notify_chain_start IS 'Send notification to the worker to start the chain';
+COMMENT ON FUNCTION timetable.notify_chain_start IS 'Add the chain to the run queue and notify the worker to start it with optional task parameter overrides. Returns the ID of the queue entry';
 
 -- notify_chain_stop() will send notification to the worker to stop the chain
 CREATE OR REPLACE FUNCTION timetable.notify_chain_stop(
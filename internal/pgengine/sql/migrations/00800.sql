@@ -0,0 +1,6 @@
+ALTER TYPE timetable.command_kind ADD VALUE IF NOT EXISTS 'SHELL';
+
+COMMENT ON COLUMN timetable.task.kind IS
+    'Indicates whether "command" is SQL, built-in function, an external program or a shell script';
+COMMENT ON COLUMN timetable.task.command IS
+    'Contains either an SQL command, command string to be executed or a shell script body';
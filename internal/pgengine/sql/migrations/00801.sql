@@ -0,0 +1,14 @@
+ALTER TABLE timetable.task
+    ADD COLUMN success_codes INTEGER[],
+    ADD COLUMN warning_codes INTEGER[];
+
+COMMENT ON COLUMN timetable.task.success_codes IS
+    'Exit codes of PROGRAM and SHELL tasks considered as success, only 0 if NULL';
+COMMENT ON COLUMN timetable.task.warning_codes IS
+    'Exit codes of PROGRAM and SHELL tasks considered as warning, the chain continues but the execution is flagged';
+
+ALTER TABLE timetable.execution_log
+    ADD COLUMN warning BOOLEAN NOT NULL DEFAULT FALSE;
+
+COMMENT ON COLUMN timetable.execution_log.warning IS
+    'Indicates whether the task finished with one of the warning exit codes';
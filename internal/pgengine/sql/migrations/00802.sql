@@ -0,0 +1,11 @@
+ALTER TABLE timetable.task
+    ADD COLUMN capture_rows INTEGER;
+
+COMMENT ON COLUMN timetable.task.capture_rows IS
+    'Maximum number of rows of SQL task result set stored as JSON, the result is not captured if NULL or 0';
+
+ALTER TABLE timetable.execution_log
+    ADD COLUMN result JSONB;
+
+COMMENT ON COLUMN timetable.execution_log.result IS
+    'Contains captured result set of the SQL task as JSON array of row objects';
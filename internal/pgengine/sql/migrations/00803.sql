@@ -0,0 +1,5 @@
+ALTER TABLE timetable.task
+    ADD COLUMN assertion JSONB;
+
+COMMENT ON COLUMN timetable.task.assertion IS
+    'Expected result of SQL task query, e.g. {"min_rows": 1, "max_rows": 10, "expr": "cnt < 100", "message": "...", "warning": false}';
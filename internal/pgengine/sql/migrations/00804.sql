@@ -0,0 +1,24 @@
+CREATE TABLE timetable.connection (
+    connection_name TEXT    PRIMARY KEY,
+    connect_string  TEXT    NOT NULL,
+    credentials     TEXT    CHECK (credentials ~ '^(env:.+|file:.+|config(:.+)?)$'),
+    max_conns       INTEGER NOT NULL DEFAULT 4 CHECK (max_conns > 0),
+    idle_timeout    INTEGER NOT NULL DEFAULT 300 CHECK (idle_timeout >= 0)
+);
+
+COMMENT ON TABLE timetable.connection IS
+    'Holds named connections to remote databases used by tasks';
+COMMENT ON COLUMN timetable.connection.connect_string IS
+    'Connection string without password, e.g. postgres://user@host/db or host=... dbname=...';
+COMMENT ON COLUMN timetable.connection.credentials IS
+    'Password source resolved by the scheduler: env:VAR, file:/path or config[:key]. Connection string is used as is if NULL';
+COMMENT ON COLUMN timetable.connection.max_conns IS
+    'Maximum number of connections in the pool opened by each scheduler';
+COMMENT ON COLUMN timetable.connection.idle_timeout IS
+    'Close connections idle for more than the specified number of seconds, 0 means never';
+
+ALTER TABLE timetable.task
+    ADD COLUMN connection_name TEXT REFERENCES timetable.connection(connection_name) ON UPDATE CASCADE;
+
+COMMENT ON COLUMN timetable.task.connection_name IS
+    'Name of the registered connection to execute the SQL task against, takes precedence over database_connection';
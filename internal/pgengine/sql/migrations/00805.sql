@@ -0,0 +1,16 @@
+CREATE TABLE timetable.secret (
+    secret_name TEXT    PRIMARY KEY,
+    value       BYTEA   NOT NULL
+);
+
+COMMENT ON TABLE timetable.secret IS
+    'Holds secrets referenced by tasks, values are encrypted with pgp_sym_encrypt() from pgcrypto extension';
+COMMENT ON COLUMN timetable.secret.value IS
+    'Encrypted secret value, e.g. pgp_sym_encrypt(''password'', ''key''), where key is known only to the scheduler';
+
+ALTER TABLE timetable.connection
+    DROP CONSTRAINT IF EXISTS connection_credentials_check,
+    ADD CONSTRAINT connection_credentials_check CHECK (credentials ~ '^(env:.+|file:.+|config(:.+)?|secret:.+)$');
+
+COMMENT ON COLUMN timetable.connection.credentials IS
+    'Password source resolved by the scheduler: env:name or file:name of the secrets namespace, config[:key] or secret:name. Connection string is used as is if NULL';
@@ -0,0 +1,85 @@
+CREATE TYPE timetable.run_status AS ENUM ('RUNNING', 'SUCCEEDED', 'FAILED', 'CANCELLED');
+
+CREATE TABLE timetable.chain_run (
+    run_id          BIGSERIAL   PRIMARY KEY,
+    chain_id        BIGINT      NOT NULL,
+    trigger         TEXT        NOT NULL,
+    scheduled_at    TIMESTAMPTZ,
+    started_at      TIMESTAMPTZ NOT NULL DEFAULT clock_timestamp(),
+    finished_at     TIMESTAMPTZ,
+    status          timetable.run_status NOT NULL DEFAULT 'RUNNING',
+    error           TEXT,
+    client_name     TEXT        NOT NULL
+);
+
+COMMENT ON TABLE timetable.chain_run IS
+    'Stores history of chain runs, one row per run';
+COMMENT ON COLUMN timetable.chain_run.chain_id IS
+    'Link to the chain executed';
+COMMENT ON COLUMN timetable.chain_run.trigger IS
+    'What started the run: cron, interval, reboot or manual';
+COMMENT ON COLUMN timetable.chain_run.scheduled_at IS
+    'Time the run was scheduled for, e.g. the cron minute or the moment of notify_chain_start() call plus delay';
+COMMENT ON COLUMN timetable.chain_run.status IS
+    'RUNNING until the chain is finished, SUCCEEDED, FAILED or CANCELLED after';
+COMMENT ON COLUMN timetable.chain_run.error IS
+    'Error message of the failed task or the reason of the cancellation';
+COMMENT ON COLUMN timetable.chain_run.client_name IS
+    'Name of the client executing the chain';
+
+CREATE INDEX chain_run_chain_id_started_at_idx
+    ON timetable.chain_run (chain_id, started_at);
+
+ALTER TABLE timetable.execution_log
+    ADD COLUMN run_id BIGINT;
+
+COMMENT ON COLUMN timetable.execution_log.run_id IS
+    'Link to the chain run, see timetable.chain_run';
+
+CREATE INDEX execution_log_run_id_idx
+    ON timetable.execution_log (run_id);
+
+CREATE OR REPLACE FUNCTION timetable.try_lock_client_name(worker_pid BIGINT, worker_name TEXT)
+RETURNS bool AS
+$CODE$
+BEGIN
+    IF pg_is_in_recovery() THEN
+        RAISE NOTICE 'Cannot obtain lock on a replica. Please, use the primary node';
+        RETURN FALSE;
+    END IF;
+    -- remove disconnected sessions
+    DELETE
+        FROM timetable.active_session
+        WHERE server_pid NOT IN (
+            SELECT pid
+            FROM pg_catalog.pg_stat_activity
+            WHERE application_name = 'pg_timetable'
+        );
+    DELETE 
+        FROM timetable.active_chain 
+        WHERE client_name NOT IN (
+            SELECT client_name FROM timetable.active_session
+        );
+    UPDATE timetable.chain_run
+        SET status = 'CANCELLED', finished_at = clock_timestamp(), error = 'client session terminated'
+        WHERE status = 'RUNNING' AND client_name NOT IN (
+            SELECT client_name FROM timetable.active_session
+        );
+    -- check if there any active sessions with the client name but different client pid
+    PERFORM 1
+        FROM timetable.active_session s
+        WHERE
+            s.client_pid <> worker_pid
+            AND s.client_name = worker_name
+        LIMIT 1;
+    IF FOUND THEN
+        RAISE NOTICE 'Another client is already connected to server with name: %', worker_name;
+        RETURN FALSE;
+    END IF;
+    -- insert current session information
+    INSERT INTO timetable.active_session(client_pid, client_name, server_pid) VALUES (worker_pid, worker_name, pg_backend_pid());
+    RETURN TRUE;
+END;
+$CODE$
+STRICT
+LANGUAGE plpgsql;
@@ -0,0 +1,80 @@
+CREATE INDEX log_ts_brin_idx
+    ON timetable.log USING brin (ts);
+
+CREATE OR REPLACE FUNCTION timetable.log_partitions(tbl TEXT)
+RETURNS TABLE (partition_name TEXT, upper_bound TIMESTAMPTZ) AS $$
+    SELECT c.relname::text, (regexp_match(pg_get_expr(c.relpartbound, c.oid), 'TO \(''([^'']+)''\)'))[1]::timestamptz
+    FROM pg_catalog.pg_inherits i JOIN pg_catalog.pg_class c ON c.oid = i.inhrelid
+    WHERE i.inhparent = format('timetable.%I', tbl)::regclass
+$$ LANGUAGE sql STABLE;
+
+COMMENT ON FUNCTION timetable.log_partitions(TEXT) IS
+    'Returns partitions of the partitioned log table with their upper bounds, NULL for the default partition';
+
+CREATE OR REPLACE FUNCTION timetable.create_log_partitions(tbl TEXT, key_column TEXT, days_ahead INTEGER DEFAULT 3)
+RETURNS INTEGER AS $$
+DECLARE
+    lo      TIMESTAMPTZ;
+    part    TEXT;
+    created INTEGER := 0;
+BEGIN
+    SELECT greatest(max(upper_bound), current_date::timestamptz) INTO lo FROM timetable.log_partitions(tbl);
+    WHILE lo <= (current_date + days_ahead)::timestamptz LOOP
+        part := tbl || to_char(lo, '"_p"YYYYMMDD');
+        EXECUTE format('CREATE TABLE timetable.%I (LIKE timetable.%I INCLUDING DEFAULTS INCLUDING CONSTRAINTS)', part, tbl);
+        -- move rows stored in the default partition in the meantime, otherwise attaching fails
+        EXECUTE format('WITH moved AS (DELETE FROM timetable.%I WHERE %I >= $1 AND %I < $2 RETURNING *) INSERT INTO timetable.%I SELECT * FROM moved',
+            tbl || '_default', key_column, key_column, part) USING lo, lo + interval '1 day';
+        EXECUTE format('ALTER TABLE timetable.%I ATTACH PARTITION timetable.%I FOR VALUES FROM (%L) TO (%L)',
+            tbl, part, lo, lo + interval '1 day');
+        lo := lo + interval '1 day';
+        created := created + 1;
+    END LOOP;
+    RETURN created;
+END;
+$$ LANGUAGE plpgsql;
+
+COMMENT ON FUNCTION timetable.create_log_partitions(TEXT, TEXT, INTEGER) IS
+    'Creates daily partitions of the partitioned log table up to the specified number of days ahead';
+
+CREATE OR REPLACE FUNCTION timetable.drop_log_partitions(tbl TEXT, older_than TIMESTAMPTZ)
+RETURNS INTEGER AS $$
+DECLARE
+    part    TEXT;
+    dropped INTEGER := 0;
+BEGIN
+    FOR part IN SELECT partition_name FROM timetable.log_partitions(tbl) WHERE upper_bound <= older_than LOOP
+        EXECUTE format('DROP TABLE timetable.%I', part);
+        dropped := dropped + 1;
+    END LOOP;
+    RETURN dropped;
+END;
+$$ LANGUAGE plpgsql;
+
+COMMENT ON FUNCTION timetable.drop_log_partitions(TEXT, TIMESTAMPTZ) IS
+    'Drops partitions of the partitioned log table containing only entries older than specified';
+
+CREATE OR REPLACE FUNCTION timetable.partition_log_table(tbl TEXT, key_column TEXT)
+RETURNS BOOLEAN AS $$
+BEGIN
+    IF (SELECT relkind FROM pg_catalog.pg_class WHERE oid = format('timetable.%I', tbl)::regclass) = 'p' THEN
+        RETURN FALSE;
+    END IF;
+    EXECUTE format('LOCK TABLE timetable.%I IN ACCESS EXCLUSIVE MODE', tbl);
+    -- partition key cannot be NULL, keep such entries in the partition with existing data
+    EXECUTE format('UPDATE timetable.%I SET %I = ''-infinity'' WHERE %I IS NULL', tbl, key_column, key_column);
+    EXECUTE format('ALTER TABLE timetable.%I ALTER COLUMN %I SET NOT NULL', tbl, key_column);
+    EXECUTE format('ALTER TABLE timetable.%I RENAME TO %I', tbl, tbl || '_old');
+    EXECUTE format('CREATE TABLE timetable.%I (LIKE timetable.%I INCLUDING ALL) PARTITION BY RANGE (%I)', tbl, tbl || '_old', key_column);
+    EXECUTE format('COMMENT ON TABLE timetable.%I IS %L', tbl, obj_description(format('timetable.%I', tbl || '_old')::regclass, 'pg_class'));
+    -- existing data becomes the partition holding everything before tomorrow
+    EXECUTE format('ALTER TABLE timetable.%I ATTACH PARTITION timetable.%I FOR VALUES FROM (MINVALUE) TO (%L)',
+        tbl, tbl || '_old', (current_date + 1)::timestamptz);
+    EXECUTE format('CREATE TABLE timetable.%I PARTITION OF timetable.%I DEFAULT', tbl || '_default', tbl);
+    PERFORM timetable.create_log_partitions(tbl, key_column);
+    RETURN TRUE;
+END;
+$$ LANGUAGE plpgsql;
+
+COMMENT ON FUNCTION timetable.partition_log_table(TEXT, TEXT) IS
+    'Converts the log table to the table partitioned by day on the key column, existing entries are kept in the "_old" partition';
@@ -0,0 +1,32 @@
+CREATE TABLE timetable.chain_claim(
+    cluster_name    TEXT        NOT NULL,
+    chain_id        BIGINT      NOT NULL REFERENCES timetable.chain(chain_id) ON UPDATE CASCADE ON DELETE CASCADE,
+    fire_at         TIMESTAMPTZ NOT NULL,
+    client_name     TEXT        NOT NULL,
+    claimed_at      TIMESTAMPTZ NOT NULL DEFAULT clock_timestamp(),
+    PRIMARY KEY (cluster_name, chain_id)
+);
+
+COMMENT ON TABLE timetable.chain_claim IS
+    'Stores the last scheduled run of chains without client name claimed by a client of the cluster';
+COMMENT ON COLUMN timetable.chain_claim.fire_at IS
+    'Scheduled time of the claimed run, e.g. the cron minute or the start of the interval';
+
+CREATE OR REPLACE FUNCTION timetable.claim_chain(cluster TEXT, chain BIGINT, scheduled_at TIMESTAMPTZ, worker_name TEXT)
+RETURNS BOOLEAN AS
+$CODE$
+    WITH claimed AS (
+        INSERT INTO timetable.chain_claim AS cc (cluster_name, chain_id, fire_at, client_name)
+        VALUES (cluster, chain, scheduled_at, worker_name)
+        ON CONFLICT ON CONSTRAINT chain_claim_pkey DO UPDATE
+        SET fire_at = EXCLUDED.fire_at, client_name = EXCLUDED.client_name, claimed_at = clock_timestamp()
+        WHERE cc.fire_at < EXCLUDED.fire_at
+        RETURNING 1
+    )
+    SELECT count(*) > 0 FROM claimed
+$CODE$
+STRICT
+LANGUAGE sql;
+
+COMMENT ON FUNCTION timetable.claim_chain(TEXT, BIGINT, TIMESTAMPTZ, TEXT) IS
+    'Claims the run of the chain scheduled at the given time for the client. Returns FALSE if the run is claimed by another client of the cluster already';
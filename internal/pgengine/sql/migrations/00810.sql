@@ -0,0 +1,44 @@
+CREATE TABLE timetable.run_queue (
+    queue_id        BIGSERIAL   PRIMARY KEY,
+    chain_id        BIGINT      NOT NULL REFERENCES timetable.chain(chain_id) ON UPDATE CASCADE ON DELETE CASCADE,
+    client_name     TEXT        NOT NULL,
+    start_at        TIMESTAMPTZ NOT NULL DEFAULT now(),
+    enqueued_at     TIMESTAMPTZ NOT NULL DEFAULT clock_timestamp()
+);
+
+COMMENT ON TABLE timetable.run_queue IS
+    'Stores chain starts requested by notify_chain_start() or REST API until the client picks them up';
+COMMENT ON COLUMN timetable.run_queue.client_name IS
+    'Name of the client should start the chain';
+COMMENT ON COLUMN timetable.run_queue.start_at IS
+    'Time the chain should be started at, e.g. the moment of the request plus delay';
+
+CREATE INDEX run_queue_client_name_start_at_idx
+    ON timetable.run_queue (client_name, start_at);
+
+DROP FUNCTION timetable.notify_chain_start(BIGINT, TEXT, INTERVAL);
+
+-- notify_chain_start() will add the chain to the run queue and notify the worker to start the chain
+CREATE OR REPLACE FUNCTION timetable.notify_chain_start(
+    chain_id    BIGINT, 
+    worker_name TEXT,
+    start_delay INTERVAL DEFAULT NULL
+) RETURNS BIGINT AS $$
+DECLARE
+    v_queue_id BIGINT;
+BEGIN
+    INSERT INTO timetable.run_queue (chain_id, client_name, start_at)
+        VALUES (notify_chain_start.chain_id, worker_name, now() + COALESCE(start_delay, INTERVAL '0'))
+        RETURNING queue_id INTO v_queue_id;
+    PERFORM pg_notify(
+        worker_name, 
+        format('{"ConfigID": %s, "Command": "START", "Ts": %s}', 
+            notify_chain_start.chain_id, 
+            EXTRACT(epoch FROM clock_timestamp())::bigint
+        )
+    );
+    RETURN v_queue_id;
+END;
+$$ LANGUAGE plpgsql;
+
+COMMENT ON FUNCTION timetable.notify_chain_start IS 'Add the chain to the run queue and notify the worker to start it. Returns the ID of the queue entry';
@@ -0,0 +1,40 @@
+ALTER TABLE timetable.run_queue ADD COLUMN overrides JSONB;
+
+COMMENT ON COLUMN timetable.run_queue.overrides IS
+    'Task parameter overrides for this run only, keyed by task name or ID';
+
+ALTER TABLE timetable.chain_run ADD COLUMN overrides JSONB;
+
+COMMENT ON COLUMN timetable.chain_run.overrides IS
+    'Task parameter overrides passed at chain start, keyed by task name or ID';
+
+DROP FUNCTION timetable.notify_chain_start(BIGINT, TEXT, INTERVAL);
+
+-- notify_chain_start() will add the chain to the run queue and notify the worker to start the chain
+CREATE OR REPLACE FUNCTION timetable.notify_chain_start(
+    chain_id    BIGINT, 
+    worker_name TEXT,
+    start_delay INTERVAL DEFAULT NULL,
+    overrides   JSONB DEFAULT NULL
+) RETURNS BIGINT AS $$
+DECLARE
+    v_queue_id BIGINT;
+BEGIN
+    IF jsonb_typeof(overrides) <> 'object' THEN
+        RAISE EXCEPTION 'overrides must be a JSON object keyed by task name or ID';
+    END IF;
+    INSERT INTO timetable.run_queue (chain_id, client_name, start_at, overrides)
+        VALUES (notify_chain_start.chain_id, worker_name, now() + COALESCE(start_delay, INTERVAL '0'), overrides)
+        RETURNING queue_id INTO v_queue_id;
+    PERFORM pg_notify(
+        worker_name, 
+        format('{"ConfigID": %s, "Command": "START", "Ts": %s}', 
+            notify_chain_start.chain_id, 
+            EXTRACT(epoch FROM clock_timestamp())::bigint
+        )
+    );
+    RETURN v_queue_id;
+END;
+$$ LANGUAGE plpgsql;
+
+COMMENT ON FUNCTION timetable.notify_chain_start IS 'Add the chain to the run queue and notify the worker to start it with optional task parameter overrides. Returns the ID of the queue entry';
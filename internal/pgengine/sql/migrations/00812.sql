@@ -0,0 +1,4 @@
+ALTER TABLE timetable.run_queue ADD COLUMN claimed_by INTEGER;
+
+COMMENT ON COLUMN timetable.run_queue.claimed_by IS
+    'Session ID of the client picked up the entry, the entry is deleted once the chain run is registered';
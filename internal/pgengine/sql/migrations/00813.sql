@@ -0,0 +1,10 @@
+DROP INDEX IF EXISTS timetable.log_ts_brin_idx;
+
+CREATE INDEX log_ts_idx
+    ON timetable.log (ts);
+
+CREATE INDEX execution_log_finished_idx
+    ON timetable.execution_log (finished);
+
+CREATE INDEX chain_run_started_at_idx
+    ON timetable.chain_run (started_at);
@@ -0,0 +1,41 @@
+-- sequences are not owned by the columns, so dropping the "_old" partition of the partitioned table keeps them
+CREATE SEQUENCE timetable.log_id_seq;
+
+CREATE SEQUENCE timetable.execution_log_id_seq;
+
+ALTER TABLE timetable.log
+    ADD COLUMN log_id BIGINT NOT NULL DEFAULT nextval('timetable.log_id_seq');
+
+ALTER TABLE timetable.execution_log
+    ADD COLUMN execution_id BIGINT NOT NULL DEFAULT nextval('timetable.execution_log_id_seq');
+
+COMMENT ON COLUMN timetable.log.log_id IS
+    'Identifies the entry, used to order entries with the same timestamp';
+COMMENT ON COLUMN timetable.execution_log.execution_id IS
+    'Identifies the entry, used to order entries with the same finish timestamp';
+
+-- timestamp indexes are replaced by the ones below, their names differ if the table is partitioned already
+DO $$
+DECLARE
+    idx TEXT;
+BEGIN
+    FOR idx IN
+        SELECT i.indexrelid::regclass::text
+        FROM pg_catalog.pg_index i
+            JOIN pg_catalog.pg_class c ON c.oid = i.indexrelid
+            JOIN pg_catalog.pg_am am ON am.oid = c.relam
+            JOIN pg_catalog.pg_attribute a ON a.attrelid = i.indrelid AND a.attnum = i.indkey[0]
+        WHERE am.amname = 'btree' AND i.indnatts = 1 AND (
+            i.indrelid = 'timetable.log'::regclass AND a.attname = 'ts' OR
+            i.indrelid = 'timetable.execution_log'::regclass AND a.attname = 'finished')
+    LOOP
+        EXECUTE 'DROP INDEX ' || idx;
+    END LOOP;
+END;
+$$;
+
+CREATE INDEX log_ts_id_idx
+    ON timetable.log (ts, log_id);
+
+CREATE INDEX execution_log_finished_id_idx
+    ON timetable.execution_log (finished, execution_id);
@@ -0,0 +1,147 @@
+package pgengine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"time"
+
+	pgx "github.com/jackc/pgx/v5"
+	retry "github.com/sethvargo/go-retry"
+)
+
+// the leader lock is the session advisory lock with the keys of the application and the client name
+const sqlTryLockLeader = "SELECT pg_try_advisory_lock(hashtext('pg_timetable'), hashtext($1))"
+
+// the leader lock is listed in pg_locks with the keys in classid and objid
+const sqlLeaderLockHeld = `SELECT EXISTS (SELECT FROM pg_catalog.pg_locks WHERE locktype = 'advisory'
+	AND pid = pg_backend_pid() AND granted AND objsubid = 2
+	AND classid = hashtext('pg_timetable')::oid AND objid = hashtext($1)::oid)`
+
+// ErrLeadershipLost is returned if the lease session is closed or does not hold the leader lock anymore
+var ErrLeadershipLost = errors.New("leader lock is not held by the lease session")
+
+// standbyPollInterval specifies how often the standby client tries to obtain the leader lock
+const standbyPollInterval = time.Second
+
+// standbyKeepalives make the server notice the lost client in seconds, so the leader lock
+// and sessions of the failed active client are released for the standby one
+var standbyKeepalives = map[string]string{
+	"tcp_keepalives_idle":     "5",
+	"tcp_keepalives_interval": "1",
+	"tcp_keepalives_count":    "5",
+}
+
+// getLeaseConnConfig returns configuration of the dedicated session holding the leader lock
+func (pge *PgEngine) getLeaseConnConfig() (*pgx.ConnConfig, error) {
+	connConfig, err := pgx.ParseConfig(pge.ConnStr)
+	if err != nil {
+		return nil, err
+	}
+	connConfig.RuntimeParams["application_name"] = "pg_timetable"
+	maps.Copy(connConfig.RuntimeParams, standbyKeepalives)
+	return connConfig, nil
+}
+
+// ObtainLeadership opens the lease session and waits until the client becomes the active one
+// for the client name. Used in the standby mode only
+func (pge *PgEngine) ObtainLeadership(ctx context.Context) error {
+	connConfig, err := pge.getLeaseConnConfig()
+	if err != nil {
+		return err
+	}
+	return retry.Do(ctx, backoff, func(ctx context.Context) error {
+		conn, err := pgx.ConnectConfig(ctx, connConfig)
+		if err == nil {
+			pge.lease = conn
+			if err = pge.WaitForLeadership(ctx); err == nil {
+				return nil
+			}
+			_ = conn.Close(context.Background())
+			pge.lease = nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		pge.l.WithError(err).Error("Lease session failed")
+		pge.l.Info("Sleeping before reconnecting...")
+		return retry.RetryableError(err)
+	})
+}
+
+// WaitForLeadership blocks until the lease session obtains the leader lock of the client name.
+// While waiting the session is registered in timetable.active_session with the standby role
+func (pge *PgEngine) WaitForLeadership(ctx context.Context) error {
+	l := pge.l.WithField("client", pge.ClientName)
+	var leader, standby bool
+	for {
+		if err := pge.lease.QueryRow(ctx, sqlTryLockLeader, pge.ClientName).Scan(&leader); err != nil {
+			return err
+		}
+		if leader {
+			break
+		}
+		if !standby {
+			if err := pge.registerStandby(ctx); err != nil {
+				return err
+			}
+			standby = true
+			l.Info("Another client is active, waiting as standby...")
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(standbyPollInterval):
+		}
+	}
+	if standby {
+		// active sessions are registered by try_lock_client_name() for every connection of the pool
+		if _, err := pge.lease.Exec(ctx, "DELETE FROM timetable.active_session WHERE server_pid = pg_backend_pid()"); err != nil {
+			return err
+		}
+	}
+	l.Info("Leadership obtained, client is active")
+	return nil
+}
+
+// registerStandby adds the lease session to timetable.active_session if the schema is already available
+func (pge *PgEngine) registerStandby(ctx context.Context) error {
+	var procoid int
+	sql := "SELECT COALESCE(to_regproc('timetable.register_standby')::int4, 0)"
+	if err := pge.lease.QueryRow(ctx, sql).Scan(&procoid); err != nil || procoid == 0 {
+		return err
+	}
+	_, err := pge.lease.Exec(ctx, "SELECT timetable.register_standby($1, $2)", pge.Getsid(), pge.ClientName)
+	return err
+}
+
+// CheckLeadership returns ErrLeadershipLost if the lease session of the active client is closed or does not
+// hold the leader lock anymore, another client in the standby mode may have taken over the client name already.
+// Other errors, e.g. timeouts, mean the leadership cannot be checked at the moment
+func (pge *PgEngine) CheckLeadership(ctx context.Context) error {
+	if pge.lease == nil {
+		return nil
+	}
+	pge.leaseMutex.Lock()
+	defer pge.leaseMutex.Unlock()
+	var held bool
+	err := pge.lease.QueryRow(ctx, sqlLeaderLockHeld, pge.ClientName).Scan(&held)
+	switch {
+	case err == nil && !held:
+		err = ErrLeadershipLost
+	case err != nil && pge.leaseClosed():
+		err = fmt.Errorf("%w: %w", ErrLeadershipLost, err)
+	default:
+		return err
+	}
+	pge.leadershipLost.Store(true)
+	return err
+}
+
+// leaseClosed returns true if the lease session is closed, e.g. after the network failure, so the server
+// releases the leader lock as soon as it notices that
+func (pge *PgEngine) leaseClosed() bool {
+	conn, ok := pge.lease.(interface{ IsClosed() bool })
+	return ok && conn.IsClosed()
+}
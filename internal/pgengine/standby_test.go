@@ -0,0 +1,100 @@
+package pgengine
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/pashagolub/pgxmock/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWaitForLeadership(t *testing.T) {
+	mock, err := pgxmock.NewConn()
+	require.NoError(t, err)
+	pge := NewDB(nil, "-c", "standby_unit_test", "--standby")
+	pge.lease = mock
+	ctx := context.Background()
+
+	t.Run("Check leadership obtained right away", func(t *testing.T) {
+		mock.ExpectQuery("SELECT pg_try_advisory_lock").WithArgs(pge.ClientName).
+			WillReturnRows(pgxmock.NewRows([]string{"locked"}).AddRow(true))
+		assert.NoError(t, pge.WaitForLeadership(ctx))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Check standby takes over", func(t *testing.T) {
+		mock.ExpectQuery("SELECT pg_try_advisory_lock").WithArgs(pge.ClientName).
+			WillReturnRows(pgxmock.NewRows([]string{"locked"}).AddRow(false))
+		mock.ExpectQuery("to_regproc").
+			WillReturnRows(pgxmock.NewRows([]string{"oid"}).AddRow(42))
+		mock.ExpectExec("SELECT timetable\\.register_standby").WithArgs(pge.Getsid(), pge.ClientName).
+			WillReturnResult(pgxmock.NewResult("SELECT", 1))
+		mock.ExpectQuery("SELECT pg_try_advisory_lock").WithArgs(pge.ClientName).
+			WillReturnRows(pgxmock.NewRows([]string{"locked"}).AddRow(true))
+		mock.ExpectExec("DELETE FROM timetable\\.active_session").
+			WillReturnResult(pgxmock.NewResult("DELETE", 1))
+		assert.NoError(t, pge.WaitForLeadership(ctx))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Check standby without schema", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+		defer cancel()
+		mock.ExpectQuery("SELECT pg_try_advisory_lock").WithArgs(pge.ClientName).
+			WillReturnRows(pgxmock.NewRows([]string{"locked"}).AddRow(false))
+		mock.ExpectQuery("to_regproc").
+			WillReturnRows(pgxmock.NewRows([]string{"oid"}).AddRow(0))
+		assert.ErrorIs(t, pge.WaitForLeadership(ctx), context.DeadlineExceeded)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Check lease session fails", func(t *testing.T) {
+		mock.ExpectQuery("SELECT pg_try_advisory_lock").WithArgs(pge.ClientName).
+			WillReturnError(errors.New("connection lost"))
+		assert.Error(t, pge.WaitForLeadership(ctx))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestCheckLeadership(t *testing.T) {
+	pge := NewDB(nil, "-c", "standby_unit_test")
+	assert.NoError(t, pge.CheckLeadership(context.Background()), "no lease outside of standby mode")
+
+	mock, err := pgxmock.NewConn()
+	require.NoError(t, err)
+	pge.lease = mock
+	expectLock := func() *pgxmock.ExpectedQuery {
+		return mock.ExpectQuery("FROM pg_catalog\\.pg_locks").WithArgs("standby_unit_test")
+	}
+	expectLock().WillReturnRows(pgxmock.NewRows([]string{"exists"}).AddRow(true))
+	assert.NoError(t, pge.CheckLeadership(context.Background()))
+	assert.False(t, pge.leadershipLost.Load())
+
+	expectLock().WillReturnError(errors.New("timeout"))
+	err = pge.CheckLeadership(context.Background())
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, ErrLeadershipLost, "leadership is unknown while the lease session is open")
+	assert.False(t, pge.leadershipLost.Load())
+
+	expectLock().WillReturnRows(pgxmock.NewRows([]string{"exists"}).AddRow(false))
+	assert.ErrorIs(t, pge.CheckLeadership(context.Background()), ErrLeadershipLost)
+	assert.True(t, pge.leadershipLost.Load())
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	pge.leadershipLost.Store(false)
+	pge.lease = closedConn{mock}
+	expectLock().WillReturnError(errors.New("conn closed"))
+	assert.ErrorIs(t, pge.CheckLeadership(context.Background()), ErrLeadershipLost, "closed lease session releases the lock")
+	assert.True(t, pge.leadershipLost.Load())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// closedConn is the lease session closed by pgx, e.g. after the network failure
+type closedConn struct {
+	pgxmock.PgxConnIface
+}
+
+func (closedConn) IsClosed() bool { return true }
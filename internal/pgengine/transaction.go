@@ -1,6 +1,7 @@
 package pgengine
 
 import (
+	"cmp"
 	"context"
 	"encoding/json"
 	"errors"
@@ -25,12 +26,10 @@ WHERE pid = pg_backend_pid() AND virtualxid IS NOT NULL`).Scan(&vxid)
 	return
 }
 
-// CommitTransaction commits transaction and log error in the case of error
-func (pge *PgEngine) CommitTransaction(ctx context.Context, tx pgx.Tx) {
-	err := tx.Commit(ctx)
-	if err != nil {
-		log.GetLogger(ctx).WithError(err).Error("Application cannot commit after job finished")
-	}
+// CommitTransaction commits transaction and returns error, e.g. deferred constraint violation,
+// so the chain run is not considered successful
+func (pge *PgEngine) CommitTransaction(ctx context.Context, tx pgx.Tx) error {
+	return tx.Commit(ctx)
 }
 
 // RollbackTransaction rollbacks transaction and log error in the case of error
@@ -100,11 +99,16 @@ func (pge *PgEngine) ExecStandaloneTask(ctx context.Context, connf func() (PgxCo
 	return pge.ExecuteSQLCommand(ctx, conn, task, paramValues)
 }
 
-// ExecRemoteSQLTask executes task against remote connection
+// ExecRemoteSQLTask executes task against remote connection. Registered connections take precedence over connection strings
 func (pge *PgEngine) ExecRemoteSQLTask(ctx context.Context, task *ChainTask, paramValues []string) error {
 	log.GetLogger(ctx).Info("Switching to remote task mode")
 	return pge.ExecStandaloneTask(ctx,
-		func() (PgxConnIface, error) { return pge.GetRemoteDBConnection(ctx, task.ConnectString) },
+		func() (PgxConnIface, error) {
+			if task.ConnectionName != "" {
+				return pge.GetNamedDBConnection(ctx, task.ConnectionName)
+			}
+			return pge.GetRemoteDBConnection(ctx, task.ConnectString)
+		},
 		task, paramValues)
 }
 
@@ -124,10 +128,12 @@ func (pge *PgEngine) ExecuteSQLCommand(ctx context.Context, executor executor, t
 		return errors.New("SQL command cannot be empty")
 	}
 	if len(paramValues) == 0 { //mimic empty param
-		ct, e := executor.Exec(ctx, task.Command)
-		pge.LogTaskExecution(context.Background(), task, errCodes[e != nil], ct.String(), "")
+		out, e := pge.execSQLCommand(ctx, executor, task)
+		pge.LogTaskExecution(context.Background(), task, errCodes[e != nil], out, "")
 		return e
 	}
+	var warning bool
+	defer func() { task.Warning = warning }()
 	for _, val := range paramValues {
 		if val == "" {
 			continue
@@ -137,13 +143,138 @@ func (pge *PgEngine) ExecuteSQLCommand(ctx context.Context, executor executor, t
 			err = errors.Join(err, fmt.Errorf("failed to parse parameter %s: %w", val, parseErr))
 			return
 		}
-		ct, e := executor.Exec(ctx, task.Command, params...)
+		out, e := pge.execSQLCommand(ctx, executor, task, params...)
 		err = errors.Join(err, e)
-		pge.LogTaskExecution(context.Background(), task, errCodes[e != nil], ct.String(), val)
+		warning = warning || task.Warning
+		pge.LogTaskExecution(context.Background(), task, errCodes[e != nil], out, val)
 	}
 	return
 }
 
+// execSQLCommand returns the command tag of the executed statement. If the task captures its result,
+// at most task.CaptureRows rows are stored in task.Result as JSON array of row objects
+func (pge *PgEngine) execSQLCommand(ctx context.Context, executor executor, task *ChainTask, args ...any) (string, error) {
+	task.Result = nil
+	task.Warning = false
+	if task.Assertion != nil {
+		return pge.execAssertion(ctx, executor, task, args...)
+	}
+	if task.CaptureRows <= 0 {
+		ct, err := executor.Exec(ctx, task.Command, args...)
+		return ct.String(), err
+	}
+	rows, err := executor.Query(ctx, task.Command, args...)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+	result := make([]map[string]any, 0)
+	for rows.Next() {
+		if len(result) >= task.CaptureRows {
+			continue // drain the rest to get the proper command tag
+		}
+		row, err := pgx.RowToMap(rows)
+		if err != nil {
+			return "", err
+		}
+		result = append(result, row)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return "", err
+	}
+	if task.Result, err = json.Marshal(result); err != nil {
+		return "", fmt.Errorf("failed to marshal result: %w", err)
+	}
+	return rows.CommandTag().String(), nil
+}
+
+// execAssertion runs the task query wrapped into the row count and expression check. If the assertion
+// does not hold, the failure message is returned as an error or as an output with task.Warning set
+func (pge *PgEngine) execAssertion(ctx context.Context, executor executor, task *ChainTask, args ...any) (string, error) {
+	a := task.Assertion
+	if err := a.Validate(); err != nil {
+		return "", err
+	}
+	expr := cmp.Or(strings.TrimSpace(a.Expr), "TRUE")
+	query := strings.TrimRight(strings.TrimSpace(task.Command), "; \t\n")
+	var total, failed int
+	var err error
+	if task.CaptureRows > 0 {
+		total, failed, err = pge.captureAssertion(ctx, executor, task, expr, query, args...)
+	} else {
+		var rows pgx.Rows
+		rows, err = executor.Query(ctx, fmt.Sprintf("SELECT count(*), count(*) FILTER (WHERE (%s) IS NOT TRUE) FROM (%s) AS result",
+			expr, query), args...)
+		if err == nil {
+			_, err = pgx.ForEachRow(rows, []any{&total, &failed}, func() error { return nil })
+		}
+	}
+	if err != nil {
+		return "", err
+	}
+	var msg string
+	switch {
+	case a.MinRows != nil && total < *a.MinRows:
+		msg = fmt.Sprintf("expected at least %d rows, got %d", *a.MinRows, total)
+	case a.MaxRows != nil && total > *a.MaxRows:
+		msg = fmt.Sprintf("expected at most %d rows, got %d", *a.MaxRows, total)
+	case failed > 0:
+		msg = fmt.Sprintf("%d of %d rows do not satisfy %s", failed, total, a.Expr)
+	default:
+		return fmt.Sprintf("ASSERT %d", total), nil
+	}
+	msg = "assertion failed: " + msg
+	if a.Message != "" {
+		msg = a.Message + " (" + msg + ")"
+	}
+	if a.Warning {
+		task.Warning = true
+		return msg, nil
+	}
+	return msg, errors.New(msg)
+}
+
+// captureAssertion returns row counts of the assertion calculated by window functions along with the rows of the query,
+// so the query is executed once and at most task.CaptureRows rows are stored in task.Result
+func (pge *PgEngine) captureAssertion(ctx context.Context, executor executor, task *ChainTask, expr, query string, args ...any) (total, failed int, err error) {
+	rows, err := executor.Query(ctx, fmt.Sprintf(
+		"SELECT count(*) OVER (), count(*) FILTER (WHERE (%s) IS NOT TRUE) OVER (), result.* FROM (%s) AS result",
+		expr, query), args...)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer rows.Close()
+	result := make([]map[string]any, 0)
+	for rows.Next() {
+		values, err := rows.Values()
+		if err != nil {
+			return 0, 0, err
+		}
+		cnt, ok := values[0].(int64)
+		fcnt, fok := values[1].(int64)
+		if !ok || !fok {
+			return 0, 0, errors.New("failed to read assertion row counts")
+		}
+		total, failed = int(cnt), int(fcnt)
+		if len(result) >= task.CaptureRows {
+			continue // drain the rest
+		}
+		row := make(map[string]any, len(values)-2)
+		for i, fd := range rows.FieldDescriptions()[2:] {
+			row[fd.Name] = values[i+2]
+		}
+		result = append(result, row)
+	}
+	if err = rows.Err(); err != nil {
+		return 0, 0, err
+	}
+	if task.Result, err = json.Marshal(result); err != nil {
+		return 0, 0, fmt.Errorf("failed to marshal result: %w", err)
+	}
+	return total, failed, nil
+}
+
 // GetLocalDBConnection acquires a connection from a local pool and returns it
 func (pge *PgEngine) GetLocalDBConnection(ctx context.Context) (conn PgxConnIface, err error) {
 	c, err := pge.ConfigDb.Acquire(ctx)
@@ -60,7 +60,7 @@ func TestMustTransaction(t *testing.T) {
 	mockPool.ExpectCommit().WillReturnError(errors.New("error"))
 	tx, err := mockPool.Begin(context.Background())
 	assert.NoError(t, err)
-	pge.CommitTransaction(ctx, tx)
+	assert.Error(t, pge.CommitTransaction(ctx, tx))
 
 	mockPool.ExpectBegin()
 	mockPool.ExpectRollback().WillReturnError(errors.New("error"))
@@ -200,6 +200,92 @@ func TestExecuteSQLCommand(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestExecuteSQLCommandCaptureResult(t *testing.T) {
+	initmockdb(t)
+	pge := pgengine.NewDB(mockPool, "pgengine_unit_test", "--log-database-level=none")
+	task := &pgengine.ChainTask{Command: "SELECT id, name FROM foo", CaptureRows: 2}
+
+	mockPool.ExpectQuery("SELECT id, name FROM foo").WillReturnRows(
+		pgxmock.NewRows([]string{"id", "name"}).AddRow(1, "a").AddRow(2, "b").AddRow(3, "c"))
+	assert.NoError(t, pge.ExecuteSQLCommand(ctx, mockPool, task, []string{}))
+	assert.JSONEq(t, `[{"id": 1, "name": "a"}, {"id": 2, "name": "b"}]`, string(task.Result), "Result must be capped")
+
+	mockPool.ExpectQuery("SELECT id, name FROM foo").WithArgs("bar").WillReturnRows(pgxmock.NewRows([]string{"id", "name"}))
+	assert.NoError(t, pge.ExecuteSQLCommand(ctx, mockPool, task, []string{`["bar"]`}))
+	assert.JSONEq(t, `[]`, string(task.Result))
+
+	mockPool.ExpectQuery("SELECT id, name FROM foo").WillReturnError(errors.New("query failed"))
+	assert.Error(t, pge.ExecuteSQLCommand(ctx, mockPool, task, []string{}))
+	assert.Nil(t, task.Result)
+
+	mockPool.ExpectQuery("SELECT id, name FROM foo").WillReturnRows(
+		pgxmock.NewRows([]string{"id", "name"}).AddRow(1, "a").RowError(0, errors.New("row failed")))
+	assert.Error(t, pge.ExecuteSQLCommand(ctx, mockPool, task, []string{}))
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestExecuteSQLCommandAssertion(t *testing.T) {
+	initmockdb(t)
+	pge := pgengine.NewDB(mockPool, "pgengine_unit_test", "--log-database-level=none")
+	one, two := 1, 2
+	assertRows := func(total, failed int) *pgxmock.Rows {
+		return pgxmock.NewRows([]string{"count", "count"}).AddRow(total, failed)
+	}
+
+	task := &pgengine.ChainTask{Command: "SELECT cnt FROM foo;", Assertion: &pgengine.Assertion{MinRows: &one, MaxRows: &two, Expr: "cnt < 100"}}
+	mockPool.ExpectQuery(`FILTER \(WHERE \(cnt < 100\) IS NOT TRUE\) FROM \(SELECT cnt FROM foo\) AS result`).
+		WillReturnRows(assertRows(2, 0))
+	assert.NoError(t, pge.ExecuteSQLCommand(ctx, mockPool, task, []string{}))
+
+	mockPool.ExpectQuery("SELECT count").WithArgs("bar").WillReturnRows(assertRows(0, 0))
+	assert.ErrorContains(t, pge.ExecuteSQLCommand(ctx, mockPool, task, []string{`["bar"]`}), "expected at least 1 rows, got 0")
+
+	mockPool.ExpectQuery("SELECT count").WillReturnRows(assertRows(3, 0))
+	assert.ErrorContains(t, pge.ExecuteSQLCommand(ctx, mockPool, task, []string{}), "expected at most 2 rows, got 3")
+
+	mockPool.ExpectQuery("SELECT count").WillReturnRows(assertRows(2, 1))
+	assert.ErrorContains(t, pge.ExecuteSQLCommand(ctx, mockPool, task, []string{}), "1 of 2 rows do not satisfy cnt < 100")
+
+	task.Assertion.Warning = true
+	task.Assertion.Message = "Too many sessions"
+	mockPool.ExpectQuery("SELECT count").WillReturnRows(assertRows(2, 1))
+	assert.NoError(t, pge.ExecuteSQLCommand(ctx, mockPool, task, []string{}))
+	assert.True(t, task.Warning, "Warning assertion should flag the task")
+
+	mockPool.ExpectQuery("SELECT count").WillReturnRows(assertRows(2, 0))
+	assert.NoError(t, pge.ExecuteSQLCommand(ctx, mockPool, task, []string{}))
+	assert.False(t, task.Warning, "Warning should be reset for every execution")
+
+	mockPool.ExpectQuery("SELECT count").WillReturnError(errors.New("syntax error"))
+	assert.Error(t, pge.ExecuteSQLCommand(ctx, mockPool, task, []string{}))
+
+	task.CaptureRows = 1
+	mockPool.ExpectQuery(`SELECT count\(\*\) OVER \(\), count\(\*\) FILTER \(WHERE \(cnt < 100\) IS NOT TRUE\) OVER \(\), result\.\* FROM \(SELECT cnt FROM foo\) AS result`).
+		WillReturnRows(pgxmock.NewRows([]string{"count", "count", "cnt"}).AddRow(int64(2), int64(1), 10).AddRow(int64(2), int64(1), 200))
+	assert.NoError(t, pge.ExecuteSQLCommand(ctx, mockPool, task, []string{}))
+	assert.True(t, task.Warning, "Assertion should be checked when rows are captured")
+	assert.JSONEq(t, `[{"cnt": 10}]`, string(task.Result), "Rows should be captured up to capture_rows")
+
+	mockPool.ExpectQuery("OVER").WillReturnRows(pgxmock.NewRows([]string{"count", "count", "cnt"}))
+	assert.NoError(t, pge.ExecuteSQLCommand(ctx, mockPool, task, []string{}))
+	assert.True(t, task.Warning, "Empty result should not satisfy min_rows")
+	assert.JSONEq(t, `[]`, string(task.Result))
+	task.CaptureRows = 0
+
+	task.Assertion = &pgengine.Assertion{}
+	assert.ErrorContains(t, pge.ExecuteSQLCommand(ctx, mockPool, task, []string{}), "must specify")
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestAssertionValidate(t *testing.T) {
+	zero, one, minus := 0, 1, -1
+	assert.NoError(t, (&pgengine.Assertion{MaxRows: &zero}).Validate())
+	assert.NoError(t, (&pgengine.Assertion{Expr: "ok"}).Validate())
+	assert.Error(t, (&pgengine.Assertion{}).Validate())
+	assert.Error(t, (&pgengine.Assertion{MinRows: &minus}).Validate())
+	assert.Error(t, (&pgengine.Assertion{MinRows: &one, MaxRows: &zero}).Validate())
+}
+
 func TestGetChainElements(t *testing.T) {
 	initmockdb(t)
 
@@ -210,8 +296,8 @@ func TestGetChainElements(t *testing.T) {
 
 	mockPool.ExpectQuery("SELECT").WithArgs(0).WillReturnRows(
 		pgxmock.NewRows([]string{"task_id", "task_name", "command", "kind", "run_as",
-			"ignore_error", "autonomous", "database_connection", "timeout"}).
-			AddRow(24, "task1", "foo", "sql", "user", false, false, "postgres://foo@boo/bar", 0))
+			"ignore_error", "autonomous", "database_connection", "connection_name", "timeout", "success_codes", "warning_codes", "capture_rows", "assertion"}).
+			AddRow(24, "task1", "foo", "sql", "user", false, false, "postgres://foo@boo/bar", "", 0, []int{0}, []int{}, 0, nil))
 	assert.NoError(t, pge.GetChainElements(ctx, &[]pgengine.ChainTask{}, 0))
 
 	mockPool.ExpectQuery("SELECT").WithArgs(0).WillReturnError(errors.New("error"))
@@ -2,10 +2,14 @@ package pgengine
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
 
+	pgx "github.com/jackc/pgx/v5"
 	pgconn "github.com/jackc/pgx/v5/pgconn"
 )
 
@@ -20,17 +24,22 @@ func logIdent(id int, name string) string {
 
 type executor interface {
 	Exec(ctx context.Context, sql string, arguments ...any) (commandTag pgconn.CommandTag, err error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
 }
 
 // Chain structure used to represent tasks chains
 type Chain struct {
-	ChainID            int    `db:"chain_id" yaml:"-"`
-	ChainName          string `db:"chain_name" yaml:"name"`
-	SelfDestruct       bool   `db:"self_destruct" yaml:"self_destruct,omitempty"`
-	ExclusiveExecution bool   `db:"exclusive_execution" yaml:"exclusive,omitempty"`
-	MaxInstances       int    `db:"max_instances" yaml:"max_instances,omitempty"`
-	Timeout            int    `db:"timeout" yaml:"timeout,omitempty"`
-	OnError            string `db:"on_error" yaml:"on_error,omitempty"`
+	ChainID            int            `db:"chain_id" yaml:"-"`
+	ChainName          string         `db:"chain_name" yaml:"name"`
+	SelfDestruct       bool           `db:"self_destruct" yaml:"self_destruct,omitempty"`
+	ExclusiveExecution bool           `db:"exclusive_execution" yaml:"exclusive,omitempty"`
+	MaxInstances       int            `db:"max_instances" yaml:"max_instances,omitempty"`
+	Timeout            int            `db:"timeout" yaml:"timeout,omitempty"`
+	OnError            string         `db:"on_error" yaml:"on_error,omitempty"`
+	Trigger            string         `db:"-" yaml:"-"` // what started the run, e.g. cron, interval, reboot, manual
+	ScheduledAt        time.Time      `db:"-" yaml:"-"`
+	Overrides          ParamOverrides `db:"-" yaml:"-"` // task parameter overrides passed at start
+	QueueID            int64          `db:"-" yaml:"-"` // run queue entry requested the run, if any
 }
 
 // String returns a log-friendly identifier, e.g. "42|Import Chain From S3".
@@ -43,6 +52,7 @@ type IntervalChain struct {
 	Chain
 	Interval    int  `db:"interval_seconds"`
 	RepeatAfter bool `db:"repeat_after"`
+	Shared      bool `db:"shared"` // without client name, claimed by one client of the cluster
 }
 
 func (ichain IntervalChain) IsListed(ichains []IntervalChain) bool {
@@ -56,22 +66,92 @@ func (ichain IntervalChain) IsListed(ichains []IntervalChain) bool {
 
 // ChainTask structure describes each chain task
 type ChainTask struct {
-	ChainID       int       `db:"-" yaml:"-"`
-	TaskID        int       `db:"task_id" yaml:"-"`
-	TaskName      string    `db:"task_name" yaml:"-"`
-	Command       string    `db:"command" yaml:"command"`
-	Kind          string    `db:"kind" yaml:"kind,omitempty"`
-	RunAs         string    `db:"run_as" yaml:"run_as,omitempty"`
-	IgnoreError   bool      `db:"ignore_error" yaml:"ignore_error,omitempty"`
-	Autonomous    bool      `db:"autonomous" yaml:"autonomous,omitempty"`
-	ConnectString string    `db:"database_connection" yaml:"connect_string,omitempty"`
-	Timeout       int       `db:"timeout" yaml:"timeout,omitempty"` // in milliseconds
-	StartedAt     time.Time `db:"-" yaml:"-"`
-	Vxid          int64     `db:"-" yaml:"-"`
+	ChainID        int             `db:"-" yaml:"-"`
+	TaskID         int             `db:"task_id" yaml:"-"`
+	TaskName       string          `db:"task_name" yaml:"-"`
+	Command        string          `db:"command" yaml:"command"`
+	Kind           string          `db:"kind" yaml:"kind,omitempty"`
+	RunAs          string          `db:"run_as" yaml:"run_as,omitempty"`
+	IgnoreError    bool            `db:"ignore_error" yaml:"ignore_error,omitempty"`
+	Autonomous     bool            `db:"autonomous" yaml:"autonomous,omitempty"`
+	ConnectString  string          `db:"database_connection" yaml:"connect_string,omitempty"`
+	ConnectionName string          `db:"connection_name" yaml:"connection,omitempty"` // registered connection, see timetable.connection
+	Timeout        int             `db:"timeout" yaml:"timeout,omitempty"`            // in milliseconds
+	SuccessCodes   []int           `db:"success_codes" yaml:"success_codes,omitempty"`
+	WarningCodes   []int           `db:"warning_codes" yaml:"warning_codes,omitempty"`
+	CaptureRows    int             `db:"capture_rows" yaml:"capture_rows,omitempty"` // max rows of result set to capture as JSON
+	Assertion      *Assertion      `db:"assertion" yaml:"assert,omitempty"`
+	StartedAt      time.Time       `db:"-" yaml:"-"`
+	Vxid           int64           `db:"-" yaml:"-"`
+	RunID          int64           `db:"-" yaml:"-"`
+	Warning        bool            `db:"-" yaml:"-"` // set if the last execution finished with a warning code
+	Result         []byte          `db:"-" yaml:"-"` // captured result set of the last execution as JSON
+	Secrets        []string        `db:"-" yaml:"-"` // resolved secret values to be masked in logs
+	Override       json.RawMessage `db:"-" yaml:"-"` // parameter override of the chain run, see ParamOverrides
+
+	// LoggedParams maps parameter values with resolved secrets to the values with secret references,
+	// which are logged instead to keep the execution log meaningful
+	LoggedParams map[string]string `db:"-" yaml:"-"`
 }
 
 func (task *ChainTask) IsRemote() bool {
-	return strings.TrimSpace(task.ConnectString) != ""
+	return strings.TrimSpace(task.ConnectString) != "" || task.ConnectionName != ""
+}
+
+// AddSecret remembers the value to be masked in logs, both as is and JSON escaped
+func (task *ChainTask) AddSecret(value string) {
+	if value == "" {
+		return
+	}
+	task.Secrets = append(task.Secrets, value)
+	if b, err := json.Marshal(value); err == nil {
+		if escaped := string(b[1 : len(b)-1]); escaped != value {
+			task.Secrets = append(task.Secrets, escaped)
+		}
+	}
+}
+
+// Redact masks resolved secret values in s
+func (task *ChainTask) Redact(s string) string {
+	for _, secret := range task.Secrets {
+		s = strings.ReplaceAll(s, secret, SecretMask)
+	}
+	return s
+}
+
+// IsSuccessCode returns true if the program exit code means success. Only 0 is a success if no codes specified
+func (task *ChainTask) IsSuccessCode(code int) bool {
+	if len(task.SuccessCodes) == 0 {
+		return code == 0
+	}
+	return slices.Contains(task.SuccessCodes, code)
+}
+
+// IsWarningCode returns true if the program exit code means a warning, so the chain can proceed
+func (task *ChainTask) IsWarningCode(code int) bool {
+	return slices.Contains(task.WarningCodes, code)
+}
+
+// Assertion describes the expected result of the SQL task query. Use MaxRows = 0 to check the query returns no rows
+type Assertion struct {
+	MinRows *int   `json:"min_rows,omitempty" yaml:"min_rows,omitempty"`
+	MaxRows *int   `json:"max_rows,omitempty" yaml:"max_rows,omitempty"`
+	Expr    string `json:"expr,omitempty" yaml:"expr,omitempty"`       // SQL boolean expression must hold for every row
+	Message string `json:"message,omitempty" yaml:"message,omitempty"` // custom text stored on failure
+	Warning bool   `json:"warning,omitempty" yaml:"warning,omitempty"` // report a warning instead of failing the task
+}
+
+// Validate checks the assertion is consistent
+func (a *Assertion) Validate() error {
+	switch {
+	case a.MinRows != nil && *a.MinRows < 0, a.MaxRows != nil && *a.MaxRows < 0:
+		return errors.New("assertion row count must be non-negative")
+	case a.MinRows != nil && a.MaxRows != nil && *a.MinRows > *a.MaxRows:
+		return errors.New("assertion min_rows cannot be greater than max_rows")
+	case a.MinRows == nil && a.MaxRows == nil && strings.TrimSpace(a.Expr) == "":
+		return errors.New("assertion must specify min_rows, max_rows or expr")
+	}
+	return nil
 }
 
 // String returns a log-friendly identifier, e.g. "49|Check_if_file_exist".
@@ -2,7 +2,6 @@ package pgengine
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"os"
 	"strings"
@@ -73,9 +72,13 @@ func (pge *PgEngine) LoadYamlChains(ctx context.Context, filePath string, replac
 
 // CreateChainFromYaml creates a multi-task chain using direct SQL inserts
 func (pge *PgEngine) CreateChainFromYaml(ctx context.Context, yamlChain *YamlChain) (int64, error) {
+	return createChainFromYaml(ctx, pge.ConfigDb, yamlChain)
+}
+
+func createChainFromYaml(ctx context.Context, db chainWriter, yamlChain *YamlChain) (int64, error) {
 	// Insert chain
 	var chainID int64
-	err := pge.ConfigDb.QueryRow(ctx, `INSERT INTO timetable.chain (
+	err := db.QueryRow(ctx, `INSERT INTO timetable.chain (
 			chain_name, run_at, max_instances, timeout, live, 
 			self_destruct, exclusive_execution, client_name, on_error
 		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) 
@@ -93,51 +96,11 @@ func (pge *PgEngine) CreateChainFromYaml(ctx context.Context, yamlChain *YamlCha
 		return 0, fmt.Errorf("failed to insert chain: %w", err)
 	}
 
-	// Insert tasks
-	for i, task := range yamlChain.Tasks {
-		taskOrder := float64((i + 1) * 10)
-
-		var taskID int64
-		err := pge.ConfigDb.QueryRow(ctx, `
-			INSERT INTO timetable.task (
-				chain_id, task_order, task_name, kind, command, 
-				run_as, database_connection, ignore_error, autonomous, timeout, live
-			) VALUES ($1, $2, $3, $4::timetable.command_kind, $5, $6, $7, $8, $9, $10, $11) 
-			RETURNING task_id`,
-			chainID,
-			taskOrder,
-			nullString(task.TaskName),
-			task.Kind,
-			task.Command,
-			nullString(task.RunAs),
-			nullString(task.ConnectString),
-			task.IgnoreError,
-			task.Autonomous,
-			task.Timeout,
-			task.Live == nil || *task.Live).Scan(&taskID)
-		if err != nil {
+	// Insert tasks with parameters
+	for i := range yamlChain.Tasks {
+		if _, err := insertYamlTask(ctx, db, chainID, float64((i+1)*10), &yamlChain.Tasks[i]); err != nil {
 			return 0, fmt.Errorf("failed to insert task %d: %w", i+1, err)
 		}
-
-		// Insert parameters if any
-		if len(task.Parameters) > 0 {
-			for paramIndex, param := range task.Parameters {
-				orderID := paramIndex + 1
-
-				// Convert parameter to JSON for JSONB storage
-				jsonValue, err := json.Marshal(param)
-				if err != nil {
-					return 0, fmt.Errorf("failed to marshal parameter %d to JSON: %w", orderID, err)
-				}
-
-				_, err = pge.ConfigDb.Exec(ctx,
-					"INSERT INTO timetable.parameter (task_id, order_id, value) VALUES ($1, $2, $3::jsonb)",
-					taskID, orderID, string(jsonValue))
-				if err != nil {
-					return 0, fmt.Errorf("failed to insert parameter %d: %w", orderID, err)
-				}
-			}
-		}
 	}
 
 	return chainID, nil
@@ -200,10 +163,10 @@ func (t *YamlTask) ValidateTask() error {
 
 	// Validate kind
 	switch strings.ToUpper(t.Kind) {
-	case "", "SQL", "PROGRAM", "BUILTIN":
+	case "", "SQL", "PROGRAM", "BUILTIN", "SHELL":
 		// Valid kinds
 	default:
-		return fmt.Errorf("invalid task kind: %s (must be SQL, PROGRAM, or BUILTIN)", t.Kind)
+		return fmt.Errorf("invalid task kind: %s (must be SQL, PROGRAM, BUILTIN, or SHELL)", t.Kind)
 	}
 
 	// Validate timeout is non-negative
@@ -211,6 +174,21 @@ func (t *YamlTask) ValidateTask() error {
 		return fmt.Errorf("task timeout must be non-negative")
 	}
 
+	// Validate result capture
+	if t.CaptureRows < 0 {
+		return fmt.Errorf("task capture_rows must be non-negative")
+	}
+
+	// Validate assertion
+	if t.Assertion != nil {
+		if k := strings.ToUpper(t.Kind); k != "" && k != "SQL" {
+			return fmt.Errorf("task assertion is only supported for SQL tasks")
+		}
+		if err := t.Assertion.Validate(); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -223,15 +201,19 @@ func (c *YamlChain) SetDefaults() {
 
 	// Task defaults
 	for i := range c.Tasks {
-		task := &c.Tasks[i]
-		if task.Kind == "" {
-			task.Kind = "SQL"
-		}
-		// Tasks are live by default, unlike chains
-		if task.Live == nil {
-			live := true
-			task.Live = &live
-		}
+		c.Tasks[i].SetDefaults()
+	}
+}
+
+// SetDefaults sets default values for optional task fields
+func (t *YamlTask) SetDefaults() {
+	if t.Kind == "" {
+		t.Kind = "SQL"
+	}
+	// Tasks are live by default, unlike chains
+	if t.Live == nil {
+		live := true
+		t.Live = &live
 	}
 }
 
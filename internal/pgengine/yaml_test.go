@@ -490,7 +490,7 @@ func TestYamlTaskValidation(t *testing.T) {
 	})
 
 	t.Run("Valid kinds", func(t *testing.T) {
-		validKinds := []string{"", "SQL", "PROGRAM", "BUILTIN", "sql", "program", "builtin"}
+		validKinds := []string{"", "SQL", "PROGRAM", "BUILTIN", "SHELL", "sql", "program", "builtin", "shell"}
 		for _, kind := range validKinds {
 			task := &pgengine.YamlTask{
 				ChainTask: pgengine.ChainTask{
@@ -530,6 +530,36 @@ func TestYamlTaskValidation(t *testing.T) {
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "task timeout must be non-negative")
 	})
+
+	t.Run("Negative capture_rows", func(t *testing.T) {
+		task := &pgengine.YamlTask{
+			ChainTask: pgengine.ChainTask{
+				Command:     "SELECT 1",
+				CaptureRows: -1,
+			},
+		}
+
+		err := task.ValidateTask()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "task capture_rows must be non-negative")
+	})
+
+	t.Run("Assertion", func(t *testing.T) {
+		task := &pgengine.YamlTask{
+			ChainTask: pgengine.ChainTask{
+				Command:   "SELECT 1",
+				Assertion: &pgengine.Assertion{Expr: "true"},
+			},
+		}
+		assert.NoError(t, task.ValidateTask())
+
+		task.Kind = "PROGRAM"
+		assert.ErrorContains(t, task.ValidateTask(), "only supported for SQL tasks")
+
+		task.Kind = "SQL"
+		task.Assertion = &pgengine.Assertion{}
+		assert.ErrorContains(t, task.ValidateTask(), "must specify")
+	})
 }
 
 func TestYamlChainSetDefaults(t *testing.T) {
@@ -767,7 +797,7 @@ func TestNullString(t *testing.T) {
 			WithArgs(anyArgs(9)...).
 			WillReturnRows(pgxmock.NewRows([]string{"chain_id"}).AddRow(1))
 		mockPool.ExpectQuery(`INSERT INTO timetable\.task`).
-			WithArgs(anyArgs(11)...).
+			WithArgs(anyArgs(16)...).
 			WillReturnRows(pgxmock.NewRows([]string{"task_id"}).AddRow(1))
 
 		err := mockpge.LoadYamlChains(context.Background(), tmpfile, false)
@@ -823,7 +853,7 @@ func TestLoadYamlChainsMultiTask(t *testing.T) {
 
 		// Mock first task creation
 		mockPool.ExpectQuery(`INSERT INTO timetable\.task`).
-			WithArgs(anyArgs(11)...).
+			WithArgs(anyArgs(16)...).
 			WillReturnRows(pgxmock.NewRows([]string{"task_id"}).AddRow(1))
 		// Mock first task parameters (2 parameters)
 		mockPool.ExpectExec(`INSERT INTO timetable\.parameter`).
@@ -833,7 +863,7 @@ func TestLoadYamlChainsMultiTask(t *testing.T) {
 
 		// Mock second task creation
 		mockPool.ExpectQuery(`INSERT INTO timetable\.task`).
-			WithArgs(anyArgs(11)...).
+			WithArgs(anyArgs(16)...).
 			WillReturnRows(pgxmock.NewRows([]string{"task_id"}).AddRow(2))
 		// Mock second task parameters (2 parameters)
 		mockPool.ExpectExec(`INSERT INTO timetable\.parameter`).
@@ -945,11 +975,11 @@ func TestCreateChainFromYamlEdgeCases(t *testing.T) {
 
 		// Mock first task creation (no parameters)
 		mockPool.ExpectQuery(`INSERT INTO timetable\.task`).
-			WithArgs(anyArgs(11)...).
+			WithArgs(anyArgs(16)...).
 			WillReturnRows(pgxmock.NewRows([]string{"task_id"}).AddRow(1))
 		// Mock second task creation (empty parameters)
 		mockPool.ExpectQuery(`INSERT INTO timetable\.task`).
-			WithArgs(anyArgs(11)...).
+			WithArgs(anyArgs(16)...).
 			WillReturnRows(pgxmock.NewRows([]string{"task_id"}).AddRow(2))
 
 		err := mockpge.LoadYamlChains(context.Background(), tmpfile, false)
@@ -979,7 +1009,7 @@ func TestCreateChainFromYamlEdgeCases(t *testing.T) {
 			WithArgs(anyArgs(9)...).
 			WillReturnRows(pgxmock.NewRows([]string{"chain_id"}).AddRow(1))
 		mockPool.ExpectQuery(`INSERT INTO timetable\.task`).
-			WithArgs(anyArgs(11)...).
+			WithArgs(anyArgs(16)...).
 			WillReturnRows(pgxmock.NewRows([]string{"task_id"}).AddRow(1))
 		// Mock parameter insertion
 		mockPool.ExpectExec(`INSERT INTO timetable\.parameter`).
@@ -1023,7 +1053,7 @@ func TestCreateChainFromYamlErrorHandling(t *testing.T) {
 
 		// Mock first task with complex parameter
 		mockPool.ExpectQuery(`INSERT INTO timetable\.task`).
-			WithArgs(anyArgs(11)...).
+			WithArgs(anyArgs(16)...).
 			WillReturnRows(pgxmock.NewRows([]string{"task_id"}).AddRow(1))
 		mockPool.ExpectExec(`INSERT INTO timetable\.parameter`).
 			WithArgs(anyArgs(3)...).
@@ -1031,7 +1061,7 @@ func TestCreateChainFromYamlErrorHandling(t *testing.T) {
 
 		// Mock second task (no parameters)
 		mockPool.ExpectQuery(`INSERT INTO timetable\.task`).
-			WithArgs(anyArgs(11)...).
+			WithArgs(anyArgs(16)...).
 			WillReturnRows(pgxmock.NewRows([]string{"task_id"}).AddRow(2))
 
 		err := mockpge.LoadYamlChains(context.Background(), tmpfile, false)
@@ -1089,7 +1119,7 @@ func TestCreateChainFromYamlErrorHandling(t *testing.T) {
 
 		// Mock sql-task creation with 2 parameters
 		mockPool.ExpectQuery(`INSERT INTO timetable\.task`).
-			WithArgs(anyArgs(11)...).
+			WithArgs(anyArgs(16)...).
 			WillReturnRows(pgxmock.NewRows([]string{"task_id"}).AddRow(1))
 		mockPool.ExpectExec(`INSERT INTO timetable\.parameter`).
 			WithArgs(anyArgs(3)...).
@@ -1098,7 +1128,7 @@ func TestCreateChainFromYamlErrorHandling(t *testing.T) {
 
 		// Mock program-task creation with 2 parameters
 		mockPool.ExpectQuery(`INSERT INTO timetable\.task`).
-			WithArgs(anyArgs(11)...).
+			WithArgs(anyArgs(16)...).
 			WillReturnRows(pgxmock.NewRows([]string{"task_id"}).AddRow(2))
 		mockPool.ExpectExec(`INSERT INTO timetable\.parameter`).
 			WithArgs(anyArgs(3)...).
@@ -1107,7 +1137,7 @@ func TestCreateChainFromYamlErrorHandling(t *testing.T) {
 
 		// Mock builtin-task creation with 1 parameter
 		mockPool.ExpectQuery(`INSERT INTO timetable\.task`).
-			WithArgs(anyArgs(11)...).
+			WithArgs(anyArgs(16)...).
 			WillReturnRows(pgxmock.NewRows([]string{"task_id"}).AddRow(3))
 		mockPool.ExpectExec(`INSERT INTO timetable\.parameter`).
 			WithArgs(anyArgs(3)...).
@@ -1150,7 +1180,7 @@ func TestNullStringFunction(t *testing.T) {
 			WillReturnRows(pgxmock.NewRows([]string{"chain_id"}).AddRow(1))
 		// Mock task creation with NULL fields
 		mockPool.ExpectQuery(`INSERT INTO timetable\.task`).
-			WithArgs(anyArgs(11)...).
+			WithArgs(anyArgs(16)...).
 			WillReturnRows(pgxmock.NewRows([]string{"task_id"}).AddRow(1))
 
 		err := mockpge.LoadYamlChains(context.Background(), tmpfile, false)
@@ -1183,7 +1213,7 @@ func TestNullStringFunction(t *testing.T) {
 			WillReturnRows(pgxmock.NewRows([]string{"chain_id"}).AddRow(1))
 		// Mock task creation with mixed NULL/non-NULL fields
 		mockPool.ExpectQuery(`INSERT INTO timetable\.task`).
-			WithArgs(anyArgs(11)...).
+			WithArgs(anyArgs(16)...).
 			WillReturnRows(pgxmock.NewRows([]string{"task_id"}).AddRow(1))
 
 		err := mockpge.LoadYamlChains(context.Background(), tmpfile, false)
@@ -1211,7 +1241,7 @@ func TestCreateChainFromYamlErrors(t *testing.T) {
 			WithArgs(anyArgs(9)...).
 			WillReturnRows(pgxmock.NewRows([]string{"chain_id"}).AddRow(1))
 		mockPool.ExpectQuery(`INSERT INTO timetable.task`).
-			WithArgs(anyArgs(11)...).
+			WithArgs(anyArgs(16)...).
 			WillReturnError(fmt.Errorf("simulated DB error on task"))
 
 		_, err := mockpge.CreateChainFromYaml(ctx, &pgengine.YamlChain{
@@ -1230,7 +1260,7 @@ func TestCreateChainFromYamlErrors(t *testing.T) {
 			WithArgs(anyArgs(9)...).
 			WillReturnRows(pgxmock.NewRows([]string{"chain_id"}).AddRow(1))
 		mockPool.ExpectQuery(`INSERT INTO timetable.task`).
-			WithArgs(anyArgs(11)...).
+			WithArgs(anyArgs(16)...).
 			WillReturnRows(pgxmock.NewRows([]string{"task_id"}).AddRow(1))
 
 		_, err := mockpge.CreateChainFromYaml(ctx, &pgengine.YamlChain{
@@ -1252,7 +1282,7 @@ func TestCreateChainFromYamlErrors(t *testing.T) {
 			WithArgs(anyArgs(9)...).
 			WillReturnRows(pgxmock.NewRows([]string{"chain_id"}).AddRow(1))
 		mockPool.ExpectQuery(`INSERT INTO timetable.task`).
-			WithArgs(anyArgs(11)...).
+			WithArgs(anyArgs(16)...).
 			WillReturnRows(pgxmock.NewRows([]string{"task_id"}).AddRow(1))
 		mockPool.ExpectExec(`INSERT INTO timetable.parameter`).
 			WithArgs(anyArgs(3)...).
@@ -5,6 +5,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
 	"time"
 
 	"github.com/cybertec-postgresql/pg_timetable/internal/log"
@@ -20,13 +21,15 @@ type (
 	ChainSignal = pgengine.ChainSignal
 )
 
-// SendChain sends chain to the channel for workers
-func (sch *Scheduler) SendChain(c Chain) {
+// SendChain sends chain to the channel for workers, returns false if all workers are busy and the channel is full
+func (sch *Scheduler) SendChain(c Chain) bool {
 	select {
 	case sch.chainsChan <- c:
 		sch.l.WithField("chain", c).Debug("Sent chain to the execution channel")
+		return true
 	default:
 		sch.l.WithField("chain", c).Error("Failed to send chain to the execution channel")
+		return false
 	}
 }
 
@@ -34,6 +37,7 @@ func (sch *Scheduler) SendChain(c Chain) {
 func (sch *Scheduler) Lock(exclusiveExecution bool) {
 	if exclusiveExecution {
 		sch.exclusiveMutex.Lock()
+		sch.exclusiveLocked.Store(true)
 	} else {
 		sch.exclusiveMutex.RLock()
 	}
@@ -42,6 +46,7 @@ func (sch *Scheduler) Lock(exclusiveExecution bool) {
 // Unlock releases the lock after the chain execution
 func (sch *Scheduler) Unlock(exclusiveExecution bool) {
 	if exclusiveExecution {
+		sch.exclusiveLocked.Store(false)
 		sch.exclusiveMutex.Unlock()
 	} else {
 		sch.exclusiveMutex.RUnlock()
@@ -63,22 +68,14 @@ func (sch *Scheduler) retrieveAsyncChainsAndRun(ctx context.Context) {
 
 func (sch *Scheduler) processAsyncChain(ctx context.Context, chainSignal ChainSignal) error {
 	switch chainSignal.Command {
-	case "START":
-		var c Chain
-		if err := sch.pgengine.SelectChain(ctx, &c, chainSignal.ConfigID); err != nil {
-			return fmt.Errorf("cannot start chain with ID: %d; %w", chainSignal.ConfigID, err)
-		}
-		go func() {
-			select {
-			case <-ctx.Done():
-				return
-			case <-time.After(time.Duration(chainSignal.Delay) * time.Second):
-				sch.SendChain(c)
-			}
-		}()
+	case "START": // the chain is added to the run queue already by notify_chain_start()
+		sch.wakeRunQueue()
 	case "STOP":
-		if cancel, ok := sch.activeChains[chainSignal.ConfigID]; ok {
-			cancel()
+		sch.activeChainMutex.Lock()
+		ac, ok := sch.activeChains[chainSignal.ConfigID]
+		sch.activeChainMutex.Unlock()
+		if ok {
+			ac.cancel()
 			return nil
 		}
 		return fmt.Errorf("cannot stop chain with ID: %d. No running chain found", chainSignal.ConfigID)
@@ -102,6 +99,10 @@ func (sch *Scheduler) retrieveChainsAndRun(ctx context.Context, reboot bool) {
 		sch.l.WithError(err).Error("Could not query pending tasks")
 		return
 	}
+	trigger, scheduledAt := "cron", time.Now().Truncate(time.Minute)
+	if reboot {
+		trigger, scheduledAt = "reboot", time.Now()
+	}
 	headChainsCount := len(headChains)
 	sch.l.WithField("count", headChainsCount).Info(msg)
 	// now we can loop through the chains
@@ -110,13 +111,14 @@ func (sch *Scheduler) retrieveChainsAndRun(ctx context.Context, reboot bool) {
 		if headChainsCount > sch.Config().Resource.CronWorkers*refetchTimeout {
 			time.Sleep(time.Duration(refetchTimeout*1000/headChainsCount) * time.Millisecond)
 		}
+		c.Trigger, c.ScheduledAt = trigger, scheduledAt
 		sch.SendChain(c)
 	}
 }
 
-func (sch *Scheduler) addActiveChain(id int, cancel context.CancelFunc) {
+func (sch *Scheduler) addActiveChain(chain Chain, cancel context.CancelFunc) {
 	sch.activeChainMutex.Lock()
-	sch.activeChains[id] = cancel
+	sch.activeChains[chain.ChainID] = &activeChain{cancel: cancel, chain: chain, startedAt: time.Now()}
 	sch.activeChainMutex.Unlock()
 }
 
@@ -127,10 +129,12 @@ func (sch *Scheduler) deleteActiveChain(id int) {
 }
 
 func (sch *Scheduler) terminateChains() {
-	for id, cancel := range sch.activeChains {
+	sch.activeChainMutex.Lock()
+	for id, ac := range sch.activeChains {
 		sch.l.WithField("chain", id).Debug("Terminating chain...")
-		cancel()
+		ac.cancel()
 	}
+	sch.activeChainMutex.Unlock()
 	for {
 		time.Sleep(1 * time.Second) // give some time to terminate chains gracefully
 		if len(sch.activeChains) == 0 {
@@ -150,18 +154,27 @@ func (sch *Scheduler) chainWorker(ctx context.Context, chains <-chan Chain) {
 			case chain := <-chains:
 				chainL := sch.l.WithField("chain", chain)
 				chainContext := log.WithLogger(ctx, chainL)
+				if !sch.checkLeadership(ctx) {
+					chainL.Info("Leadership lost, chain skipped")
+					continue
+				}
 				if !sch.pgengine.InsertChainRunStatus(ctx, chain.ChainID, chain.MaxInstances) {
 					chainL.Info("Cannot proceed. Sleeping")
+					if chain.QueueID != 0 { // the requested start is skipped the same way as the scheduled one
+						sch.pgengine.DeleteQueuedChain(ctx, chain.QueueID)
+					}
 					continue
 				}
 				chainL.Info("Starting chain")
+				sch.busyCronWorkers.Add(1)
 				sch.Lock(chain.ExclusiveExecution)
 				chainContext, cancel := context.WithCancel(chainContext)
-				sch.addActiveChain(chain.ChainID, cancel)
+				sch.addActiveChain(chain, cancel)
 				sch.executeChain(chainContext, chain)
 				sch.deleteActiveChain(chain.ChainID)
 				cancel()
 				sch.Unlock(chain.ExclusiveExecution)
+				sch.busyCronWorkers.Add(-1)
 			case <-ctx.Done():
 				return
 			}
@@ -191,9 +204,13 @@ func (sch *Scheduler) executeOnErrorHandler(ctx context.Context, chain Chain) {
 	}
 	sch.pgengine.SetCurrentTaskContext(ctx, tx, chain.ChainID, 0)
 	_, err = tx.Exec(ctx, chain.OnError)
-	sch.pgengine.CommitTransaction(ctx, tx)
+	if err == nil {
+		err = sch.pgengine.CommitTransaction(ctx, tx)
+	} else {
+		sch.pgengine.RollbackTransaction(ctx, tx)
+	}
 	if err != nil {
-		l.Info("Error handler failed")
+		l.WithError(err).Info("Error handler failed")
 		return
 	}
 	l.Info("Error handler executed successfully")
@@ -223,10 +240,15 @@ func (sch *Scheduler) executeChain(ctx context.Context, chain Chain) {
 		defer cancel()
 	}
 
-	chainL := sch.l.WithField("chain", chain)
+	runID := sch.pgengine.StartChainRun(ctx, chain)
+	chainSpan.SetAttributes(attribute.Int64("chain.run_id", runID))
+	chainL := sch.l.WithField("chain", chain).WithField("run", runID)
+	sch.publishChain(EventChainStarted, chain, runID, "", "")
 	tx, vxid, err := sch.pgengine.StartTransaction(chainCtx)
 	if err != nil {
 		chainL.WithError(err).Error("Cannot start transaction")
+		sch.pgengine.FinishChainRun(context.WithoutCancel(ctx), runID, pgengine.RunFailed, err.Error())
+		sch.publishChain(EventChainFailed, chain, runID, pgengine.RunFailed, sch.pgengine.Redact(err.Error()))
 		return
 	}
 	chainL = chainL.WithField("vxid", vxid)
@@ -235,21 +257,34 @@ func (sch *Scheduler) executeChain(ctx context.Context, chain Chain) {
 	if err != nil {
 		chainL.WithError(err).Error("Failed to retrieve chain elements")
 		sch.pgengine.RollbackTransaction(chainCtx, tx)
+		sch.pgengine.FinishChainRun(context.WithoutCancel(ctx), runID, pgengine.RunFailed, err.Error())
+		sch.publishChain(EventChainFailed, chain, runID, pgengine.RunFailed, sch.pgengine.Redact(err.Error()))
 		return
 	}
 
+	results := chainResults{}
 	/* now we can loop through every element of the task chain */
 	for _, task := range ChainTasks {
 		task.ChainID = chain.ChainID
 		task.Vxid = vxid
+		task.RunID = runID
+		task.Override = chain.Overrides.For(task)
 		l := chainL.WithField("task", task)
 		l.Info("Starting task")
+		sch.setActiveTask(chain.ChainID, task)
+		sch.publishTask(EventTaskStarted, task, "", nil)
 		taskCtx := log.WithLogger(chainCtx, l)
-		err = sch.executeTask(taskCtx, tx, &task)
-		if err != nil {
+		err = sch.executeTask(taskCtx, tx, &task, results)
+		switch {
+		case err != nil:
 			l.WithError(err).Error("Task execution failed")
-		} else {
+			sch.publishTask(EventTaskFinished, task, "FAILED", err)
+		case task.Warning:
+			l.Warn("Task executed with warning")
+			sch.publishTask(EventTaskFinished, task, "WARNING", nil)
+		default:
 			l.Info("Task executed successfully")
+			sch.publishTask(EventTaskFinished, task, "SUCCEEDED", nil)
 		}
 
 		// we detach the context from cancellation here because the current one
@@ -261,6 +296,13 @@ func (sch *Scheduler) executeChain(ctx context.Context, chain Chain) {
 				chainL.Error("Chain failed")
 				sch.pgengine.RemoveChainRunStatus(bctx, chain.ChainID)
 				sch.pgengine.RollbackTransaction(bctx, tx)
+				status := pgengine.RunFailed
+				if errors.Is(chainCtx.Err(), context.Canceled) {
+					status = pgengine.RunCancelled
+				}
+				errMsg := fmt.Sprintf("task %s: %s", task, err)
+				sch.pgengine.FinishChainRun(bctx, runID, status, errMsg)
+				sch.publishChain(EventChainFailed, chain, runID, status, sch.pgengine.Redact(errMsg))
 				chainSpan.SetStatus(codes.Error, "chain failed")
 				sch.provider.RecordChainFailed(bctx, sch.Config().ClientName)
 				sch.executeOnErrorHandler(bctx, chain)
@@ -270,18 +312,40 @@ func (sch *Scheduler) executeChain(ctx context.Context, chain Chain) {
 		}
 	}
 	bctx = log.WithLogger(context.WithoutCancel(chainCtx), chainL)
-	sch.pgengine.CommitTransaction(bctx, tx)
+	if err = sch.pgengine.CommitTransaction(bctx, tx); err != nil {
+		chainL.WithError(err).Error("Chain failed, cannot commit transaction")
+		sch.pgengine.RemoveChainRunStatus(bctx, chain.ChainID)
+		errMsg := "commit: " + err.Error()
+		sch.pgengine.FinishChainRun(bctx, runID, pgengine.RunFailed, errMsg)
+		sch.publishChain(EventChainFailed, chain, runID, pgengine.RunFailed, sch.pgengine.Redact(errMsg))
+		chainSpan.SetStatus(codes.Error, "chain failed")
+		sch.provider.RecordChainFailed(bctx, sch.Config().ClientName)
+		sch.executeOnErrorHandler(bctx, chain)
+		return
+	}
 	sch.provider.RecordChainDuration(ctx, time.Since(chainStart).Seconds(), sch.Config().ClientName)
 	chainL.Info("Chain executed successfully")
 	sch.provider.RecordChainCompleted(ctx, sch.Config().ClientName)
 	sch.pgengine.RemoveChainRunStatus(bctx, chain.ChainID)
+	sch.pgengine.FinishChainRun(bctx, runID, pgengine.RunSucceeded, "")
+	sch.publishChain(EventChainFinished, chain, runID, pgengine.RunSucceeded, "")
 	if chain.SelfDestruct {
 		sch.pgengine.DeleteChain(bctx, chain.ChainID)
 	}
 }
 
+// prepareParams renders templates of the stored values with captured results and applies the parameter override
+// of the chain run afterwards, so overrides are never rendered
+func prepareParams(paramValues []string, task *pgengine.ChainTask, results chainResults) ([]string, error) {
+	paramValues, err := results.render(paramValues)
+	if err != nil {
+		return nil, err
+	}
+	return pgengine.OverrideParamValues(paramValues, task.Override)
+}
+
 /* execute a task */
-func (sch *Scheduler) executeTask(ctx context.Context, tx pgx.Tx, task *pgengine.ChainTask) error {
+func (sch *Scheduler) executeTask(ctx context.Context, tx pgx.Tx, task *pgengine.ChainTask, results chainResults) error {
 	var (
 		paramValues []string
 		err         error
@@ -292,7 +356,7 @@ func (sch *Scheduler) executeTask(ctx context.Context, tx pgx.Tx, task *pgengine
 	tracer := sch.provider.Tracer()
 	ctx, taskSpan := tracer.Start(ctx, "task.execute",
 		trace.WithAttributes(
-			attribute.String("task.name", task.Command),
+			attribute.String("task.name", sch.pgengine.Redact(task.Command)),
 			attribute.String("task.kind", task.Kind),
 		))
 	defer taskSpan.End()
@@ -303,6 +367,35 @@ func (sch *Scheduler) executeTask(ctx context.Context, tx pgx.Tx, task *pgengine
 		l.WithError(err).Error("cannot fetch parameters values for chain: ", err)
 		return err
 	}
+	if task.Override != nil && !task.Overridable() { // overrides of notify_chain_start() are not validated
+		err = errors.New("parameters of the task cannot be overridden")
+		l.WithError(err).Error("cannot apply parameter override")
+		return err
+	}
+	// secrets are resolved in the stored values only, so overrides and captured results cannot refer to them
+	storedValues := paramValues
+	if paramValues, err = sch.pgengine.ResolveSecrets(ctx, task, storedValues); err != nil {
+		l.WithError(err).Error("cannot resolve secrets for task")
+		return err
+	}
+	if task.Override != nil && !slices.Equal(paramValues, storedValues) {
+		// the override might keep the resolved secret and change e.g. the server it is sent to
+		err = errors.New("parameters of the task referring to secrets cannot be overridden")
+		l.WithError(err).Error("cannot apply parameter override")
+		return err
+	}
+	if paramValues, err = prepareParams(paramValues, task, results); err != nil {
+		return err
+	}
+	task.LoggedParams = nil
+	if len(task.Secrets) > 0 { // log secret references instead of masked values
+		if loggedValues, e := prepareParams(storedValues, task, results); e == nil && len(loggedValues) == len(paramValues) {
+			task.LoggedParams = make(map[string]string, len(paramValues))
+			for i, val := range paramValues {
+				task.LoggedParams[val] = loggedValues[i]
+			}
+		}
+	}
 
 	ctx, cancel = getTimeoutContext(ctx, sch.Config().Resource.TaskTimeout, task.Timeout)
 	if cancel != nil {
@@ -319,16 +412,34 @@ func (sch *Scheduler) executeTask(ctx context.Context, tx pgx.Tx, task *pgengine
 			return errors.New("program tasks execution is disabled")
 		}
 		err = sch.ExecuteProgramCommand(ctx, task, paramValues)
+	case "SHELL":
+		if sch.pgengine.NoProgramTasks {
+			l.Info("Shell task execution skipped")
+			return errors.New("shell tasks execution is disabled")
+		}
+		err = sch.ExecuteShellScript(ctx, task, paramValues)
 	case "BUILTIN":
 		err = sch.executeBuiltinTask(ctx, task, paramValues)
 	}
 	returnCode := 0
 	if err != nil {
+		if msg := sch.pgengine.Redact(task.Redact(err.Error())); msg != err.Error() {
+			err = errors.New(msg) // error message may contain parameters
+		}
 		returnCode = -1
 		taskSpan.RecordError(err)
 		taskSpan.SetStatus(codes.Error, err.Error())
 	}
 	taskSpan.SetAttributes(attribute.Int("task.return_code", returnCode))
+	if err == nil {
+		if e := results.add(task); e != nil {
+			l.WithError(e).Error("Failed to store task result")
+		}
+	}
+	if task.Warning {
+		taskSpan.SetAttributes(attribute.Bool("task.warning", true))
+		sch.provider.RecordTaskWarning(ctx, sch.Config().ClientName, task.Kind)
+	}
 	sch.provider.RecordTaskExecuted(ctx, sch.Config().ClientName, task.Kind)
 	return err
 }
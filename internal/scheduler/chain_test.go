@@ -31,29 +31,16 @@ func TestAsyncChains(t *testing.T) {
 	pge := pgengine.NewDB(mock, "scheduler_unit_test")
 	sch := New(pge, log.Init(config.LoggingOpts{LogLevel: "panic", LogDBLevel: "none"}), otel.NewNoop())
 	n1 := &pgconn.Notification{Payload: `{"ConfigID": 1, "Command": "START"}`}
-	n2 := &pgconn.Notification{Payload: `{"ConfigID": 2, "Command": "START"}`}
 	ns := &pgconn.Notification{Payload: `{"ConfigID": 24, "Command": "STOP"}`}
 
-	//add correct chain
+	// START notification wakes up the run queue processing
 	pge.NotificationHandler(&pgconn.PgConn{}, n1)
 	pge.NotificationHandler(&pgconn.PgConn{}, ns)
-	mock.ExpectQuery("SELECT.+chain_id").
-		WillReturnRows(pgxmock.NewRows([]string{"chain_id", "task_id", "chain_name",
-			"self_destruct", "exclusive_execution", "max_instances"}).
-			AddRow(24, 24, "foo", false, false, 16))
-	if pge.Verbose() {
-		mock.ExpectExec("INSERT.+log").WillReturnResult(pgxmock.NewResult("EXECUTE", 1))
-	}
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*2)
 	defer cancel()
 	sch.retrieveAsyncChainsAndRun(ctx)
-	//add incorrect chain
-	pge.NotificationHandler(&pgconn.PgConn{}, n2)
-	mock.ExpectQuery("SELECT.+chain_id").WillReturnError(errors.New("error"))
-	mock.ExpectExec("INSERT.+log").WillReturnResult(pgxmock.NewResult("EXECUTE", 1))
-	ctx, cancel = context.WithTimeout(context.Background(), time.Second*2)
-	defer cancel()
-	sch.retrieveAsyncChainsAndRun(ctx)
+	assert.Len(t, sch.queueSignal, 1)
+	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
 func TestChainWorker(t *testing.T) {
@@ -92,6 +79,37 @@ func TestChainWorker(t *testing.T) {
 		chains <- Chain{}
 		sch.chainWorker(ctx, chains)
 	})
+
+	t.Run("Check chainWorker removes queued start if cannot proceed", func(t *testing.T) {
+		mock, err := pgxmock.NewPool()
+		assert.NoError(t, err)
+		sch := New(pgengine.NewDB(mock, "-c", "scheduler_unit_test"), log.Init(config.LoggingOpts{LogLevel: "panic"}), otel.NewNoop())
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		mock.ExpectExec("INSERT INTO timetable\\.active_chain").WithArgs(42, "scheduler_unit_test", 16).
+			WillReturnResult(pgxmock.NewResult("INSERT", 0))
+		mock.ExpectExec("DELETE FROM timetable\\.run_queue").WithArgs(int64(5)).
+			WillReturnResult(pgxmock.NewResult("DELETE", 1))
+		chains := make(chan Chain, 1)
+		chains <- Chain{ChainID: 42, MaxInstances: 16, QueueID: 5}
+		sch.chainWorker(ctx, chains)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Check chainWorker skips chains if leadership lost", func(t *testing.T) {
+		mock, err := pgxmock.NewPool()
+		assert.NoError(t, err)
+		sch := New(pgengine.NewDB(mock, "-c", "scheduler_unit_test"), log.Init(config.LoggingOpts{LogLevel: "panic"}), otel.NewNoop())
+		close(sch.leaderLost)
+		assert.False(t, sch.checkLeadership(t.Context()))
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		chains := make(chan Chain, 1)
+		chains <- Chain{ChainID: 42, MaxInstances: 16}
+		sch.chainWorker(ctx, chains)
+		assert.Empty(t, chains)
+		assert.NoError(t, mock.ExpectationsWereMet(), "no chain run expected")
+	})
 }
 
 func TestExecuteChain(t *testing.T) {
@@ -101,6 +119,36 @@ func TestExecuteChain(t *testing.T) {
 	sch := New(pge, log.Init(config.LoggingOpts{LogLevel: "panic", LogDBLevel: "none"}), otel.NewNoop())
 
 	sch.executeChain(t.Context(), Chain{Timeout: 1})
+
+	t.Run("Check chain run is finished if transaction fails", func(t *testing.T) {
+		mock.ExpectQuery("INSERT INTO timetable\\.chain_run").
+			WithArgs(42, "cron", pgxmock.AnyArg(), pgxmock.AnyArg(), nil, int64(0)).
+			WillReturnRows(pgxmock.NewRows([]string{"run_id"}).AddRow(int64(7)))
+		mock.ExpectBegin().WillReturnError(errors.New("expected"))
+		mock.ExpectExec("UPDATE timetable\\.chain_run").
+			WithArgs(int64(7), pgengine.RunFailed, "expected").
+			WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+		sch.executeChain(t.Context(), Chain{ChainID: 42, Trigger: "cron", ScheduledAt: time.Now()})
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Check chain run is failed if commit fails", func(t *testing.T) {
+		mock.ExpectQuery("INSERT INTO timetable\\.chain_run").
+			WithArgs(42, "cron", pgxmock.AnyArg(), pgxmock.AnyArg(), nil, int64(0)).
+			WillReturnRows(pgxmock.NewRows([]string{"run_id"}).AddRow(int64(8)))
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT").WillReturnRows(pgxmock.NewRows([]string{"vxid"}).AddRow(int64(42)))
+		mock.ExpectQuery("SELECT").WithArgs(42).WillReturnRows(pgxmock.NewRows([]string{"task_id"}))
+		mock.ExpectCommit().WillReturnError(errors.New("deferred constraint violated"))
+		mock.ExpectExec("DELETE FROM timetable\\.active_chain").
+			WithArgs(42, "scheduler_unit_test").
+			WillReturnResult(pgxmock.NewResult("DELETE", 1))
+		mock.ExpectExec("UPDATE timetable\\.chain_run").
+			WithArgs(int64(8), pgengine.RunFailed, "commit: deferred constraint violated").
+			WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+		sch.executeChain(t.Context(), Chain{ChainID: 42, Trigger: "cron", ScheduledAt: time.Now()})
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
 }
 
 func TestExecuteChainElement(t *testing.T) {
@@ -111,10 +159,68 @@ func TestExecuteChainElement(t *testing.T) {
 	task := &pgengine.ChainTask{Timeout: 1}
 
 	mock.ExpectQuery("SELECT").WithArgs(pgxmock.AnyArg()).WillReturnRows(pgxmock.NewRows([]string{"value"}).AddRow("foo"))
-	_ = sch.executeTask(t.Context(), mock, task)
+	_ = sch.executeTask(t.Context(), mock, task, chainResults{})
 	assert.False(t, task.StartedAt.IsZero())
 }
 
+func TestExecuteTaskRejectsProgramOverride(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	pge := pgengine.NewDB(mock, "-c", "scheduler_unit_test", "--password=somestrong")
+	sch := New(pge, log.Init(config.LoggingOpts{LogLevel: "panic", LogDBLevel: "none"}), otel.NewNoop())
+	task := &pgengine.ChainTask{Kind: "PROGRAM", Command: "echo", Override: []byte(`["-c", "id"]`)}
+
+	mock.ExpectQuery("SELECT").WithArgs(pgxmock.AnyArg()).WillReturnRows(pgxmock.NewRows([]string{"value"}).AddRow(`["foo"]`))
+	assert.ErrorContains(t, sch.executeTask(t.Context(), mock, task, chainResults{}), "cannot be overridden")
+	assert.True(t, task.StartedAt.IsZero(), "task must not be started")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func anyArgs(n int) []any {
+	args := make([]any, n)
+	for i := range args {
+		args[i] = pgxmock.AnyArg()
+	}
+	return args
+}
+
+func TestExecuteTaskSecrets(t *testing.T) {
+	t.Setenv("PGTT_SECRET_PW", "hunter2")
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	pge := pgengine.NewDB(mock, "-c", "scheduler_unit_test")
+	pge.Secrets.EnvPrefix = "PGTT_SECRET_"
+	sch := New(pge, log.Init(config.LoggingOpts{LogLevel: "panic", LogDBLevel: "none"}), otel.NewNoop())
+	expectTask := func(storedValue string, arg any, loggedParams string) {
+		mock.ExpectQuery("SELECT value FROM timetable\\.parameter").WithArgs(0).
+			WillReturnRows(pgxmock.NewRows([]string{"value"}).AddRow(storedValue))
+		mock.ExpectExec("SELECT set_config").WithArgs(anyArgs(3)...).WillReturnResult(pgxmock.NewResult("SELECT", 1))
+		mock.ExpectExec("SELECT \\$1").WithArgs(arg).WillReturnResult(pgxmock.NewResult("SELECT", 1))
+		mock.ExpectExec("INSERT INTO timetable\\.execution_log").
+			WithArgs(append(anyArgs(11), loggedParams, pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg())...).
+			WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	}
+
+	ref := `[{"$secret": "pw"}]`
+	expectTask(ref, "hunter2", `[{"$secret":"pw"}]`)
+	task := &pgengine.ChainTask{Kind: "SQL", Command: "SELECT $1"}
+	assert.NoError(t, sch.executeTask(t.Context(), mock, task, chainResults{}))
+	assert.NoError(t, mock.ExpectationsWereMet(), "secret reference should be logged instead of the masked value")
+
+	expectTask(`["foo"]`, map[string]any{"$secret": "pw"}, `[{"$secret": "******"}]`)
+	task = &pgengine.ChainTask{Kind: "SQL", Command: "SELECT $1", Override: []byte(ref)}
+	assert.NoError(t, sch.executeTask(t.Context(), mock, task, chainResults{}))
+	assert.NoError(t, mock.ExpectationsWereMet(), "secret reference of the override must not be resolved")
+	assert.Empty(t, task.Secrets)
+
+	mock.ExpectQuery("SELECT value FROM timetable\\.parameter").WithArgs(0).
+		WillReturnRows(pgxmock.NewRows([]string{"value"}).AddRow(`{"username": "bob", "password": {"$secret": "pw"}, "serverhost": "smtp"}`))
+	task = &pgengine.ChainTask{Kind: "BUILTIN", Command: "SendMail", Override: []byte(`{"serverhost": "evil"}`)}
+	assert.ErrorContains(t, sch.executeTask(t.Context(), mock, task, chainResults{}), "referring to secrets cannot be overridden")
+	assert.True(t, task.StartedAt.IsZero(), "task must not be started")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
 func TestExecuteOnErrorHandler(t *testing.T) {
 	c := Chain{ChainID: 42, OnError: "FOO"}
 	mock, err := pgxmock.NewPool()
@@ -148,9 +254,81 @@ func TestExecuteOnErrorHandler(t *testing.T) {
 		mock.ExpectQuery("SELECT").WillReturnRows(pgxmock.NewRows([]string{"txid"}).AddRow(int64(42)))
 		mock.ExpectExec("SELECT set_config").WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnResult(pgxmock.NewResult("SELECT", 1))
 		mock.ExpectExec("FOO").WillReturnError(errors.New("Syntax error near FOO"))
-		mock.ExpectCommit()
+		mock.ExpectRollback()
 		sch.executeOnErrorHandler(context.Background(), c)
 	})
 
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
+
+func TestCheckLeadership(t *testing.T) {
+	newScheduler := func(checkLease func(context.Context) error) *Scheduler {
+		sch := New(pgengine.NewDB(nil, "-c", "scheduler_unit_test"), log.Init(config.LoggingOpts{LogLevel: "panic", LogDBLevel: "none"}), otel.NewNoop())
+		sch.checkLease = checkLease
+		return sch
+	}
+	isLost := func(sch *Scheduler) bool {
+		select {
+		case <-sch.leaderLost:
+			return true
+		default:
+			return false
+		}
+	}
+
+	sch := newScheduler(func(context.Context) error { return errors.New("must not be called") })
+	assert.True(t, sch.checkLeadership(t.Context()), "Recently confirmed leadership is not checked again")
+
+	calls := 0
+	sch = newScheduler(func(context.Context) error {
+		if calls++; calls == 1 {
+			return errors.New("timeout")
+		}
+		return nil
+	})
+	sch.leaderAt = time.Now().Add(-2 * leadershipCacheTTL)
+	assert.True(t, sch.checkLeadership(t.Context()), "Check should be retried within the grace period")
+	assert.Equal(t, 2, calls)
+	assert.False(t, isLost(sch))
+
+	sch = newScheduler(func(context.Context) error { return errors.New("timeout") })
+	sch.leaderAt = time.Now().Add(-leadershipGracePeriod)
+	assert.False(t, sch.checkLeadership(t.Context()), "Leadership is lost after the grace period")
+	assert.True(t, isLost(sch))
+
+	sch = newScheduler(func(context.Context) error { return pgengine.ErrLeadershipLost })
+	sch.leaderAt = time.Time{}
+	assert.False(t, sch.checkLeadership(t.Context()))
+	assert.True(t, isLost(sch))
+	assert.False(t, sch.checkLeadership(t.Context()), "Lost leadership is never checked again")
+}
+
+func TestCheckConnection(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	pge := pgengine.NewDB(mock, "-c", "scheduler_unit_test")
+	sch := New(pge, log.Init(config.LoggingOpts{LogLevel: "panic", LogDBLevel: "none"}), otel.NewNoop())
+
+	assert.True(t, sch.checkLeadership(t.Context()), "Leadership is never lost outside of standby mode")
+	mock.ExpectPing()
+	sch.checkConnection(t.Context())
+	assert.True(t, sch.IsReady())
+
+	mock.ExpectPing().WillReturnError(errors.New("connection lost"))
+	mock.ExpectReset()
+	mock.ExpectPing()
+	mock.ExpectExec("WITH del_ch AS \\(DELETE FROM timetable\\.active_chain").
+		WithArgs("scheduler_unit_test", []int{}).
+		WillReturnResult(pgxmock.NewResult("INSERT", 0))
+	sch.checkConnection(t.Context())
+	assert.True(t, sch.IsReady(), "Scheduler should be ready after reconnect")
+
+	ctx, cancel := context.WithCancel(t.Context())
+	mock.ExpectPing().WillReturnError(errors.New("connection lost"))
+	mock.ExpectReset()
+	mock.ExpectPing().WillReturnError(errors.New("still down"))
+	go func() { time.Sleep(50 * time.Millisecond); cancel() }()
+	sch.checkConnection(ctx)
+	assert.False(t, sch.IsReady(), "Scheduler should not be ready while reconnecting")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
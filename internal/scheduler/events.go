@@ -0,0 +1,161 @@
+package scheduler
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/cybertec-postgresql/pg_timetable/internal/pgengine"
+	"github.com/sirupsen/logrus"
+)
+
+// EventType specifies what happened in the scheduler
+type EventType string
+
+// Types of events published by the scheduler
+const (
+	EventChainStarted  EventType = "chain_started"
+	EventChainFinished EventType = "chain_finished"
+	EventChainFailed   EventType = "chain_failed"
+	EventTaskStarted   EventType = "task_started"
+	EventTaskFinished  EventType = "task_finished"
+	EventLog           EventType = "log"
+)
+
+// EventTypes lists all types of events published by the scheduler
+var EventTypes = []EventType{
+	EventChainStarted,
+	EventChainFinished,
+	EventChainFailed,
+	EventTaskStarted,
+	EventTaskFinished,
+	EventLog,
+}
+
+// Event describes the change of the chain or task state, or the log entry
+type Event struct {
+	ID        uint64            `json:"id"`
+	Type      EventType         `json:"type"`
+	Time      time.Time         `json:"time"`
+	ChainID   int               `json:"chain_id,omitempty"`
+	ChainName string            `json:"chain_name,omitempty"`
+	RunID     int64             `json:"run_id,omitempty"`
+	TaskID    int               `json:"task_id,omitempty"`
+	TaskName  string            `json:"task_name,omitempty"`
+	Status    string            `json:"status,omitempty"` // e.g. SUCCEEDED, FAILED or CANCELLED for finished chains
+	Error     string            `json:"error,omitempty"`
+	Level     string            `json:"level,omitempty"` // log entries only
+	Message   string            `json:"message,omitempty"`
+	Fields    map[string]string `json:"fields,omitempty"`
+}
+
+// EventBus delivers scheduler events to subscribers, e.g. the REST API stream, metrics or notifications.
+// Publishing never blocks: events are dropped for subscribers not reading fast enough
+type EventBus struct {
+	mu      sync.RWMutex
+	subs    map[chan Event]struct{}
+	lastID  atomic.Uint64
+	dropped atomic.Uint64
+}
+
+// NewEventBus returns the bus without subscribers
+func NewEventBus() *EventBus {
+	return &EventBus{subs: make(map[chan Event]struct{})}
+}
+
+// Subscribe returns the channel of events buffered for size events, call the returned function to unsubscribe
+func (b *EventBus) Subscribe(size int) (<-chan Event, func()) {
+	ch := make(chan Event, size)
+	b.mu.Lock()
+	b.subs[ch] = struct{}{}
+	b.mu.Unlock()
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subs, ch)
+			b.mu.Unlock()
+			close(ch)
+		})
+	}
+}
+
+// HasSubscribers returns true if anybody listens to the events
+func (b *EventBus) HasSubscribers() bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return len(b.subs) > 0
+}
+
+// Dropped returns the number of events not delivered to slow subscribers
+func (b *EventBus) Dropped() uint64 {
+	return b.dropped.Load()
+}
+
+// Publish assigns the ID and the time to the event and sends it to all subscribers
+func (b *EventBus) Publish(e Event) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if len(b.subs) == 0 {
+		return
+	}
+	e.ID = b.lastID.Add(1)
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	for ch := range b.subs {
+		select {
+		case ch <- e:
+		default:
+			b.dropped.Add(1)
+		}
+	}
+}
+
+// Events returns the bus of the scheduler events
+func (sch *Scheduler) Events() *EventBus {
+	return sch.events
+}
+
+func (sch *Scheduler) publishChain(t EventType, chain Chain, runID int64, status string, err string) {
+	sch.events.Publish(Event{Type: t, ChainID: chain.ChainID, ChainName: chain.ChainName, RunID: runID, Status: status, Error: err})
+}
+
+func (sch *Scheduler) publishTask(t EventType, task pgengine.ChainTask, status string, err error) {
+	e := Event{Type: t, ChainID: task.ChainID, RunID: task.RunID, TaskID: task.TaskID, TaskName: task.TaskName, Status: status}
+	if err != nil {
+		e.Error = sch.pgengine.Redact(err.Error())
+	}
+	sch.events.Publish(e)
+}
+
+// EventLogHook publishes log entries to the event bus. It must be added after the redaction hook,
+// so subscribers receive masked entries
+type EventLogHook struct {
+	*EventBus
+}
+
+// Levels returns all levels, the logger filters entries by its own level before firing hooks
+func (hook EventLogHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire publishes the log entry
+func (hook EventLogHook) Fire(entry *logrus.Entry) error {
+	if !hook.HasSubscribers() {
+		return nil
+	}
+	e := Event{Type: EventLog, Time: entry.Time, Level: entry.Level.String(), Message: entry.Message}
+	if chain, ok := entry.Data["chain"].(Chain); ok {
+		e.ChainID, e.ChainName = chain.ChainID, chain.ChainName
+	}
+	if len(entry.Data) > 0 {
+		e.Fields = make(map[string]string, len(entry.Data))
+		for k, v := range entry.Data {
+			e.Fields[k] = fmt.Sprint(v)
+		}
+	}
+	hook.Publish(e)
+	return nil
+}
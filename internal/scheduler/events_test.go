@@ -0,0 +1,84 @@
+package scheduler
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/cybertec-postgresql/pg_timetable/internal/config"
+	"github.com/cybertec-postgresql/pg_timetable/internal/log"
+	"github.com/cybertec-postgresql/pg_timetable/internal/otel"
+	"github.com/cybertec-postgresql/pg_timetable/internal/pgengine"
+	"github.com/pashagolub/pgxmock/v5"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventBus(t *testing.T) {
+	b := NewEventBus()
+	assert.False(t, b.HasSubscribers())
+	b.Publish(Event{Type: EventChainStarted}) // nobody listens
+
+	ch, unsubscribe := b.Subscribe(1)
+	assert.True(t, b.HasSubscribers())
+	b.Publish(Event{Type: EventChainStarted, ChainID: 42})
+	b.Publish(Event{Type: EventChainFinished, ChainID: 42}) // buffer is full
+	e := <-ch
+	assert.Equal(t, EventChainStarted, e.Type)
+	assert.EqualValues(t, 1, e.ID)
+	assert.False(t, e.Time.IsZero())
+	assert.EqualValues(t, 1, b.Dropped())
+
+	unsubscribe()
+	unsubscribe() // second call is no-op
+	_, ok := <-ch
+	assert.False(t, ok, "channel must be closed")
+	assert.False(t, b.HasSubscribers())
+}
+
+func TestEventLogHook(t *testing.T) {
+	b := NewEventBus()
+	hook := EventLogHook{EventBus: b}
+	assert.Equal(t, logrus.AllLevels, hook.Levels())
+	ch, unsubscribe := b.Subscribe(1)
+	defer unsubscribe()
+
+	logger := logrus.New()
+	logger.SetLevel(logrus.InfoLevel)
+	logger.AddHook(hook)
+	logger.Debug("filtered by level")
+	logger.WithField("chain", Chain{ChainID: 42, ChainName: "foo"}).Info("Starting chain")
+	e := <-ch
+	assert.Equal(t, EventLog, e.Type)
+	assert.Equal(t, "info", e.Level)
+	assert.Equal(t, "Starting chain", e.Message)
+	assert.Equal(t, 42, e.ChainID)
+	assert.Equal(t, "42|foo", e.Fields["chain"])
+}
+
+func TestExecuteChainEvents(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	pge := pgengine.NewDB(mock, "-c", "scheduler_unit_test")
+	sch := New(pge, log.Init(config.LoggingOpts{LogLevel: "panic", LogDBLevel: "none"}), otel.NewNoop())
+	ch, unsubscribe := sch.Events().Subscribe(10)
+	defer unsubscribe()
+
+	mock.ExpectQuery("INSERT INTO timetable\\.chain_run").
+		WithArgs(42, "manual", nil, "scheduler_unit_test", nil, int64(0)).
+		WillReturnRows(pgxmock.NewRows([]string{"run_id"}).AddRow(int64(7)))
+	mock.ExpectBegin().WillReturnError(errors.New("expected"))
+	mock.ExpectExec("UPDATE timetable\\.chain_run").
+		WithArgs(int64(7), pgengine.RunFailed, "expected").
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	sch.executeChain(t.Context(), Chain{ChainID: 42, ChainName: "foo"})
+	require.NoError(t, mock.ExpectationsWereMet())
+
+	require.Len(t, ch, 2)
+	e := <-ch
+	assert.Equal(t, Event{ID: e.ID, Type: EventChainStarted, Time: e.Time, ChainID: 42, ChainName: "foo", RunID: 7}, e)
+	e = <-ch
+	assert.Equal(t, EventChainFailed, e.Type)
+	assert.Equal(t, pgengine.RunFailed, e.Status)
+	assert.Equal(t, "expected", e.Error)
+}
@@ -12,6 +12,7 @@ type IntervalChain = pgengine.IntervalChain
 
 // SendIntervalChain sends interval chain to the channel for workers
 func (sch *Scheduler) SendIntervalChain(c IntervalChain) {
+	c.Trigger, c.ScheduledAt = "interval", time.Now()
 	select {
 	case sch.ichainsChan <- c:
 		sch.l.WithField("chain", c).Debug("Sent interval chain to the execution channel")
@@ -23,7 +24,8 @@ func (sch *Scheduler) SendIntervalChain(c IntervalChain) {
 func (sch *Scheduler) isValid(ichain IntervalChain) bool {
 	sch.intervalChainMutex.Lock()
 	defer sch.intervalChainMutex.Unlock()
-	return (IntervalChain{}) != sch.intervalChains[ichain.ChainID]
+	_, ok := sch.intervalChains[ichain.ChainID]
+	return ok
 }
 
 func (sch *Scheduler) reschedule(ctx context.Context, ichain IntervalChain) {
@@ -57,7 +59,7 @@ func (sch *Scheduler) retrieveIntervalChainsAndRun(ctx context.Context) {
 
 	// update chains from the database and send to working channel new one
 	for _, ichain := range ichains {
-		if (IntervalChain{}) == sch.intervalChains[ichain.ChainID] {
+		if _, ok := sch.intervalChains[ichain.ChainID]; !ok {
 			sch.SendIntervalChain(ichain)
 		}
 		sch.intervalChains[ichain.ChainID] = ichain
@@ -78,10 +80,21 @@ func (sch *Scheduler) intervalChainWorker(ctx context.Context, ichains <-chan In
 				}
 				chainL := sch.l.WithField("chain", ichain)
 				chainContext := log.WithLogger(ctx, chainL)
+				if !sch.checkLeadership(ctx) {
+					chainL.Info("Leadership lost, chain skipped")
+					continue
+				}
 				chainL.Info("Starting chain")
 				if !ichain.RepeatAfter {
 					go sch.reschedule(chainContext, ichain)
 				}
+				if !sch.pgengine.ClaimIntervalChain(ctx, ichain) {
+					chainL.Debug("Chain run is claimed by another client of the cluster")
+					if ichain.RepeatAfter {
+						go sch.reschedule(chainContext, ichain)
+					}
+					continue
+				}
 				if !sch.pgengine.InsertChainRunStatus(ctx, ichain.ChainID, ichain.MaxInstances) {
 					chainL.Info("Cannot proceed. Sleeping")
 					if ichain.RepeatAfter {
@@ -89,9 +102,11 @@ func (sch *Scheduler) intervalChainWorker(ctx context.Context, ichains <-chan In
 					}
 					continue
 				}
+				sch.busyIntervalWorkers.Add(1)
 				sch.Lock(ichain.ExclusiveExecution)
 				sch.executeChain(chainContext, ichain.Chain)
 				sch.Unlock(ichain.ExclusiveExecution)
+				sch.busyIntervalWorkers.Add(-1)
 				if ichain.RepeatAfter {
 					go sch.reschedule(chainContext, ichain)
 				}
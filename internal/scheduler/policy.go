@@ -0,0 +1,121 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+
+	"github.com/cybertec-postgresql/pg_timetable/internal/log"
+	"gopkg.in/yaml.v3"
+)
+
+// ProgramRule describes an executable allowed to be run by PROGRAM tasks and Copy*Program builtins
+type ProgramRule struct {
+	Command string   `yaml:"command"` // absolute path or glob pattern, e.g. /usr/bin/* or /opt/scripts/*.sh
+	Args    []string `yaml:"args"`    // regular expressions every argument must match, any arguments allowed if empty
+	args    []*regexp.Regexp
+}
+
+// ProgramPolicy is the allow-list of executables. Nothing is allowed if there are no rules
+type ProgramPolicy struct {
+	Rules []ProgramRule `yaml:"allow"`
+}
+
+// LoadProgramPolicy reads and validates the policy file
+func LoadProgramPolicy(filename string) (*ProgramPolicy, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var p ProgramPolicy
+	if err := yaml.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("failed to parse program policy: %w", err)
+	}
+	for i := range p.Rules {
+		if err := p.Rules[i].compile(); err != nil {
+			return nil, fmt.Errorf("program policy rule %d: %w", i+1, err)
+		}
+	}
+	return &p, nil
+}
+
+func (r *ProgramRule) compile() error {
+	if !filepath.IsAbs(r.Command) {
+		return fmt.Errorf("command must be an absolute path: %q", r.Command)
+	}
+	if _, err := filepath.Match(r.Command, ""); err != nil {
+		return fmt.Errorf("invalid command pattern %q: %w", r.Command, err)
+	}
+	r.args = make([]*regexp.Regexp, 0, len(r.Args))
+	for _, a := range r.Args {
+		re, err := regexp.Compile("^(?:" + a + ")$")
+		if err != nil {
+			return fmt.Errorf("invalid argument pattern %q: %w", a, err)
+		}
+		r.args = append(r.args, re)
+	}
+	return nil
+}
+
+func (r *ProgramRule) matchArgs(args []string) bool {
+	if len(r.args) == 0 {
+		return true
+	}
+	for _, arg := range args {
+		matched := false
+		for _, re := range r.args {
+			if matched = re.MatchString(arg); matched {
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// Allow returns an error if the policy does not permit to execute command with args.
+// The command is resolved using PATH the same way exec.Command does before matching.
+func (p *ProgramPolicy) Allow(command string, args []string) error {
+	path, err := exec.LookPath(command)
+	if err != nil {
+		return fmt.Errorf("program %q is not allowed: %w", command, err)
+	}
+	if path, err = filepath.Abs(path); err != nil {
+		return fmt.Errorf("program %q is not allowed: %w", command, err)
+	}
+	return p.allow(path, args)
+}
+
+func (p *ProgramPolicy) allow(path string, args []string) error {
+	for _, r := range p.Rules {
+		if ok, _ := filepath.Match(r.Command, path); ok && r.matchArgs(args) {
+			return nil
+		}
+	}
+	return fmt.Errorf("program %q with arguments %q is not allowed by policy", path, args)
+}
+
+// checkProgram verifies the command executed by the scheduler against the program policy if any
+func (sch *Scheduler) checkProgram(ctx context.Context, command string, args []string) error {
+	if sch.programPolicy == nil {
+		return nil
+	}
+	return sch.enforcePolicy(ctx, command, args, sch.programPolicy.Allow(command, args))
+}
+
+// enforcePolicy logs the rejected attempt as security event
+func (sch *Scheduler) enforcePolicy(ctx context.Context, command string, args []string, err error) error {
+	if err != nil {
+		log.GetLogger(ctx).WithError(err).
+			WithField("security_event", "program_rejected").
+			WithField("command", command).
+			WithField("args", args).
+			Error("Program execution rejected by policy")
+	}
+	return err
+}
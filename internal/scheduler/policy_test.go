@@ -0,0 +1,87 @@
+package scheduler
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/cybertec-postgresql/pg_timetable/internal/config"
+	"github.com/cybertec-postgresql/pg_timetable/internal/log"
+	"github.com/cybertec-postgresql/pg_timetable/internal/otel"
+	"github.com/cybertec-postgresql/pg_timetable/internal/pgengine"
+	"github.com/pashagolub/pgxmock/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writePolicy(t *testing.T, content string) string {
+	fname := filepath.Join(t.TempDir(), "policy.yaml")
+	require.NoError(t, os.WriteFile(fname, []byte(content), 0600))
+	return fname
+}
+
+func TestLoadProgramPolicy(t *testing.T) {
+	_, err := LoadProgramPolicy("foo.bar.baz.yaml")
+	assert.Error(t, err, "Missing file should fail")
+
+	_, err = LoadProgramPolicy(writePolicy(t, "allow: foo"))
+	assert.ErrorContains(t, err, "failed to parse program policy")
+
+	_, err = LoadProgramPolicy(writePolicy(t, "allow:\n  - command: bash"))
+	assert.ErrorContains(t, err, "must be an absolute path")
+
+	_, err = LoadProgramPolicy(writePolicy(t, "allow:\n  - command: /bin/[\n"))
+	assert.ErrorContains(t, err, "invalid command pattern")
+
+	_, err = LoadProgramPolicy(writePolicy(t, "allow:\n  - command: /bin/ls\n    args: ['(']"))
+	assert.ErrorContains(t, err, "invalid argument pattern")
+
+	p, err := LoadProgramPolicy(writePolicy(t, "allow:\n  - command: /bin/ls\n    args: ['-l', '/tmp/.*']"))
+	assert.NoError(t, err)
+	assert.Len(t, p.Rules, 1)
+}
+
+func TestProgramPolicyAllow(t *testing.T) {
+	dir := t.TempDir()
+	script := filepath.Join(dir, "backup.sh")
+	require.NoError(t, os.WriteFile(script, []byte("#!/bin/sh\n"), 0700))
+
+	p, err := LoadProgramPolicy(writePolicy(t, `allow:
+  - command: `+filepath.Join(dir, "*.sh")+`
+    args: ['--db=\w+', '-v']
+`))
+	require.NoError(t, err)
+
+	assert.NoError(t, p.Allow(script, nil), "No arguments should be allowed")
+	assert.NoError(t, p.Allow(script, []string{"--db=sales", "-v"}))
+	assert.Error(t, p.Allow(script, []string{"--db=sales; rm -rf /"}), "Argument must match the whole pattern")
+	assert.Error(t, p.Allow(script, []string{"-x"}), "Unknown argument should be rejected")
+	assert.Error(t, p.Allow("no_such_program_for_sure", nil), "Unresolvable command should be rejected")
+	assert.Error(t, (&ProgramPolicy{}).Allow(script, nil), "Empty policy should reject everything")
+}
+
+func TestCheckProgram(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	pge := pgengine.NewDB(mock, "--log-database-level=none", "--program-policy=foo.bar.baz.yaml")
+	sch := New(pge, log.Init(config.LoggingOpts{LogLevel: "panic", LogDBLevel: "none"}), otel.NewNoop())
+	assert.NotNil(t, sch.programPolicy, "Broken policy should fail closed")
+	assert.Error(t, sch.checkProgram(context.Background(), "sh", nil))
+	assert.Error(t, sch.ExecuteProgramCommand(context.Background(), &pgengine.ChainTask{Command: "sh"}, nil))
+	_, err = taskCopyToProgram(context.Background(), sch, `{"sql": "COPY foo TO STDOUT", "cmd": "sh"}`)
+	assert.ErrorContains(t, err, "not allowed")
+	_, err = taskCopyFromProgram(context.Background(), sch, `{"sql": "COPY foo FROM STDIN", "cmd": "sh"}`)
+	assert.ErrorContains(t, err, "not allowed")
+
+	sh, err := exec.LookPath("sh")
+	require.NoError(t, err)
+	sch.programPolicy, err = LoadProgramPolicy(writePolicy(t, "allow:\n  - command: "+sh+"\n"))
+	require.NoError(t, err)
+	_, err = taskCopyToProgram(context.Background(), sch, `{"sql": "COPY foo TO STDOUT", "cmd": "sh"}`)
+	assert.NotContains(t, err.Error(), "not allowed", "Program of COPY is resolved using PATH like PROGRAM tasks")
+
+	sch.programPolicy = nil
+	assert.NoError(t, sch.checkProgram(context.Background(), "sh", nil), "No policy means no restrictions")
+}
@@ -0,0 +1,110 @@
+package scheduler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/cybertec-postgresql/pg_timetable/internal/pgengine"
+)
+
+// chainResults holds captured results of the already executed tasks of the chain
+// keyed by the task name, or by "task_<id>" if the task has no name
+type chainResults map[string]any
+
+var resultFuncs = template.FuncMap{"json": marshalJSON}
+
+// marshalJSON returns JSON encoding of v without escaping HTML characters, e.g. for email bodies
+func marshalJSON(v any) (string, error) {
+	var buf bytes.Buffer
+	e := json.NewEncoder(&buf)
+	e.SetEscapeHTML(false)
+	if err := e.Encode(v); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
+
+// add stores the captured result of the task if any
+func (r chainResults) add(task *pgengine.ChainTask) error {
+	if task.Result == nil {
+		return nil
+	}
+	var rows any
+	if err := json.Unmarshal(task.Result, &rows); err != nil {
+		return err
+	}
+	key := task.TaskName
+	if key == "" {
+		key = "task_" + strconv.Itoa(task.TaskID)
+	}
+	r[key] = rows
+	return nil
+}
+
+// templateKey marks the parameter value rendered as a text template, e.g. {"$template": "{{ json .Results.check }}"}
+const templateKey = "$template"
+
+// render replaces template values of JSON parameters with text templates executed with the captured results
+// available as .Results, e.g. {"msgbody": {"$template": "Found {{ len .Results.check }} rows"}}.
+// Parameters without template values are left untouched
+func (r chainResults) render(paramValues []string) ([]string, error) {
+	rendered := make([]string, len(paramValues))
+	for i, val := range paramValues {
+		if !strings.Contains(val, templateKey) {
+			rendered[i] = val
+			continue
+		}
+		var param any
+		d := json.NewDecoder(strings.NewReader(val))
+		d.UseNumber()
+		if err := d.Decode(&param); err != nil {
+			return nil, fmt.Errorf("failed to parse parameter %s: %w", val, err)
+		}
+		param, err := r.renderValue(param)
+		if err != nil {
+			return nil, err
+		}
+		if rendered[i], err = marshalJSON(param); err != nil {
+			return nil, err
+		}
+	}
+	return rendered, nil
+}
+
+func (r chainResults) renderValue(v any) (any, error) {
+	var err error
+	switch v := v.(type) {
+	case []any:
+		for i := range v {
+			if v[i], err = r.renderValue(v[i]); err != nil {
+				return nil, err
+			}
+		}
+	case map[string]any:
+		if text, ok := v[templateKey].(string); ok && len(v) == 1 {
+			return r.execute(text)
+		}
+		for k := range v {
+			if v[k], err = r.renderValue(v[k]); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return v, nil
+}
+
+func (r chainResults) execute(text string) (string, error) {
+	tmpl, err := template.New("param").Funcs(resultFuncs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse parameter template: %w", err)
+	}
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, struct{ Results chainResults }{r}); err != nil {
+		return "", fmt.Errorf("failed to render parameter template: %w", err)
+	}
+	return sb.String(), nil
+}
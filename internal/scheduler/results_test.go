@@ -0,0 +1,51 @@
+package scheduler
+
+import (
+	"testing"
+
+	"github.com/cybertec-postgresql/pg_timetable/internal/pgengine"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChainResults(t *testing.T) {
+	r := chainResults{}
+	params := []string{`{"msgbody": "{{ .Results.check }}"}`}
+	rendered, err := r.render(params)
+	assert.NoError(t, err)
+	assert.Equal(t, params, rendered, "Nothing should be rendered without template values")
+
+	assert.NoError(t, r.add(&pgengine.ChainTask{TaskName: "skip"}))
+	assert.Empty(t, r, "Tasks without captured result should be skipped")
+	assert.Error(t, r.add(&pgengine.ChainTask{TaskID: 1, Result: []byte("foo")}))
+	assert.NoError(t, r.add(&pgengine.ChainTask{TaskName: "check", Result: []byte(`[{"cnt": 42, "name": "<b>"}]`)}))
+	assert.NoError(t, r.add(&pgengine.ChainTask{TaskID: 7, Result: []byte(`[]`)}))
+
+	rendered, err = r.render([]string{
+		`["plain", 1, "{{ len .Results.check }}"]`,
+		`{"msgbody": {"$template": "{{ json .Results.check }}"}, "big": 12345678901234567890}`,
+		`[{"$template": "{{ range .Results.check }}{{ .name }}={{ .cnt }}{{ end }}"}, {"$template": "{{ len .Results.task_7 }}"}, 3.5]`,
+		`[{"$template": "{{ len .Results.check }}", "other": 1}]`,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		`["plain", 1, "{{ len .Results.check }}"]`,
+		`{"big":12345678901234567890,"msgbody":"[{\"cnt\":42,\"name\":\"<b>\"}]"}`,
+		`["<b>=42","0",3.5]`,
+		`[{"$template":"{{ len .Results.check }}","other":1}]`,
+	}, rendered)
+
+	_, err = r.render([]string{`[{"$template": "{{ .Results.unknown }}"}]`})
+	assert.ErrorContains(t, err, "failed to render")
+	_, err = r.render([]string{`{"a": [{"$template": "{{ .Results.check "}]}`})
+	assert.ErrorContains(t, err, "failed to parse parameter template")
+	_, err = r.render([]string{`{{ .Results.check }} $template`})
+	assert.ErrorContains(t, err, "failed to parse parameter")
+}
+
+func TestPrepareParams(t *testing.T) {
+	r := chainResults{"check": []any{1, 2}}
+	task := &pgengine.ChainTask{Override: []byte(`{"b": {"$template": "{{ len .Results.check }}"}}`)}
+	params, err := prepareParams([]string{`{"a": {"$template": "{{ len .Results.check }}"}, "b": "stored"}`}, task, r)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{`{"a":"2","b":{"$template":"{{ len .Results.check }}"}}`}, params, "Overrides must not be rendered")
+}
@@ -0,0 +1,27 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+)
+
+// the period of log tables cleanup
+const retentionTimeout = time.Hour
+
+// retentionLoop enforces retention settings of log tables right away and every retentionTimeout after
+func (sch *Scheduler) retentionLoop(ctx context.Context) {
+	ticker := time.NewTicker(retentionTimeout)
+	defer ticker.Stop()
+	for {
+		sch.l.Debug("Applying retention to log tables...")
+		if err := sch.pgengine.ApplyRetention(ctx); err != nil {
+			sch.l.WithError(err).Error("Cannot apply retention to log tables")
+		}
+		select {
+		case <-ticker.C:
+			// pass
+		case <-ctx.Done():
+			return
+		}
+	}
+}
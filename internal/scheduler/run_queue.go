@@ -0,0 +1,82 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	pgx "github.com/jackc/pgx/v5"
+)
+
+// the longest period between run queue checks, entries added without notification are started with this delay at most
+const runQueueTimeout = refetchTimeout * time.Second
+
+// wakeRunQueue makes the run queue processed right away, e.g. after the START notification
+func (sch *Scheduler) wakeRunQueue() {
+	select {
+	case sch.queueSignal <- struct{}{}:
+	default: // already signalled
+	}
+}
+
+// runQueueLoop starts chains from the run queue once they are due
+func (sch *Scheduler) runQueueLoop(ctx context.Context) {
+	for {
+		timer := time.NewTimer(sch.processRunQueue(ctx))
+		select {
+		case <-timer.C:
+			// pass
+		case <-sch.queueSignal:
+			timer.Stop()
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		}
+	}
+}
+
+// processRunQueue sends due chains of the run queue to workers and returns the time until the next queued start.
+// Entries failed to start are put back to the queue and retried after healthCheckTimeout
+func (sch *Scheduler) processRunQueue(ctx context.Context) time.Duration {
+	if sch.disconnected.Load() {
+		return healthCheckTimeout
+	}
+	runs, err := sch.pgengine.ClaimQueuedChains(ctx)
+	if err != nil {
+		sch.l.WithError(err).Error("Could not retrieve chains from the run queue")
+		return healthCheckTimeout
+	}
+	var retry bool
+	for _, r := range runs {
+		var c Chain
+		l := sch.l.WithField("queue_id", r.QueueID)
+		err := sch.pgengine.SelectChain(ctx, &c, r.ChainID)
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			l.Errorf("Cannot start chain with ID: %d. No chain found for the client", r.ChainID)
+			sch.pgengine.DeleteQueuedChain(ctx, r.QueueID)
+			continue
+		case err != nil:
+			l.WithError(err).Errorf("Cannot start chain with ID: %d", r.ChainID)
+		default:
+			c.Trigger, c.ScheduledAt, c.Overrides, c.QueueID = "manual", r.StartAt, r.Overrides, r.QueueID
+			if sch.SendChain(c) {
+				continue
+			}
+		}
+		sch.pgengine.ReleaseQueuedChain(ctx, r.QueueID)
+		retry = true
+	}
+	if retry {
+		return healthCheckTimeout
+	}
+	next, ok, err := sch.pgengine.NextQueuedStart(ctx)
+	if err != nil {
+		sch.l.WithError(err).Error("Could not retrieve the next start from the run queue")
+		return healthCheckTimeout
+	}
+	if !ok {
+		return runQueueTimeout
+	}
+	return min(next, runQueueTimeout)
+}
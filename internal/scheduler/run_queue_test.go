@@ -0,0 +1,113 @@
+package scheduler
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cybertec-postgresql/pg_timetable/internal/config"
+	"github.com/cybertec-postgresql/pg_timetable/internal/log"
+	"github.com/cybertec-postgresql/pg_timetable/internal/otel"
+	"github.com/cybertec-postgresql/pg_timetable/internal/pgengine"
+	"github.com/pashagolub/pgxmock/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+var chainColumns = []string{"chain_id", "chain_name", "self_destruct", "exclusive_execution", "timeout", "max_instances", "on_error"}
+
+func TestProcessRunQueue(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	pge := pgengine.NewDB(mock, "-c", "scheduler_unit_test")
+	sch := New(pge, log.Init(config.LoggingOpts{LogLevel: "panic", LogDBLevel: "none"}), otel.NewNoop())
+	startAt := time.Now().Add(-time.Second)
+	secs := 5.5
+
+	queueColumns := []string{"queue_id", "chain_id", "start_at", "overrides"}
+
+	t.Run("Check due chains are sent to workers", func(t *testing.T) {
+		mock.ExpectQuery("UPDATE timetable\\.run_queue SET claimed_by").WithArgs("scheduler_unit_test", pge.Getsid()).
+			WillReturnRows(pgxmock.NewRows(queueColumns).
+				AddRow(int64(1), 42, startAt, pgengine.ParamOverrides{"foo": []byte(`{"a": 1}`)}).AddRow(int64(2), 24, startAt, nil))
+		mock.ExpectQuery("SELECT chain_id").WithArgs("scheduler_unit_test", 42).
+			WillReturnRows(pgxmock.NewRows(chainColumns).AddRow(42, "foo", false, false, 0, 16, ""))
+		mock.ExpectQuery("SELECT chain_id").WithArgs("scheduler_unit_test", 24).
+			WillReturnRows(pgxmock.NewRows(chainColumns))
+		mock.ExpectExec("DELETE FROM timetable\\.run_queue").WithArgs(int64(2)).
+			WillReturnResult(pgxmock.NewResult("DELETE", 1))
+		mock.ExpectQuery("SELECT EXTRACT\\(epoch FROM min\\(start_at\\)").WithArgs("scheduler_unit_test", pge.Getsid()).
+			WillReturnRows(pgxmock.NewRows([]string{"secs"}).AddRow(&secs))
+		assert.Equal(t, 5500*time.Millisecond, sch.processRunQueue(t.Context()))
+		assert.Len(t, sch.chainsChan, 1)
+		c := <-sch.chainsChan
+		assert.Equal(t, 42, c.ChainID)
+		assert.Equal(t, "manual", c.Trigger)
+		assert.Equal(t, startAt, c.ScheduledAt)
+		assert.EqualValues(t, 1, c.QueueID)
+		assert.JSONEq(t, `{"a": 1}`, string(c.Overrides["foo"]))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Check entries are put back to the run queue if chain cannot be started", func(t *testing.T) {
+		mock.ExpectQuery("UPDATE timetable\\.run_queue SET claimed_by").WithArgs("scheduler_unit_test", pge.Getsid()).
+			WillReturnRows(pgxmock.NewRows(queueColumns).AddRow(int64(3), 42, startAt, nil))
+		mock.ExpectQuery("SELECT chain_id").WithArgs("scheduler_unit_test", 42).
+			WillReturnError(errors.New("connection lost"))
+		mock.ExpectExec("UPDATE timetable\\.run_queue SET claimed_by = NULL").WithArgs(int64(3)).
+			WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+		assert.Equal(t, healthCheckTimeout, sch.processRunQueue(t.Context()))
+		assert.Empty(t, sch.chainsChan)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Check empty run queue", func(t *testing.T) {
+		mock.ExpectQuery("UPDATE timetable\\.run_queue SET claimed_by").WithArgs("scheduler_unit_test", pge.Getsid()).
+			WillReturnRows(pgxmock.NewRows(queueColumns))
+		mock.ExpectQuery("SELECT EXTRACT\\(epoch FROM min\\(start_at\\)").WithArgs("scheduler_unit_test", pge.Getsid()).
+			WillReturnRows(pgxmock.NewRows([]string{"secs"}).AddRow(nil))
+		assert.Equal(t, runQueueTimeout, sch.processRunQueue(t.Context()))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Check run queue if sql fails", func(t *testing.T) {
+		mock.ExpectQuery("UPDATE timetable\\.run_queue SET claimed_by").WithArgs("scheduler_unit_test", pge.Getsid()).
+			WillReturnError(errors.New("error"))
+		assert.Equal(t, healthCheckTimeout, sch.processRunQueue(t.Context()))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestStartChain(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	pge := pgengine.NewDB(mock, "-c", "scheduler_unit_test")
+	sch := New(pge, log.Init(config.LoggingOpts{LogLevel: "panic", LogDBLevel: "none"}), otel.NewNoop())
+
+	mock.ExpectQuery("SELECT chain_id").WithArgs("scheduler_unit_test", 42).
+		WillReturnRows(pgxmock.NewRows(chainColumns).AddRow(42, "foo", false, false, 0, 16, ""))
+	mock.ExpectQuery("SELECT timetable\\.notify_chain_start").WithArgs(42, "scheduler_unit_test", 0.0, nil).
+		WillReturnRows(pgxmock.NewRows([]string{"queue_id"}).AddRow(int64(1)))
+	assert.NoError(t, sch.StartChain(t.Context(), 42, nil))
+	assert.Len(t, sch.queueSignal, 1)
+
+	mock.ExpectQuery("SELECT chain_id").WithArgs("scheduler_unit_test", 24).
+		WillReturnError(errors.New("chain not found"))
+	assert.ErrorContains(t, sch.StartChain(t.Context(), 24, nil), "cannot start chain with ID: 24")
+
+	overrides := pgengine.ParamOverrides{"report": []byte(`{"day": "2026-01-01"}`)}
+	expectTask := func(taskName string) {
+		mock.ExpectQuery("SELECT chain_id").WithArgs("scheduler_unit_test", 42).
+			WillReturnRows(pgxmock.NewRows(chainColumns).AddRow(42, "foo", false, false, 0, 16, ""))
+		mock.ExpectQuery("FROM timetable\\.task WHERE chain_id").WithArgs(42).WillReturnRows(
+			pgxmock.NewRows([]string{"task_id", "task_name", "command", "kind", "run_as", "ignore_error", "autonomous",
+				"database_connection", "connection_name", "timeout", "success_codes", "warning_codes", "capture_rows", "assertion"}).
+				AddRow(1, taskName, "SELECT 1", "SQL", "", false, false, "", "", 0, []int{}, []int{}, 0, nil))
+	}
+	expectTask("report")
+	mock.ExpectQuery("SELECT timetable\\.notify_chain_start").WithArgs(42, "scheduler_unit_test", 0.0, `{"report":{"day":"2026-01-01"}}`).
+		WillReturnRows(pgxmock.NewRows([]string{"queue_id"}).AddRow(int64(2)))
+	assert.NoError(t, sch.StartChain(t.Context(), 42, overrides))
+	expectTask("other")
+	assert.ErrorContains(t, sch.StartChain(t.Context(), 42, overrides), `override "report" doesn't match any task`)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -2,7 +2,10 @@ package scheduler
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/cybertec-postgresql/pg_timetable/internal/config"
@@ -14,6 +17,19 @@ import (
 // the main loop period. Should be 60 (sec) for release configuration. Set to 10 (sec) for debug purposes
 const refetchTimeout = 60
 
+// the period of the database connection health check
+const healthCheckTimeout = 5 * time.Second
+
+// the confirmed leadership in the standby mode is trusted for this period before it is checked again
+const leadershipCacheTTL = time.Second
+
+// the leadership may stay unconfirmed, e.g. due to network issues, for this period before it is considered lost.
+// The server notices the lost lease session in about the same time and releases the leader lock for standby clients
+const leadershipGracePeriod = 10 * time.Second
+
+// the period of retries if the leadership cannot be checked
+const leadershipRetryInterval = time.Second
+
 // the min capacity of chains channels
 const minChannelCapacity = 1024
 
@@ -27,6 +43,8 @@ const (
 	ContextCancelledStatus
 	// Shutdown specifies proper termination of the session
 	ShutdownStatus
+	// LeadershipLostStatus specifies the lease session of the client in the standby mode is lost
+	LeadershipLostStatus
 )
 
 // Scheduler is the main class for running the tasks
@@ -36,32 +54,60 @@ type Scheduler struct {
 	chainsChan  chan Chain         // channel for passing chains to workers
 	ichainsChan chan IntervalChain // channel for passing interval chains to workers
 
-	exclusiveMutex sync.RWMutex //read-write mutex for running regular and exclusive chains
+	exclusiveMutex  sync.RWMutex //read-write mutex for running regular and exclusive chains
+	exclusiveLocked atomic.Bool  // true while an exclusive chain holds exclusiveMutex
 
-	activeChains     map[int]func() // map of chain ID with context cancel() function to abort chain by request
+	activeChains     map[int]*activeChain // map of chain ID with context cancel() function to abort chain by request
 	activeChainMutex sync.Mutex
 
+	busyCronWorkers     atomic.Int32 // workers executing chains at the moment
+	busyIntervalWorkers atomic.Int32
+
 	intervalChains     map[int]IntervalChain // map of active chains, updated every minute
 	intervalChainMutex sync.Mutex
 
-	shutdown chan struct{} // closed when shutdown is called
-	provider *otel.Provider
-	status   RunStatus
+	programPolicy *ProgramPolicy // allow-list for PROGRAM commands, nil if not configured
+
+	shutdown     chan struct{} // closed when shutdown is called
+	leaderLost   chan struct{} // closed when the leadership is lost in the standby mode
+	queueSignal  chan struct{} // wakes up the run queue processing
+	provider     *otel.Provider
+	status       RunStatus
+	disconnected atomic.Bool // true while reconnecting to the database, chains are not dispatched
+	events       *EventBus
+
+	leaderMutex sync.Mutex
+	leaderAt    time.Time                       // the last time the leadership was confirmed
+	checkLease  func(ctx context.Context) error // checks the lease session in the standby mode
 }
 
 // New returns a new instance of Scheduler
 func New(pge *pgengine.PgEngine, logger log.LoggerIface, provider *otel.Provider) *Scheduler {
-	return &Scheduler{
+	sch := &Scheduler{
 		l:              logger,
 		pgengine:       pge,
 		chainsChan:     make(chan Chain, max(minChannelCapacity, pge.Resource.CronWorkers*2)),
 		ichainsChan:    make(chan IntervalChain, max(minChannelCapacity, pge.Resource.IntervalWorkers*2)),
-		activeChains:   make(map[int]func()), //holds cancel() functions to stop chains
+		activeChains:   make(map[int]*activeChain), //holds cancel() functions to stop chains
 		intervalChains: make(map[int]IntervalChain),
 		shutdown:       make(chan struct{}),
+		leaderLost:     make(chan struct{}),
+		leaderAt:       time.Now(), // the leadership is obtained before the scheduler is created
+		checkLease:     pge.CheckLeadership,
+		queueSignal:    make(chan struct{}, 1),
 		provider:       provider,
 		status:         RunningStatus,
+		events:         NewEventBus(),
+	}
+	if pge.ProgramPolicy > "" {
+		var err error
+		if sch.programPolicy, err = LoadProgramPolicy(pge.ProgramPolicy); err != nil {
+			// fail closed: a broken policy must not open the door to arbitrary programs
+			logger.WithError(err).Error("Cannot load program policy, all PROGRAM commands will be rejected")
+			sch.programPolicy = &ProgramPolicy{}
+		}
 	}
+	return sch
 }
 
 // Shutdown terminates the current session
@@ -76,14 +122,108 @@ func (sch *Scheduler) Config() config.CmdOptions {
 
 // IsReady returns True if the scheduler is in the main loop processing chains
 func (sch *Scheduler) IsReady() bool {
-	return sch.status == RunningStatus
+	return sch.status == RunningStatus && !sch.disconnected.Load()
 }
 
-func (sch *Scheduler) StartChain(ctx context.Context, chainID int) error {
-	return sch.processAsyncChain(ctx, ChainSignal{
-		ConfigID: chainID,
-		Command:  "START",
-		Ts:       time.Now().Unix()})
+// watchConnection checks the database connection every healthCheckTimeout
+func (sch *Scheduler) watchConnection(ctx context.Context) {
+	ticker := time.NewTicker(healthCheckTimeout)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if !sch.checkLeadership(ctx) {
+				return
+			}
+			sch.checkConnection(ctx)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// checkLeadership stops the scheduler if the lease session is lost, since the standby client
+// takes over the client name. Called periodically and before every chain start, so the chain
+// is never started after the standby client might have obtained the leader lock. The leadership
+// confirmed within leadershipCacheTTL is not checked again. If it cannot be checked, the check
+// is retried until leadershipGracePeriod expires. Returns false if the leadership is lost
+func (sch *Scheduler) checkLeadership(ctx context.Context) bool {
+	sch.leaderMutex.Lock() // concurrent callers wait for the single check and use its result
+	defer sch.leaderMutex.Unlock()
+	for {
+		select {
+		case <-sch.leaderLost:
+			return false
+		default:
+		}
+		if time.Since(sch.leaderAt) < leadershipCacheTTL {
+			return true
+		}
+		// the lease session is closed on timeout, so it is not shorter than the grace period
+		checkCtx, cancel := context.WithTimeout(ctx, leadershipGracePeriod)
+		err := sch.checkLease(checkCtx)
+		cancel()
+		switch {
+		case err == nil:
+			sch.leaderAt = time.Now()
+			return true
+		case ctx.Err() != nil:
+			return false
+		case errors.Is(err, pgengine.ErrLeadershipLost) || time.Since(sch.leaderAt) > leadershipGracePeriod:
+			sch.l.WithError(err).Error("Leadership lost, terminating chains")
+			close(sch.leaderLost)
+			return false
+		}
+		sch.l.WithError(err).Warning("Cannot check leadership, retrying...")
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(leadershipRetryInterval):
+		}
+	}
+}
+
+// checkConnection pauses dispatching of chains if the database connection is lost
+// until the session is re-established and active chains are reconciled
+func (sch *Scheduler) checkConnection(ctx context.Context) {
+	pingCtx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	err := sch.pgengine.ConfigDb.Ping(pingCtx)
+	cancel()
+	if err == nil || ctx.Err() != nil {
+		return
+	}
+	sch.l.WithError(err).Error("Database connection lost, pausing chains dispatching")
+	sch.disconnected.Store(true)
+	if err = sch.pgengine.Reconnect(ctx); err != nil {
+		return // context cancelled
+	}
+	if err = sch.pgengine.ReconcileActiveChains(ctx); err != nil {
+		sch.l.WithError(err).Error("Cannot reconcile active chains")
+	}
+	sch.disconnected.Store(false)
+	sch.l.Info("Resuming chains dispatching")
+}
+
+// StartChain adds the chain to the run queue of the client. Overrides, if any, must refer to tasks of the chain
+func (sch *Scheduler) StartChain(ctx context.Context, chainID int, overrides pgengine.ParamOverrides) error {
+	var c Chain
+	if err := sch.pgengine.SelectChain(ctx, &c, chainID); err != nil {
+		return fmt.Errorf("cannot start chain with ID: %d; %w", chainID, err)
+	}
+	if len(overrides) > 0 {
+		var tasks []pgengine.ChainTask
+		if err := sch.pgengine.GetChainElements(ctx, &tasks, chainID); err != nil {
+			return fmt.Errorf("cannot start chain with ID: %d; %w", chainID, err)
+		}
+		if err := overrides.Validate(tasks); err != nil {
+			return fmt.Errorf("cannot start chain with ID: %d; %w", chainID, err)
+		}
+	}
+	if _, err := sch.pgengine.EnqueueChain(ctx, chainID, 0, overrides); err != nil {
+		return fmt.Errorf("cannot start chain with ID: %d; %w", chainID, err)
+	}
+	sch.wakeRunQueue()
+	return nil
 }
 
 func (sch *Scheduler) StopChain(ctx context.Context, chainID int) error {
@@ -116,23 +256,36 @@ func (sch *Scheduler) Run(ctx context.Context) RunStatus {
 	*/
 	sch.l.Info("Accepting asynchronous chains execution requests...")
 	go sch.retrieveAsyncChainsAndRun(ctx)
+	go sch.runQueueLoop(ctx)
 
 	if sch.Config().Start.Debug { //run blocking notifications receiving
 		sch.pgengine.HandleNotifications(ctx)
 		return ContextCancelledStatus
 	}
 
+	watchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go sch.watchConnection(watchCtx)
+
 	sch.l.Debug("Checking for @reboot task chains...")
 	sch.retrieveChainsAndRun(ctx, true)
 
+	if sch.pgengine.RetentionEnabled() {
+		go sch.retentionLoop(ctx)
+	}
+
 	// Use ticker for strict intervals
 	ticker := time.NewTicker(refetchTimeout * time.Second)
 	defer ticker.Stop()
 	for {
-		sch.l.Debug("Checking for task chains...")
-		go sch.retrieveChainsAndRun(ctx, false)
-		sch.l.Debug("Checking for interval task chains...")
-		go sch.retrieveIntervalChainsAndRun(ctx)
+		if sch.disconnected.Load() {
+			sch.l.Info("Waiting for the database connection, chains are not dispatched")
+		} else {
+			sch.l.Debug("Checking for task chains...")
+			go sch.retrieveChainsAndRun(ctx, false)
+			sch.l.Debug("Checking for interval task chains...")
+			go sch.retrieveIntervalChainsAndRun(ctx)
+		}
 
 		select {
 		case <-ticker.C:
@@ -142,6 +295,9 @@ func (sch *Scheduler) Run(ctx context.Context) RunStatus {
 		case <-sch.shutdown:
 			sch.status = ShutdownStatus
 			sch.terminateChains()
+		case <-sch.leaderLost:
+			sch.status = LeadershipLostStatus
+			sch.terminateChains()
 		}
 		if sch.status != RunningStatus {
 			return sch.status
@@ -76,7 +76,7 @@ func TestRun(t *testing.T) {
 	err = pge.ExecuteCustomScripts(context.Background(), "../../samples/ManyTasks.sql")
 	assert.NoError(t, err, "Creating many tasks failed")
 	sch := New(pge, log.Init(config.LoggingOpts{LogLevel: "panic", LogDBLevel: "none"}), otel.NewNoop())
-	assert.NoError(t, sch.StartChain(context.Background(), 1))
+	assert.NoError(t, sch.StartChain(context.Background(), 1, nil))
 	assert.ErrorContains(t, sch.StopChain(context.Background(), -1), "No running chain found")
 	go func() {
 		time.Sleep(10 * time.Second)
@@ -1,26 +1,42 @@
 package scheduler
 
 import (
+	"cmp"
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"os"
 	"os/exec"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
+	"unicode"
 
 	"github.com/cybertec-postgresql/pg_timetable/internal/pgengine"
 )
 
 type commander interface {
 	CombinedOutput(context.Context, string, ...string) ([]byte, error)
+	CombinedOutputEnv(context.Context, []string, string, ...string) ([]byte, error)
 }
 
 type realCommander struct{}
 
 // CombinedOutput executes program command and returns combined stdout and stderr
 func (c realCommander) CombinedOutput(ctx context.Context, command string, args ...string) ([]byte, error) {
+	return c.CombinedOutputEnv(ctx, nil, command, args...)
+}
+
+// CombinedOutputEnv executes program command with additional environment variables
+// and returns combined stdout and stderr
+func (c realCommander) CombinedOutputEnv(ctx context.Context, env []string, command string, args ...string) ([]byte, error) {
 	cmd := exec.CommandContext(ctx, command, args...)
 	cmd.Stdin = nil
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
 	return cmd.CombinedOutput()
 }
 
@@ -31,6 +47,7 @@ var Cmd commander = realCommander{}
 func (sch *Scheduler) ExecuteProgramCommand(ctx context.Context, task *pgengine.ChainTask, paramValues []string) error {
 	var err error
 	var exitCode int
+	var warning bool
 	command := strings.TrimSpace(task.Command)
 	if command == "" {
 		return errors.New("program command cannot be empty")
@@ -40,22 +57,174 @@ func (sch *Scheduler) ExecuteProgramCommand(ctx context.Context, task *pgengine.
 	}
 	for _, val := range paramValues {
 		task.StartedAt = time.Now() // reset start time for each parameter set execution
-		exitCode = 0
+		task.Warning = false
 		params := []string{}
 		if val > "" {
 			if err := json.Unmarshal([]byte(val), &params); err != nil {
 				return err
 			}
 		}
+		if e := sch.checkProgram(ctx, command, params); e != nil {
+			sch.pgengine.LogTaskExecution(context.Background(), task, -1, e.Error(), val)
+			return e
+		}
 		out, e := Cmd.CombinedOutput(ctx, command, params...) // #nosec
+		exitCode, e = checkExitCode(task, e)
+		err = errors.Join(err, e) // accumulate errors for all param sets
+		warning = warning || task.Warning
+		sch.pgengine.LogTaskExecution(context.Background(), task, exitCode, string(out), val)
+	}
+	task.Warning = warning
+	return err
+}
+
+// checkExitCode returns the exit code of the executed program and classifies it according
+// to the task success and warning codes. Task is marked with the warning flag if needed.
+func checkExitCode(task *pgengine.ChainTask, e error) (exitCode int, err error) {
+	var exitError *exec.ExitError
+	switch {
+	case e == nil:
+		exitCode = 0
+	case errors.As(e, &exitError):
+		exitCode = exitError.ExitCode()
+	default: // program cannot be started at all
+		return -1, e
+	}
+	switch {
+	case task.IsSuccessCode(exitCode):
+		return exitCode, nil
+	case task.IsWarningCode(exitCode):
+		task.Warning = true
+		return exitCode, nil
+	case e == nil:
+		return exitCode, fmt.Errorf("exit status %d is not a success code", exitCode)
+	}
+	return exitCode, e
+}
+
+// ExecuteShellScript saves the task command into a temporary file and executes it with the interpreter
+// specified in the shebang line, or with the default one if there is no shebang.
+// Parameters are passed as positional arguments (JSON array) or as PGTT_PARAM_<KEY> environment variables (JSON object).
+func (sch *Scheduler) ExecuteShellScript(ctx context.Context, task *pgengine.ChainTask, paramValues []string) error {
+	var err error
+	var warning bool
+	if strings.TrimSpace(task.Command) == "" {
+		return errors.New("shell script cannot be empty")
+	}
+	interpreter, interpreterArgs := scriptInterpreter(task.Command, sch.Config().ShellInterpreter)
+	script, err := writeScript(task.Command)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(script) }()
+	if len(paramValues) == 0 { //mimic empty param
+		paramValues = []string{""}
+	}
+	for _, val := range paramValues {
+		task.StartedAt = time.Now() // reset start time for each parameter set execution
+		task.Warning = false
+		args, env, e := parseScriptParams(val)
 		if e != nil {
-			exitCode = -1
-			err = errors.Join(err, e) // accumulate errors for all param sets
-			if exitError, ok := err.(*exec.ExitError); ok {
-				exitCode = exitError.ExitCode()
-			}
+			return e
+		}
+		env = append(env,
+			"PGTT_CHAIN_ID="+strconv.Itoa(task.ChainID),
+			"PGTT_TASK_ID="+strconv.Itoa(task.TaskID),
+			"PGTT_CLIENT_NAME="+sch.Config().ClientName)
+		if e := sch.checkProgram(ctx, interpreter, append(slices.Clone(interpreterArgs), args...)); e != nil {
+			sch.pgengine.LogTaskExecution(context.Background(), task, -1, e.Error(), val)
+			return e
 		}
+		cmdArgs := append(append(slices.Clone(interpreterArgs), script), args...)
+		out, e := Cmd.CombinedOutputEnv(ctx, env, interpreter, cmdArgs...) // #nosec
+		exitCode, e := checkExitCode(task, e)
+		err = errors.Join(err, e) // accumulate errors for all param sets
+		warning = warning || task.Warning
 		sch.pgengine.LogTaskExecution(context.Background(), task, exitCode, string(out), val)
 	}
+	task.Warning = warning
 	return err
 }
+
+// scriptInterpreter returns the interpreter and its arguments from the shebang line, e.g.
+// "#!/usr/bin/env python3", or the default interpreter if the script has no shebang
+func scriptInterpreter(script string, defaultInterpreter string) (string, []string) {
+	firstLine, _, _ := strings.Cut(script, "\n")
+	if shebang, ok := strings.CutPrefix(strings.TrimSpace(firstLine), "#!"); ok {
+		if fields := strings.Fields(shebang); len(fields) > 0 {
+			return fields[0], fields[1:]
+		}
+	}
+	return cmp.Or(defaultInterpreter, "sh"), nil
+}
+
+// writeScript saves the script body into a temporary file and returns its name
+func writeScript(script string) (string, error) {
+	f, err := os.CreateTemp("", "pg_timetable_script_*")
+	if err != nil {
+		return "", err
+	}
+	_, err = f.WriteString(script)
+	err = errors.Join(err, f.Close())
+	if err != nil {
+		_ = os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+// parseScriptParams converts parameter value into positional arguments if it's a JSON array,
+// or into environment variables if it's a JSON object. Positional arguments are also
+// available as PGTT_ARGC and PGTT_ARG_1...PGTT_ARG_N environment variables. Object keys are
+// exported with the PGTT_PARAM_ prefix, so parameters cannot change PATH, LD_PRELOAD and alike
+// of the interpreter, e.g. to bypass the program policy with "#!/usr/bin/env python3"
+func parseScriptParams(val string) (args []string, env []string, err error) {
+	val = strings.TrimSpace(val)
+	switch {
+	case val == "":
+		return
+	case strings.HasPrefix(val, "{"):
+		var vars map[string]any
+		if err = json.Unmarshal([]byte(val), &vars); err != nil {
+			return
+		}
+		for k, v := range vars {
+			env = append(env, scriptParamName(k)+"="+scriptValue(v))
+		}
+		slices.Sort(env)
+	default:
+		var values []any
+		if err = json.Unmarshal([]byte(val), &values); err != nil {
+			return
+		}
+		env = append(env, "PGTT_ARGC="+strconv.Itoa(len(values)))
+		for i, v := range values {
+			args = append(args, scriptValue(v))
+			env = append(env, fmt.Sprintf("PGTT_ARG_%d=%s", i+1, args[i]))
+		}
+	}
+	return
+}
+
+// scriptParamName returns the environment variable of the object key, e.g. backup_dir => PGTT_PARAM_BACKUP_DIR
+func scriptParamName(key string) string {
+	return "PGTT_PARAM_" + strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToUpper(r)
+		}
+		return '_'
+	}, key)
+}
+
+// scriptValue returns the string representation of JSON value suitable for the command line
+func scriptValue(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	default:
+		b, _ := json.Marshal(v)
+		return string(b)
+	}
+}
@@ -19,6 +19,9 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
+// realCmd holds the default commander before any test replaces it
+var realCmd = scheduler.Cmd
+
 type testCommander struct{}
 
 // overwrite CombinedOutput function of os/exec so only parameter syntax and return codes are checked...
@@ -29,6 +32,10 @@ func (c testCommander) CombinedOutput(_ context.Context, command string, args ..
 	return fmt.Appendf(nil, "Command %s not found", command), &exec.Error{Name: command, Err: exec.ErrNotFound}
 }
 
+func (c testCommander) CombinedOutputEnv(ctx context.Context, _ []string, command string, args ...string) ([]byte, error) {
+	return c.CombinedOutput(ctx, command, args...)
+}
+
 func TestShellCommandDuration(t *testing.T) {
 	mock, err := pgxmock.NewPool()
 	assert.NoError(t, err)
@@ -85,3 +92,71 @@ func TestShellCommand(t *testing.T) {
 	err = sch.ExecuteProgramCommand(ctx, &pgengine.ChainTask{Command: "ping5"}, []string{`{"param1": "localhost"}`})
 	assert.IsType(t, (*json.UnmarshalTypeError)(nil), err, "Command should fail with malformed json parameter")
 }
+
+func TestShellScript(t *testing.T) {
+	scheduler.Cmd = realCmd
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	pge := pgengine.NewDB(mock, "--log-database-level=none")
+	sch := scheduler.New(pge, log.Init(config.LoggingOpts{LogLevel: "panic", LogDBLevel: "none"}), otel.NewNoop())
+	ctx := context.Background()
+
+	err = sch.ExecuteShellScript(ctx, &pgengine.ChainTask{Command: "  "}, nil)
+	assert.EqualError(t, err, "shell script cannot be empty")
+
+	err = sch.ExecuteShellScript(ctx, &pgengine.ChainTask{Command: "echo foo\necho bar"}, nil)
+	assert.NoError(t, err, "Multi-line script without parameters is OK")
+
+	task := &pgengine.ChainTask{Command: `test "$1" = "a" && test "$2" = "42" && test "$PGTT_ARG_1" = "a" && test "$PGTT_ARGC" = "2"`}
+	err = sch.ExecuteShellScript(ctx, task, []string{`["a", 42]`})
+	assert.NoError(t, err, "Array parameters should be passed as positional arguments and env variables")
+	assert.False(t, task.StartedAt.IsZero())
+
+	err = sch.ExecuteShellScript(ctx, &pgengine.ChainTask{Command: `test "$PGTT_PARAM_FOO_BAR" = "baz"`}, []string{`{"foo-bar": "baz"}`})
+	assert.NoError(t, err, "Object parameters should be passed as prefixed env variables")
+
+	err = sch.ExecuteShellScript(ctx, &pgengine.ChainTask{Command: `test "$PATH" != "/nowhere" && test "$PGTT_PARAM_PATH" = "/nowhere"`},
+		[]string{`{"PATH": "/nowhere"}`})
+	assert.NoError(t, err, "Object parameters should not override environment of the interpreter")
+
+	err = sch.ExecuteShellScript(ctx, &pgengine.ChainTask{Command: "#!/bin/sh -e\nfalse\nexit 0"}, nil)
+	var exitError *exec.ExitError
+	assert.ErrorAs(t, err, &exitError, "Shebang interpreter with arguments should be used")
+
+	err = sch.ExecuteShellScript(ctx, &pgengine.ChainTask{Command: "exit 3"}, []string{"", "[]"})
+	assert.ErrorAs(t, err, &exitError)
+	assert.Equal(t, 3, exitError.ExitCode())
+
+	err = sch.ExecuteShellScript(ctx, &pgengine.ChainTask{Command: "exit 0"}, []string{"foo"})
+	assert.Error(t, err, "Malformed json parameter should fail")
+}
+
+func TestProgramExitCodes(t *testing.T) {
+	scheduler.Cmd = realCmd
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	pge := pgengine.NewDB(mock, "--log-database-level=none")
+	sch := scheduler.New(pge, log.Init(config.LoggingOpts{LogLevel: "panic", LogDBLevel: "none"}), otel.NewNoop())
+	ctx := context.Background()
+
+	task := &pgengine.ChainTask{Command: "sh"}
+	err = sch.ExecuteProgramCommand(ctx, task, []string{`["-c", "exit 2"]`})
+	var exitError *exec.ExitError
+	assert.ErrorAs(t, err, &exitError, "Non-zero exit code is a failure by default")
+	assert.Equal(t, 2, exitError.ExitCode())
+
+	task = &pgengine.ChainTask{Command: "sh", SuccessCodes: []int{0, 1}, WarningCodes: []int{2}}
+	assert.NoError(t, sch.ExecuteProgramCommand(ctx, task, []string{`["-c", "exit 1"]`}))
+	assert.False(t, task.Warning)
+	assert.NoError(t, sch.ExecuteProgramCommand(ctx, task, []string{`["-c", "exit 2"]`}), "Warning code should not fail")
+	assert.True(t, task.Warning)
+	assert.NoError(t, sch.ExecuteProgramCommand(ctx, task, []string{`["-c", "exit 2"]`, `["-c", "exit 0"]`}))
+	assert.True(t, task.Warning, "Warning should be kept for all parameter sets")
+	assert.Error(t, sch.ExecuteProgramCommand(ctx, task, []string{`["-c", "exit 3"]`}))
+
+	task = &pgengine.ChainTask{Command: "exit 0", SuccessCodes: []int{1}}
+	assert.EqualError(t, sch.ExecuteShellScript(ctx, task, nil), "exit status 0 is not a success code")
+	task = &pgengine.ChainTask{Command: "exit 5", WarningCodes: []int{5}}
+	assert.NoError(t, sch.ExecuteShellScript(ctx, task, nil))
+	assert.True(t, task.Warning)
+}
@@ -0,0 +1,119 @@
+package scheduler
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"time"
+
+	"github.com/cybertec-postgresql/pg_timetable/internal/pgengine"
+)
+
+// activeChain is the chain executed by the cron worker, it can be cancelled by request
+type activeChain struct {
+	cancel        context.CancelFunc
+	chain         Chain
+	startedAt     time.Time
+	task          string // current task, empty before the first task is started
+	taskStartedAt time.Time
+}
+
+// Status describes the in-memory state of the scheduler
+type Status struct {
+	Ready           bool                  `json:"ready"`
+	ActiveChains    []ActiveChainStatus   `json:"active_chains"`
+	CronWorkers     WorkerPoolStatus      `json:"cron_workers"`
+	IntervalWorkers WorkerPoolStatus      `json:"interval_workers"`
+	IntervalChains  []IntervalChainStatus `json:"interval_chains"`
+	ExclusiveLocked bool                  `json:"exclusive_locked"` // an exclusive chain is running
+}
+
+// ActiveChainStatus describes the chain being executed
+type ActiveChainStatus struct {
+	ChainID       int        `json:"chain_id"`
+	ChainName     string     `json:"chain_name"`
+	Trigger       string     `json:"trigger"`
+	Exclusive     bool       `json:"exclusive"`
+	StartedAt     time.Time  `json:"started_at"`
+	Task          string     `json:"current_task,omitempty"`
+	TaskStartedAt *time.Time `json:"task_started_at,omitempty"`
+}
+
+// WorkerPoolStatus describes the pool of workers and the channel of chains waiting for them
+type WorkerPoolStatus struct {
+	Workers       int `json:"workers"`
+	Busy          int `json:"busy"`
+	Idle          int `json:"idle"`
+	QueueDepth    int `json:"queue_depth"`
+	QueueCapacity int `json:"queue_capacity"`
+}
+
+// IntervalChainStatus describes the registered interval chain
+type IntervalChainStatus struct {
+	ChainID     int    `json:"chain_id"`
+	ChainName   string `json:"chain_name"`
+	Interval    int    `json:"interval_seconds"`
+	RepeatAfter bool   `json:"repeat_after"`
+}
+
+func poolStatus[T any](workers int, busy int32, ch chan T) WorkerPoolStatus {
+	return WorkerPoolStatus{
+		Workers:       workers,
+		Busy:          int(busy),
+		Idle:          max(workers-int(busy), 0),
+		QueueDepth:    len(ch),
+		QueueCapacity: cap(ch),
+	}
+}
+
+// Status returns the current state of workers, active and interval chains
+func (sch *Scheduler) Status() Status {
+	status := Status{
+		Ready:           sch.IsReady(),
+		ActiveChains:    []ActiveChainStatus{},
+		CronWorkers:     poolStatus(sch.Config().Resource.CronWorkers, sch.busyCronWorkers.Load(), sch.chainsChan),
+		IntervalWorkers: poolStatus(sch.Config().Resource.IntervalWorkers, sch.busyIntervalWorkers.Load(), sch.ichainsChan),
+		IntervalChains:  []IntervalChainStatus{},
+		ExclusiveLocked: sch.exclusiveLocked.Load(),
+	}
+
+	sch.activeChainMutex.Lock()
+	for _, ac := range sch.activeChains {
+		s := ActiveChainStatus{
+			ChainID:   ac.chain.ChainID,
+			ChainName: ac.chain.ChainName,
+			Trigger:   ac.chain.Trigger,
+			Exclusive: ac.chain.ExclusiveExecution,
+			StartedAt: ac.startedAt,
+			Task:      ac.task,
+		}
+		if ac.task != "" {
+			s.TaskStartedAt = &ac.taskStartedAt
+		}
+		status.ActiveChains = append(status.ActiveChains, s)
+	}
+	sch.activeChainMutex.Unlock()
+	slices.SortFunc(status.ActiveChains, func(a, b ActiveChainStatus) int { return cmp.Compare(a.ChainID, b.ChainID) })
+
+	sch.intervalChainMutex.Lock()
+	for _, ichain := range sch.intervalChains {
+		status.IntervalChains = append(status.IntervalChains, IntervalChainStatus{
+			ChainID:     ichain.ChainID,
+			ChainName:   ichain.ChainName,
+			Interval:    ichain.Interval,
+			RepeatAfter: ichain.RepeatAfter,
+		})
+	}
+	sch.intervalChainMutex.Unlock()
+	slices.SortFunc(status.IntervalChains, func(a, b IntervalChainStatus) int { return cmp.Compare(a.ChainID, b.ChainID) })
+	return status
+}
+
+// setActiveTask remembers the task currently executed by the active chain
+func (sch *Scheduler) setActiveTask(chainID int, task pgengine.ChainTask) {
+	sch.activeChainMutex.Lock()
+	defer sch.activeChainMutex.Unlock()
+	if ac, ok := sch.activeChains[chainID]; ok {
+		ac.task, ac.taskStartedAt = task.String(), time.Now()
+	}
+}
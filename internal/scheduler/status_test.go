@@ -0,0 +1,53 @@
+package scheduler
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cybertec-postgresql/pg_timetable/internal/config"
+	"github.com/cybertec-postgresql/pg_timetable/internal/log"
+	"github.com/cybertec-postgresql/pg_timetable/internal/otel"
+	"github.com/cybertec-postgresql/pg_timetable/internal/pgengine"
+	"github.com/pashagolub/pgxmock/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatus(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	pge := pgengine.NewDB(mock, "scheduler_unit_test")
+	sch := New(pge, log.Init(config.LoggingOpts{LogLevel: "panic", LogDBLevel: "none"}), otel.NewNoop())
+
+	status := sch.Status()
+	assert.True(t, status.Ready)
+	assert.Empty(t, status.ActiveChains)
+	assert.Equal(t, pge.Resource.CronWorkers, status.CronWorkers.Idle)
+	assert.Equal(t, cap(sch.chainsChan), status.CronWorkers.QueueCapacity)
+
+	_, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sch.addActiveChain(Chain{ChainID: 42, ChainName: "foo", Trigger: "cron", ExclusiveExecution: true}, cancel)
+	sch.busyCronWorkers.Add(1)
+	sch.Lock(true)
+	sch.setActiveTask(42, pgengine.ChainTask{TaskID: 1, TaskName: "bar"})
+	sch.SendChain(Chain{ChainID: 24})
+	sch.intervalChains[7] = IntervalChain{Chain: Chain{ChainID: 7, ChainName: "baz"}, Interval: 60}
+
+	status = sch.Status()
+	require.Len(t, status.ActiveChains, 1)
+	assert.Equal(t, "foo", status.ActiveChains[0].ChainName)
+	assert.Equal(t, "1|bar", status.ActiveChains[0].Task)
+	assert.NotNil(t, status.ActiveChains[0].TaskStartedAt)
+	assert.True(t, status.ExclusiveLocked)
+	assert.Equal(t, 1, status.CronWorkers.Busy)
+	assert.Equal(t, pge.Resource.CronWorkers-1, status.CronWorkers.Idle)
+	assert.Equal(t, 1, status.CronWorkers.QueueDepth)
+	assert.Equal(t, []IntervalChainStatus{{ChainID: 7, ChainName: "baz", Interval: 60}}, status.IntervalChains)
+
+	sch.Unlock(true)
+	sch.deleteActiveChain(42)
+	status = sch.Status()
+	assert.False(t, status.ExclusiveLocked)
+	assert.Empty(t, status.ActiveChains)
+}
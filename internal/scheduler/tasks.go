@@ -35,7 +35,11 @@ func (sch *Scheduler) executeBuiltinTask(ctx context.Context, task *pgengine.Cha
 		return errors.New("No built-in task found: " + name)
 	}
 	l := log.GetLogger(ctx)
-	l.WithField("name", name).Debugf("Executing builtin task with parameters %+q", paramValues)
+	redacted := make([]string, len(paramValues))
+	for i, val := range paramValues {
+		redacted[i] = task.Redact(val)
+	}
+	l.WithField("name", name).WithField("params", redacted).Debug("Executing builtin task")
 	if len(paramValues) == 0 {
 		stdout, err = f(ctx, sch, "")
 		sch.pgengine.LogTaskExecution(context.Background(), task, errCodes[err == nil], stdout, "")
@@ -125,6 +129,9 @@ func taskCopyToProgram(ctx context.Context, sch *Scheduler, val string) (stdout
 	if err := json.Unmarshal([]byte(val), &ctp); err != nil {
 		return "", err
 	}
+	if err := sch.checkProgram(ctx, ctp.Cmd, ctp.Args); err != nil {
+		return "", err
+	}
 	count, err := sch.pgengine.CopyToProgram(ctx, ctp.SQL, ctp.Cmd, ctp.Args...)
 	if err == nil {
 		stdout = fmt.Sprintf("%d rows copied to program %s", count, ctp.Cmd)
@@ -142,6 +149,9 @@ func taskCopyFromProgram(ctx context.Context, sch *Scheduler, val string) (stdou
 	if err := json.Unmarshal([]byte(val), &cfp); err != nil {
 		return "", err
 	}
+	if err := sch.checkProgram(ctx, cfp.Cmd, cfp.Args); err != nil {
+		return "", err
+	}
 	count, err := sch.pgengine.CopyFromProgram(ctx, cfp.SQL, cfp.Cmd, cfp.Args...)
 	if err == nil {
 		stdout = fmt.Sprintf("%d rows copied from program %s", count, cfp.Cmd)
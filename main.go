@@ -10,6 +10,7 @@ import (
 
 	"github.com/cybertec-postgresql/pg_timetable/internal/api"
 	"github.com/cybertec-postgresql/pg_timetable/internal/config"
+	"github.com/cybertec-postgresql/pg_timetable/internal/grpcapi"
 	"github.com/cybertec-postgresql/pg_timetable/internal/log"
 	"github.com/cybertec-postgresql/pg_timetable/internal/otel"
 	"github.com/cybertec-postgresql/pg_timetable/internal/pgengine"
@@ -46,6 +47,7 @@ const (
 	ExitCodeUserCancel
 	ExitCodeShutdownCommand
 	ExitCodeFatalError
+	ExitCodeLeadershipLost
 )
 
 var exitCode = ExitCodeOK
@@ -55,7 +57,7 @@ var (
 	commit  = "000000"
 	version = "master"
 	date    = "unknown"
-	dbapi   = "00797"
+	dbapi   = "00814"
 )
 
 func printVersion() {
@@ -77,6 +79,7 @@ func run(ctx context.Context, cmdOpts *config.CmdOptions, logger log.LoggerHooke
 		return ExitCodeDBEngineError
 	}
 	defer pge.Finalize()
+	defer apiserver.Stop() // stop serving requests before the database connection is closed
 
 	if cmdOpts.Start.Upgrade {
 		if err := pge.MigrateDb(ctx); err != nil {
@@ -97,6 +100,8 @@ func run(ctx context.Context, cmdOpts *config.CmdOptions, logger log.LoggerHooke
 	if cmdOpts.Start.Init {
 		return ExitCodeOK
 	}
+	apiserver.ChainStore = pge
+	apiserver.HistoryStore = pge
 
 	// Initialise OTel provider (noop when not configured)
 	otelProvider, otelErr := otel.New(ctx, cmdOpts.OTel, cmdOpts.ClientName, version)
@@ -113,11 +118,25 @@ func run(ctx context.Context, cmdOpts *config.CmdOptions, logger log.LoggerHooke
 		}
 	}()
 
+	if err := otelProvider.RegisterLogHookMetrics(cmdOpts.ClientName, func() (int64, int64, int64) {
+		stats := pge.LogHookStats()
+		return stats.Dropped, stats.Spooled, stats.Replayed
+	}); err != nil {
+		logger.WithError(err).Warn("Cannot register log hook metrics")
+	}
+
 	sch := scheduler.New(pge, logger, otelProvider)
+	logger.AddHook(scheduler.EventLogHook{EventBus: sch.Events()})
 	apiserver.APIHandler = sch
+	apiserver.Events = sch.Events()
+	grpcserver := grpcapi.Init(cmdOpts.RESTApi, apiserver, logger)
+	defer grpcserver.Stop()
 
-	if sch.Run(ctx) == scheduler.ShutdownStatus {
+	switch sch.Run(ctx) {
+	case scheduler.ShutdownStatus:
 		return ExitCodeShutdownCommand
+	case scheduler.LeadershipLostStatus:
+		return ExitCodeLeadershipLost
 	}
 	return ExitCodeOK
 }
@@ -0,0 +1,24 @@
+DO $$
+    -- An example of data quality checks using SQL task assertions.
+    -- The first task fails the chain if there are orphaned tasks, the second one only reports a warning
+    -- if some live chains have not been executed during the last day.
+DECLARE
+    v_chain_id bigint;
+BEGIN
+    INSERT INTO timetable.chain (chain_name, run_at, live) VALUES ('data_quality_checks', '@every 1 hour', TRUE)
+    RETURNING chain_id INTO v_chain_id;
+
+    INSERT INTO timetable.task (chain_id, task_order, task_name, kind, command, assertion)
+    VALUES (v_chain_id, 10, 'no_orphaned_tasks', 'SQL',
+        'SELECT task_id FROM timetable.task WHERE chain_id IS NULL',
+        '{"max_rows": 0, "message": "Orphaned tasks found"}');
+
+    INSERT INTO timetable.task (chain_id, task_order, task_name, kind, command, assertion)
+    VALUES (v_chain_id, 20, 'chains_executed', 'SQL',
+        $query$SELECT c.chain_name, max(l.finished) AS last_finished
+FROM timetable.chain c LEFT JOIN timetable.execution_log l USING (chain_id)
+WHERE c.live GROUP BY c.chain_name$query$,
+        $json${"expr": "last_finished > now() - interval '1 day'", "warning": true}$json$);
+END;
+$$
+LANGUAGE PLPGSQL;
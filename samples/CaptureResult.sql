@@ -0,0 +1,28 @@
+DO $$
+    -- An example of capturing SQL task result and passing it to the next task.
+DECLARE
+    v_check_task_id bigint;
+    v_log_task_id bigint;
+    v_chain_id bigint;
+BEGIN
+    INSERT INTO timetable.chain (chain_name, run_at, live) VALUES ('capture_result', '@every 1 minute', TRUE)
+    RETURNING chain_id INTO v_chain_id;
+
+    -- Run SQL as a query and store at most 10 rows as JSON in timetable.execution_log.result
+    INSERT INTO timetable.task (chain_id, task_order, task_name, kind, command, capture_rows)
+    VALUES (v_chain_id, 10, 'sessions', 'SQL', 
+        $query$SELECT state, count(*) AS cnt FROM pg_stat_activity WHERE state IS NOT NULL GROUP BY state$query$, 10)
+    RETURNING task_id INTO v_check_task_id;
+
+    -- Captured rows are available to the next tasks as .Results.<task_name> in {"$template": ...} parameter values
+    INSERT INTO timetable.task (chain_id, task_order, kind, command)
+    VALUES (v_chain_id, 20, 'BUILTIN', 'Log')
+    RETURNING task_id INTO v_log_task_id;
+
+    INSERT INTO timetable.parameter (task_id, order_id, value)
+    VALUES (v_log_task_id, 1, 
+        '{"sessions": {"$template": "{{ json .Results.sessions }}"},
+          "summary": {"$template": "{{ range .Results.sessions }}{{ .state }}={{ .cnt }} {{ end }}"}}'::jsonb);
+END;
+$$
+LANGUAGE PLPGSQL;
@@ -0,0 +1,15 @@
+-- An example for using the SHELL task with an inline script body.
+-- Positional parameters are available as $1, $2, ... and as PGTT_ARG_1, PGTT_ARG_2, ... environment variables.
+SELECT timetable.add_job(
+    job_name            => 'shell_script_chain',
+    job_schedule        => '* * * * *',
+    job_kind            => 'SHELL'::timetable.command_kind,
+    job_command         => $SCRIPT$
+set -e
+echo "Running chain $PGTT_CHAIN_ID on client $PGTT_CLIENT_NAME"
+for dir in "$@"; do
+    du -sh "$dir"
+done
+$SCRIPT$,
+    job_parameters      => '["/tmp", "/var/tmp"]'::jsonb
+) as chain_id;